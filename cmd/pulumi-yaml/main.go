@@ -0,0 +1,165 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// pulumi-yaml is a command line tool for working with Pulumi programs written in YAML. Its `fmt` command rewrites
+// templates in a canonical layout, keeping their comments.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/format"
+)
+
+const usage = `Usage: pulumi-yaml <command> [arguments]
+
+Commands:
+  fmt [--check] [paths...]  format Pulumi YAML templates
+`
+
+// stackConfig matches the names of stack configuration files, which are not templates.
+var stackConfig = regexp.MustCompile(`^Pulumi\..+\.ya?ml$`)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command given by args and returns the process's exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "fmt":
+		return runFmt(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// runFmt formats the templates at the given paths. A directory's YAML files are formatted, other than stack
+// configuration files; its subdirectories are not searched. With --check, the files that are not formatted are
+// listed and left unchanged.
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	check := flags.Bool("check", false, "list the files that are not formatted instead of rewriting them, "+
+		"and exit with status 1 if there are any")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := templateFiles(paths)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	status := 0
+	for _, path := range files {
+		changed, err := formatFile(path, !*check)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+		if changed && *check {
+			fmt.Fprintln(stdout, path)
+			status = 1
+		}
+	}
+	return status
+}
+
+// templateFiles returns the files named by paths, and the YAML files in the directories named by paths.
+func templateFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			ext := filepath.Ext(name)
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") || stackConfig.MatchString(name) {
+				continue
+			}
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	return files, nil
+}
+
+// formatFile formats the template at path, and returns true if formatting changed it. The file is rewritten only if
+// write is true.
+func formatFile(path string, write bool) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	formatted, diags := format.Format(path, source)
+	if diags.HasErrors() {
+		var messages []string
+		for _, diag := range diags {
+			if diag.Subject == nil {
+				messages = append(messages, fmt.Sprintf("%s: %s", path, diag.Summary))
+			} else {
+				messages = append(messages, diag.Error())
+			}
+		}
+		return false, fmt.Errorf("%s", strings.Join(messages, "\n"))
+	}
+	if bytes.Equal(source, formatted) {
+		return false, nil
+	}
+
+	if write {
+		if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
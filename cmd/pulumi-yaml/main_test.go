@@ -0,0 +1,73 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFmt(t *testing.T) {
+	t.Parallel()
+
+	const unformatted = "runtime: yaml\nname: test\n"
+	const formatted = "name: test\nruntime: yaml\n"
+	const stackConfig = "config:\n  test:b: 2\n  test:a: 1\n"
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "Pulumi.yaml")
+	stack := filepath.Join(dir, "Pulumi.dev.yaml")
+	require.NoError(t, os.WriteFile(project, []byte(unformatted), 0o600))
+	require.NoError(t, os.WriteFile(stack, []byte(stackConfig), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# test\n"), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"fmt", "--check", dir}, &stdout, &stderr))
+	assert.Equal(t, project+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+	source, err := os.ReadFile(project)
+	require.NoError(t, err)
+	assert.Equal(t, unformatted, string(source), "--check must not rewrite files")
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"fmt", dir}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+	source, err = os.ReadFile(project)
+	require.NoError(t, err)
+	assert.Equal(t, formatted, string(source))
+	source, err = os.ReadFile(stack)
+	require.NoError(t, err)
+	assert.Equal(t, stackConfig, string(source), "stack configuration must not be formatted")
+
+	assert.Equal(t, 0, run([]string{"fmt", "--check", project}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+}
+
+func TestFmtInvalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "Pulumi.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("- a\n"), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"fmt", path}, &stdout, &stderr))
+	assert.Equal(t, path+": Top level of '"+path+"' must be an object\n", stderr.String())
+}
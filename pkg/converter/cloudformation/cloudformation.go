@@ -0,0 +1,488 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudformation converts AWS CloudFormation templates into Pulumi YAML programs that use
+// the resources of the aws-native provider.
+package cloudformation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+)
+
+// PackageName is the name of the package whose resources converted programs use.
+const PackageName = "aws-native"
+
+// IsTemplate returns true if source, a JSON or YAML document, looks like a CloudFormation template:
+// it declares a template format version, or at least one resource with an AWS:: type.
+func IsTemplate(filename string, source []byte) bool {
+	template, diags := decode(filename, source)
+	if diags.HasErrors() {
+		return false
+	}
+	if get(template, "AWSTemplateFormatVersion") != nil {
+		return true
+	}
+	resources, _ := get(template, "Resources").(*syntax.ObjectNode)
+	for _, kvp := range entries(resources) {
+		resource, ok := kvp.Value.(*syntax.ObjectNode)
+		if !ok {
+			continue
+		}
+		if typ, ok := get(resource, "Type").(*syntax.StringNode); ok && strings.HasPrefix(typ.Value(), "AWS::") {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert converts the CloudFormation template in source, which was read from filename, into a
+// Pulumi YAML program. Resource types and property names are resolved against the aws-native
+// package, which is loaded from loader.
+//
+// Parameters become config, Conditions become conditions, Mappings become mappings and Outputs
+// become outputs. The intrinsic functions Ref, Fn::GetAtt and Fn::Sub become interpolations, and
+// the remaining intrinsics that have a Pulumi YAML equivalent become calls to builtins. Anything
+// that cannot be converted is reported as a diagnostic.
+//
+// The program is named after filename. The returned error is non-nil only if the aws-native
+// package cannot be loaded.
+func Convert(
+	ctx context.Context, filename string, source []byte, loader pulumiyaml.PackageLoader,
+) (*syntax.ObjectNode, syntax.Diagnostics, error) {
+	template, diags := decode(filename, source)
+	if diags.HasErrors() {
+		return nil, diags, nil
+	}
+
+	pkg, err := loader.LoadPackage(ctx, &schema.PackageDescriptor{Name: PackageName})
+	if err != nil {
+		return nil, diags, fmt.Errorf("loading package %q: %w", PackageName, err)
+	}
+
+	c := converter{
+		pkg:        pkg,
+		parameters: map[string]bool{},
+		mappings:   map[string]bool{},
+		conditions: map[string]bool{},
+		resources:  map[string]*resourceType{},
+		pseudo:     map[string]bool{},
+	}
+	program := c.convertTemplate(programName(filename), template)
+	diags.Extend(c.diags...)
+	return program, diags, nil
+}
+
+// ConvertToYAML is like Convert, but returns the text of the Pulumi YAML program.
+func ConvertToYAML(
+	ctx context.Context, filename string, source []byte, loader pulumiyaml.PackageLoader,
+) ([]byte, syntax.Diagnostics, error) {
+	program, diags, err := Convert(ctx, filename, source, loader)
+	if err != nil || program == nil {
+		return nil, diags, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	diags.Extend(encoding.EncodeYAML(enc, program)...)
+	if err := enc.Close(); err != nil {
+		return nil, diags, err
+	}
+	return buf.Bytes(), diags, nil
+}
+
+func decode(filename string, source []byte) (*syntax.ObjectNode, syntax.Diagnostics) {
+	// JSON is a subset of YAML, so both forms of template are read by the YAML decoder.
+	return encoding.DecodeYAML(filename, yaml.NewDecoder(bytes.NewReader(source)), tagDecoder{})
+}
+
+// programName returns the name of the program converted from filename: its base name, without
+// the extension.
+func programName(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// tagDecoder decodes the short forms of CloudFormation's intrinsic functions, e.g. `!Ref name`, as
+// their long forms, e.g. `Ref: name`.
+type tagDecoder struct{}
+
+func (d tagDecoder) DecodeTag(filename string, n *yaml.Node) (syntax.Node, syntax.Diagnostics, bool) {
+	if !strings.HasPrefix(n.Tag, "!") || strings.HasPrefix(n.Tag, "!!") {
+		return nil, nil, false
+	}
+
+	value, diags := encoding.UnmarshalYAMLNode(filename, n, d)
+	if value == nil {
+		return nil, diags, true
+	}
+
+	name := n.Tag[1:]
+	switch name {
+	case "Ref", "Condition":
+	case "GetAtt":
+		name = "Fn::GetAtt"
+		// The short form of Fn::GetAtt names the resource and attribute in one string, e.g.
+		// `!GetAtt Bucket.Arn`.
+		if s, ok := value.(*syntax.StringNode); ok {
+			if resource, attribute, ok := strings.Cut(s.Value(), "."); ok {
+				value = syntax.ListSyntax(s.Syntax(),
+					syntax.StringSyntax(s.Syntax(), resource), syntax.StringSyntax(s.Syntax(), attribute))
+			}
+		}
+	default:
+		name = "Fn::" + name
+	}
+	key := syntax.StringSyntax(value.Syntax(), name)
+	return syntax.ObjectSyntax(value.Syntax(), syntax.ObjectPropertySyntax(value.Syntax(), key, value)), diags, true
+}
+
+// get returns the value of the property of obj named key, or nil if there is no such property.
+func get(obj *syntax.ObjectNode, key string) syntax.Node {
+	for _, kvp := range entries(obj) {
+		if kvp.Key.Value() == key {
+			return kvp.Value
+		}
+	}
+	return nil
+}
+
+// entries returns the properties of obj, which may be nil.
+func entries(obj *syntax.ObjectNode) []syntax.ObjectPropertyDef {
+	if obj == nil {
+		return nil
+	}
+	props := make([]syntax.ObjectPropertyDef, obj.Len())
+	for i := range props {
+		props[i] = obj.Index(i)
+	}
+	return props
+}
+
+// resourceType describes the type of a resource declared by the template.
+type resourceType struct {
+	token pulumiyaml.ResourceTypeToken
+	// hint is nil if the resource's type could not be resolved.
+	hint *schema.ResourceType
+}
+
+type converter struct {
+	pkg pulumiyaml.Package
+
+	// The names of the entities declared by the template.
+	parameters map[string]bool
+	mappings   map[string]bool
+	conditions map[string]bool
+	resources  map[string]*resourceType
+
+	// pseudo holds the names of the pseudo parameters the template refers to.
+	pseudo map[string]bool
+
+	diags syntax.Diagnostics
+}
+
+func (c *converter) error(node syntax.Node, summary, detail string) {
+	c.diags.Extend(syntax.NodeError(node, summary, detail))
+}
+
+func (c *converter) warning(node syntax.Node, summary, detail string) {
+	c.diags.Extend(syntax.Warning(node.Syntax().Range(), summary, detail))
+}
+
+// object returns node as an object, reporting an error if it is not one.
+func (c *converter) object(node syntax.Node, what string) (*syntax.ObjectNode, bool) {
+	obj, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		c.error(node, fmt.Sprintf("%s must be an object", what), "")
+	}
+	return obj, ok
+}
+
+func (c *converter) convertTemplate(name string, template *syntax.ObjectNode) *syntax.ObjectNode {
+	var description syntax.Node
+	var parameters, mappings, conditions, resources, outputs *syntax.ObjectNode
+	for _, kvp := range entries(template) {
+		switch kvp.Key.Value() {
+		case "AWSTemplateFormatVersion":
+			// Only one version has ever been published.
+		case "Description":
+			description = kvp.Value
+		case "Parameters":
+			parameters, _ = c.object(kvp.Value, "Parameters")
+		case "Mappings":
+			mappings, _ = c.object(kvp.Value, "Mappings")
+		case "Conditions":
+			conditions, _ = c.object(kvp.Value, "Conditions")
+		case "Resources":
+			resources, _ = c.object(kvp.Value, "Resources")
+		case "Outputs":
+			outputs, _ = c.object(kvp.Value, "Outputs")
+		case "Transform":
+			c.error(kvp.Key, "transforms are not supported",
+				"Expand the template's transforms, e.g. with `aws cloudformation get-template --template-stage Processed`, "+
+					"and convert the processed template.")
+		case "Metadata", "Rules":
+			c.warning(kvp.Key, fmt.Sprintf("%s is not supported and has been dropped", kvp.Key.Value()), "")
+		default:
+			c.warning(kvp.Key, fmt.Sprintf("unknown template section %q has been dropped", kvp.Key.Value()), "")
+		}
+	}
+
+	// Declare every entity before converting any expressions, so that references may be resolved.
+	for _, kvp := range entries(parameters) {
+		c.parameters[kvp.Key.Value()] = true
+	}
+	for _, kvp := range entries(mappings) {
+		c.mappings[kvp.Key.Value()] = true
+	}
+	for _, kvp := range entries(conditions) {
+		c.conditions[kvp.Key.Value()] = true
+	}
+	for _, kvp := range entries(resources) {
+		c.resources[kvp.Key.Value()] = c.resolveResourceType(kvp.Value)
+	}
+
+	program := []syntax.ObjectPropertyDef{
+		property("name", syntax.String(name)),
+		property("runtime", syntax.String("yaml")),
+	}
+	if description != nil {
+		program = append(program, property("description", c.convertLiteral(description)))
+	}
+	if len(entries(parameters)) != 0 {
+		program = append(program, property("config", c.convertParameters(parameters)))
+	}
+	if len(entries(mappings)) != 0 {
+		program = append(program, property("mappings", c.convertLiteral(mappings)))
+	}
+	if len(entries(conditions)) != 0 {
+		var converted []syntax.ObjectPropertyDef
+		for _, kvp := range entries(conditions) {
+			converted = append(converted, property(kvp.Key.Value(), c.convertExpr(kvp.Value, nil)))
+		}
+		program = append(program, property("conditions", syntax.Object(converted...)))
+	}
+
+	// The resources and outputs are converted before the variables, which hold the pseudo
+	// parameters that they refer to.
+	var convertedResources syntax.Node
+	if len(entries(resources)) != 0 {
+		var converted []syntax.ObjectPropertyDef
+		for _, kvp := range entries(resources) {
+			if resource, ok := c.convertResource(kvp.Key, kvp.Value); ok {
+				converted = append(converted, property(kvp.Key.Value(), resource))
+			}
+		}
+		convertedResources = syntax.Object(converted...)
+	} else {
+		c.error(template, "a template must declare at least one resource", "")
+	}
+	var convertedOutputs syntax.Node
+	if len(entries(outputs)) != 0 {
+		var converted []syntax.ObjectPropertyDef
+		for _, kvp := range entries(outputs) {
+			if output, ok := c.convertOutput(kvp.Key, kvp.Value); ok {
+				converted = append(converted, property(kvp.Key.Value(), output))
+			}
+		}
+		convertedOutputs = syntax.Object(converted...)
+	}
+
+	if variables := c.pseudoParameterVariables(); variables != nil {
+		program = append(program, property("variables", variables))
+	}
+	if convertedResources != nil {
+		program = append(program, property("resources", convertedResources))
+	}
+	if convertedOutputs != nil {
+		program = append(program, property("outputs", convertedOutputs))
+	}
+	return syntax.Object(program...)
+}
+
+func property(key string, value syntax.Node) syntax.ObjectPropertyDef {
+	return syntax.ObjectProperty(syntax.String(key), value)
+}
+
+// parameterTypes maps the parameter types of CloudFormation to config types.
+var parameterTypes = map[string]string{
+	"String":             "String",
+	"Number":             "Number",
+	"List<Number>":       "List<Number>",
+	"CommaDelimitedList": "List<String>",
+}
+
+func (c *converter) convertParameters(parameters *syntax.ObjectNode) *syntax.ObjectNode {
+	var config []syntax.ObjectPropertyDef
+	for _, kvp := range entries(parameters) {
+		decl, ok := c.object(kvp.Value, fmt.Sprintf("parameter %s", kvp.Key.Value()))
+		if !ok {
+			continue
+		}
+
+		typeNode, ok := get(decl, "Type").(*syntax.StringNode)
+		if !ok {
+			c.error(kvp.Key, fmt.Sprintf("parameter %s must have a string Type", kvp.Key.Value()), "")
+			continue
+		}
+		typ, ok := parameterTypes[typeNode.Value()]
+		switch {
+		case ok:
+		case strings.HasPrefix(typeNode.Value(), "AWS::SSM::Parameter::"):
+			c.warning(typeNode, fmt.Sprintf("parameter type %s is not supported", typeNode.Value()),
+				"The parameter has been converted to a String; set it to the value of the SSM parameter.")
+			typ = "String"
+		case strings.HasPrefix(typeNode.Value(), "AWS::"):
+			typ = "String"
+		case strings.HasPrefix(typeNode.Value(), "List<AWS::"):
+			typ = "List<String>"
+		default:
+			c.error(typeNode, fmt.Sprintf("unknown parameter type %s", typeNode.Value()), "")
+			continue
+		}
+
+		param := []syntax.ObjectPropertyDef{property("type", syntax.String(typ))}
+		if def := get(decl, "Default"); def != nil {
+			if value, ok := c.convertParameterDefault(def, typ); ok {
+				param = append(param, property("default", value))
+			}
+		}
+		if noEcho := get(decl, "NoEcho"); noEcho != nil && isTrue(noEcho) {
+			param = append(param, property("secret", syntax.Boolean(true)))
+		}
+		for _, constraint := range []string{
+			"AllowedValues", "AllowedPattern", "MinLength", "MaxLength", "MinValue", "MaxValue",
+		} {
+			if node := get(decl, constraint); node != nil {
+				c.warning(node, fmt.Sprintf("parameter constraint %s is not supported and has been dropped", constraint), "")
+			}
+		}
+		config = append(config, property(kvp.Key.Value(), syntax.Object(param...)))
+	}
+	return syntax.Object(config...)
+}
+
+// convertParameterDefault converts the default value of a parameter of config type typ.
+// CloudFormation writes the defaults of list parameters as comma-delimited strings.
+func (c *converter) convertParameterDefault(def syntax.Node, typ string) (syntax.Node, bool) {
+	text, ok := scalarText(def)
+	if !ok {
+		if typ == "String" {
+			c.error(def, "the default value of a String parameter must be a scalar", "")
+			return nil, false
+		}
+		return c.convertLiteral(def), true
+	}
+
+	var elements []string
+	if strings.HasPrefix(typ, "List<") {
+		elements = strings.Split(text, ",")
+	}
+	switch typ {
+	case "String":
+		return syntax.String(escape(text)), true
+	case "Number":
+		return c.parseNumber(def, text)
+	case "List<String>":
+		values := make([]syntax.Node, len(elements))
+		for i, e := range elements {
+			values[i] = syntax.String(escape(e))
+		}
+		return syntax.List(values...), true
+	default:
+		values := make([]syntax.Node, len(elements))
+		for i, e := range elements {
+			n, ok := c.parseNumber(def, strings.TrimSpace(e))
+			if !ok {
+				return nil, false
+			}
+			values[i] = n
+		}
+		return syntax.List(values...), true
+	}
+}
+
+func (c *converter) parseNumber(node syntax.Node, text string) (syntax.Node, bool) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		c.error(node, fmt.Sprintf("%q is not a number", text), "")
+		return nil, false
+	}
+	return syntax.Number(f), true
+}
+
+// scalarText returns the text of a string, number or boolean.
+func scalarText(node syntax.Node) (string, bool) {
+	switch node := node.(type) {
+	case *syntax.StringNode:
+		return node.Value(), true
+	case *syntax.NumberNode:
+		return strconv.FormatFloat(node.Value(), 'f', -1, 64), true
+	case *syntax.BooleanNode:
+		return strconv.FormatBool(node.Value()), true
+	default:
+		return "", false
+	}
+}
+
+// isTrue returns true if node is the boolean true, or the string "true". CloudFormation accepts
+// both for boolean attributes such as NoEcho.
+func isTrue(node syntax.Node) bool {
+	text, ok := scalarText(node)
+	return ok && strings.EqualFold(text, "true")
+}
+
+// escape escapes the `$` characters of a string, so that Pulumi YAML does not read the string as
+// an interpolation.
+func escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// convertLiteral converts a value that cannot contain intrinsic functions, such as a mapping.
+func (c *converter) convertLiteral(node syntax.Node) syntax.Node {
+	switch node := node.(type) {
+	case *syntax.StringNode:
+		return syntax.String(escape(node.Value()))
+	case *syntax.NumberNode:
+		return syntax.Number(node.Value())
+	case *syntax.BooleanNode:
+		return syntax.Boolean(node.Value())
+	case *syntax.ListNode:
+		elements := make([]syntax.Node, node.Len())
+		for i := range elements {
+			elements[i] = c.convertLiteral(node.Index(i))
+		}
+		return syntax.List(elements...)
+	case *syntax.ObjectNode:
+		props := make([]syntax.ObjectPropertyDef, node.Len())
+		for i, kvp := range entries(node) {
+			props[i] = property(escape(kvp.Key.Value()), c.convertLiteral(kvp.Value))
+		}
+		return syntax.Object(props...)
+	default:
+		return syntax.Null()
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudformation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+)
+
+// awsNativePackage is a minimal stand-in for the aws-native schema, declaring the resources used by
+// the test templates.
+type awsNativePackage struct{}
+
+func object(props ...*schema.Property) *schema.ObjectType {
+	return &schema.ObjectType{Properties: props}
+}
+
+func prop(name string, typ schema.Type) *schema.Property {
+	return &schema.Property{Name: name, Type: typ}
+}
+
+var tagType = &schema.ArrayType{ElementType: object(prop("key", schema.StringType), prop("value", schema.StringType))}
+
+var awsNativeResources = map[string]*schema.Resource{
+	"aws-native:s3:Bucket": {
+		InputProperties: []*schema.Property{
+			prop("bucketName", schema.StringType),
+			prop("versioningConfiguration", object(prop("status", schema.StringType))),
+			prop("websiteConfiguration", object(prop("indexDocument", schema.StringType))),
+			prop("tags", tagType),
+		},
+		Properties: []*schema.Property{
+			prop("arn", schema.StringType),
+			prop("bucketName", schema.StringType),
+			prop("websiteUrl", schema.StringType),
+		},
+	},
+	"aws-native:ec2:Vpc": {
+		InputProperties: []*schema.Property{
+			prop("cidrBlock", schema.StringType),
+			prop("enableDnsSupport", schema.BoolType),
+		},
+		Properties: []*schema.Property{
+			prop("cidrBlock", schema.StringType),
+			prop("vpcId", schema.StringType),
+		},
+	},
+	"aws-native:iam:Role": {
+		InputProperties: []*schema.Property{
+			prop("assumeRolePolicyDocument", schema.AnyType),
+			prop("policies", &schema.ArrayType{
+				ElementType: object(prop("policyDocument", schema.AnyType), prop("policyName", schema.StringType)),
+			}),
+		},
+		Properties: []*schema.Property{prop("arn", schema.StringType)},
+	},
+}
+
+func (awsNativePackage) Name() string             { return PackageName }
+func (awsNativePackage) Version() *semver.Version { return nil }
+
+func (awsNativePackage) ResolveResource(typeName string) (pulumiyaml.ResourceTypeToken, error) {
+	if _, ok := awsNativeResources[typeName]; ok {
+		return pulumiyaml.ResourceTypeToken(typeName), nil
+	}
+	return "", fmt.Errorf("unknown resource %q", typeName)
+}
+
+func (awsNativePackage) ResolveFunction(typeName string) (pulumiyaml.FunctionTypeToken, error) {
+	return pulumiyaml.FunctionTypeToken(typeName), nil
+}
+
+func (awsNativePackage) IsComponent(pulumiyaml.ResourceTypeToken) (bool, error) { return false, nil }
+
+func (awsNativePackage) IsResourcePropertySecret(pulumiyaml.ResourceTypeToken, string) (bool, error) {
+	return false, nil
+}
+
+func (awsNativePackage) ResourceTypeHint(typeName pulumiyaml.ResourceTypeToken) *schema.ResourceType {
+	resource, ok := awsNativeResources[string(typeName)]
+	if !ok {
+		return nil
+	}
+	return &schema.ResourceType{Token: string(typeName), Resource: resource}
+}
+
+func (awsNativePackage) FunctionTypeHint(pulumiyaml.FunctionTypeToken) *schema.Function { return nil }
+
+func (awsNativePackage) ResourceConstants(pulumiyaml.ResourceTypeToken) map[string]interface{} {
+	return nil
+}
+
+type awsNativeLoader struct{}
+
+func (awsNativeLoader) LoadPackage(_ context.Context, descriptor *schema.PackageDescriptor) (pulumiyaml.Package, error) {
+	if descriptor.Name != PackageName {
+		return nil, fmt.Errorf("unexpected package %q", descriptor.Name)
+	}
+	return awsNativePackage{}, nil
+}
+
+func (awsNativeLoader) Close() {}
+
+func diagStrings(diags syntax.Diagnostics) []string {
+	var s []string
+	for _, d := range diags {
+		msg := d.Summary
+		if d.Subject != nil {
+			msg = fmt.Sprintf("%v:%v:%v: %s", d.Subject.Filename, d.Subject.Start.Line, d.Subject.Start.Column, msg)
+		}
+		s = append(s, msg)
+	}
+	return s
+}
+
+// TestConvertTemplates converts each template in testdata, and compares the result with the
+// Pulumi.yaml beside it. Set PULUMI_ACCEPT to update the expected programs.
+func TestConvertTemplates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dir      string
+		filename string
+		warnings []string
+	}{
+		{
+			dir:      "website",
+			filename: "template.yaml",
+			warnings: []string{
+				"template.yaml:7:20: parameter constraint AllowedValues is not supported and has been dropped",
+				"template.yaml:50:5: Export is not supported and has been dropped",
+			},
+		},
+		{
+			dir:      "network",
+			filename: "template.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join("testdata", tt.dir)
+			source, err := os.ReadFile(filepath.Join(dir, tt.filename))
+			require.NoError(t, err)
+			require.True(t, IsTemplate(tt.filename, source))
+
+			program, diags, err := ConvertToYAML(t.Context(), tt.filename, source, awsNativeLoader{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.warnings, diagStrings(diags))
+
+			// The program must be valid Pulumi YAML.
+			_, tdiags, err := pulumiyaml.LoadYAMLBytes("Pulumi.yaml", program)
+			require.NoError(t, err)
+			require.False(t, tdiags.HasErrors(), tdiags.Error())
+
+			expectedPath := filepath.Join(dir, "Pulumi.yaml")
+			if cmdutil.IsTruthy(os.Getenv("PULUMI_ACCEPT")) {
+				require.NoError(t, os.WriteFile(expectedPath, program, 0o600))
+			}
+			expected, err := os.ReadFile(expectedPath)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(program))
+		})
+	}
+}
+
+func TestConvertDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name: "unsupported intrinsic",
+			source: `Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Select [0, !GetAZs ""]
+`,
+			expected: []string{"template.yaml:5:31: unsupported intrinsic function Fn::GetAZs"},
+		},
+		{
+			name: "unknown reference",
+			source: `Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Ref Missing
+`,
+			expected: []string{"template.yaml:5:19: unknown reference to Missing"},
+		},
+		{
+			name: "unsupported resource type",
+			source: `Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+  Custom:
+    Type: Custom::Thing
+  Unknown:
+    Type: AWS::Frob::Nicator
+`,
+			expected: []string{
+				"template.yaml:5:11: resource type Custom::Thing is not supported",
+				"template.yaml:7:11: unable to find an aws-native resource for AWS::Frob::Nicator",
+			},
+		},
+		{
+			name: "transform",
+			source: `Transform: AWS::Serverless-2016-10-31
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`,
+			expected: []string{"template.yaml:1:1: transforms are not supported"},
+		},
+		{
+			name: "undeclared condition",
+			source: `Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Condition: IsProd
+`,
+			expected: []string{"template.yaml:4:16: Condition must name a condition declared by the template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, diags, err := Convert(t.Context(), "template.yaml", []byte(tt.source), awsNativeLoader{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, diagStrings(diags))
+		})
+	}
+}
+
+func TestIsTemplate(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsTemplate("a.yaml", []byte("AWSTemplateFormatVersion: 2010-09-09\n")))
+	assert.True(t, IsTemplate("a.yaml", []byte("Resources:\n  B:\n    Type: AWS::S3::Bucket\n")))
+	assert.False(t, IsTemplate("Pulumi.yaml", []byte("name: test\nruntime: yaml\n")))
+	assert.False(t, IsTemplate("a.json", []byte("[1, 2]")))
+}
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]string{
+		"Bucket":      "Bucket",
+		"VPC":         "Vpc",
+		"DBInstance":  "DbInstance",
+		"VPCEndpoint": "VpcEndpoint",
+		"EC2Fleet":    "Ec2Fleet",
+	} {
+		assert.Equal(t, expected, pascalCase(name))
+	}
+	assert.Equal(t, "vpcId", camelCase("VPCId"))
+	assert.Equal(t, "bucketName", camelCase("BucketName"))
+}
@@ -0,0 +1,394 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudformation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+)
+
+// builtins maps the intrinsic functions that have a Pulumi YAML equivalent to the name of the
+// builtin and the number of arguments the intrinsic takes. An intrinsic whose arguments are not a
+// list has an arity of 0.
+var builtins = map[string]struct {
+	name  string
+	arity int
+}{
+	"Fn::Join":         {"fn::join", 2},
+	"Fn::Select":       {"fn::select", 2},
+	"Fn::Split":        {"fn::split", 2},
+	"Fn::Base64":       {"fn::toBase64", 0},
+	"Fn::FindInMap":    {"fn::findInMap", 3},
+	"Fn::If":           {"fn::if", 3},
+	"Fn::Equals":       {"fn::equals", 2},
+	"Fn::Not":          {"fn::not", 1},
+	"Fn::And":          {"fn::and", -1},
+	"Fn::Or":           {"fn::or", -1},
+	"Fn::Length":       {"fn::length", 0},
+	"Fn::ToJsonString": {"fn::toJSON", 0},
+}
+
+// pseudoParameter describes a pseudo parameter that is converted to a variable, which holds the
+// result of an aws-native function.
+type pseudoParameter struct {
+	name     string
+	variable string
+	function string
+	result   string
+}
+
+var pseudoParameters = []pseudoParameter{
+	{"AWS::AccountId", "awsAccountId", "aws-native:index:getAccountId", "accountId"},
+	{"AWS::Partition", "awsPartition", "aws-native:index:getPartition", "partition"},
+	{"AWS::Region", "awsRegion", "aws-native:index:getRegion", "region"},
+	{"AWS::URLSuffix", "awsUrlSuffix", "aws-native:index:getUrlSuffix", "urlSuffix"},
+}
+
+func builtin(name string, args syntax.Node) syntax.Node {
+	return syntax.Object(property(name, args))
+}
+
+func interpolate(access string) syntax.Node {
+	return syntax.String("${" + access + "}")
+}
+
+// convertExpr converts an expression whose value has type typ. If the type is not known, typ is
+// nil, and the keys of any objects in the expression are kept as written.
+func (c *converter) convertExpr(node syntax.Node, typ schema.Type) syntax.Node {
+	if obj, ok := node.(*syntax.ObjectNode); ok && obj.Len() == 1 {
+		kvp := obj.Index(0)
+		name := kvp.Key.Value()
+		// Condition is also a common property name, e.g. of an IAM policy statement, so it is only read
+		// as an intrinsic if it names a condition.
+		if cond, ok := kvp.Value.(*syntax.StringNode); ok && name == "Condition" && c.conditions[cond.Value()] {
+			return interpolate(cond.Value())
+		}
+		if name == "Ref" || strings.HasPrefix(name, "Fn::") {
+			return c.convertIntrinsic(kvp.Key, kvp.Value)
+		}
+	}
+
+	switch node := node.(type) {
+	case *syntax.ListNode:
+		var elementType schema.Type
+		if t, ok := underlyingType(typ).(*schema.ArrayType); ok {
+			elementType = t.ElementType
+		}
+		elements := make([]syntax.Node, node.Len())
+		for i := range elements {
+			elements[i] = c.convertExpr(node.Index(i), elementType)
+		}
+		return syntax.List(elements...)
+	case *syntax.ObjectNode:
+		var elementType schema.Type
+		switch t := underlyingType(typ).(type) {
+		case *schema.ObjectType:
+			return c.convertProperties(node, t.Properties)
+		case *schema.MapType:
+			elementType = t.ElementType
+		}
+		props := make([]syntax.ObjectPropertyDef, node.Len())
+		for i, kvp := range entries(node) {
+			props[i] = property(escape(kvp.Key.Value()), c.convertExpr(kvp.Value, elementType))
+		}
+		return syntax.Object(props...)
+	default:
+		return c.convertLiteral(node)
+	}
+}
+
+// convertIntrinsic converts a call to the intrinsic function named by name.
+func (c *converter) convertIntrinsic(name *syntax.StringNode, args syntax.Node) syntax.Node {
+	switch name.Value() {
+	case "Ref":
+		ref, ok := args.(*syntax.StringNode)
+		if !ok {
+			c.error(args, "the argument to Ref must be a string", "")
+			return syntax.Null()
+		}
+		return c.ref(ref, ref.Value())
+	case "Fn::GetAtt":
+		return c.convertGetAtt(args)
+	case "Fn::Sub":
+		return c.convertSub(args)
+	case "Fn::FindInMap":
+		if list, ok := args.(*syntax.ListNode); ok && list.Len() == 3 {
+			if mapName, ok := list.Index(0).(*syntax.StringNode); !ok || !c.mappings[mapName.Value()] {
+				c.error(list.Index(0), "the first argument to Fn::FindInMap must name a mapping declared by the template", "")
+				return syntax.Null()
+			}
+		}
+	case "Fn::Select":
+		// The index may be written as a string.
+		if list, ok := args.(*syntax.ListNode); ok && list.Len() == 2 {
+			if index, ok := list.Index(0).(*syntax.StringNode); ok {
+				n, ok := c.parseNumber(index, index.Value())
+				if !ok {
+					return syntax.Null()
+				}
+				return builtin("fn::select", syntax.List(n, c.convertExpr(list.Index(1), nil)))
+			}
+		}
+	case "Fn::If":
+		if list, ok := args.(*syntax.ListNode); ok && list.Len() == 3 {
+			cond, ok := list.Index(0).(*syntax.StringNode)
+			if !ok || !c.conditions[cond.Value()] {
+				c.error(list.Index(0), "the first argument to Fn::If must name a condition declared by the template", "")
+				return syntax.Null()
+			}
+			return builtin("fn::if", syntax.List(
+				interpolate(cond.Value()), c.convertExpr(list.Index(1), nil), c.convertExpr(list.Index(2), nil)))
+		}
+	}
+
+	b, ok := builtins[name.Value()]
+	if !ok {
+		c.error(name, fmt.Sprintf("unsupported intrinsic function %s", name.Value()),
+			"The function has no equivalent in Pulumi YAML, and has been converted to null.")
+		return syntax.Null()
+	}
+	if b.arity == 0 {
+		return builtin(b.name, c.convertExpr(args, nil))
+	}
+
+	list, ok := args.(*syntax.ListNode)
+	if !ok || (b.arity > 0 && list.Len() != b.arity) {
+		summary := fmt.Sprintf("the argument to %s must be a list", name.Value())
+		if b.arity > 0 {
+			summary = fmt.Sprintf("the argument to %s must be a list of %d values", name.Value(), b.arity)
+		}
+		c.error(args, summary, "")
+		return syntax.Null()
+	}
+	converted := c.convertExpr(list, nil).(*syntax.ListNode)
+	if b.arity == 1 {
+		return builtin(b.name, converted.Index(0))
+	}
+	return builtin(b.name, converted)
+}
+
+// ref converts a reference to a parameter, resource or pseudo parameter.
+func (c *converter) ref(node syntax.Node, name string) syntax.Node {
+	switch {
+	case c.parameters[name]:
+		return interpolate(name)
+	case c.resources[name] != nil:
+		return interpolate(name + ".id")
+	case name == "AWS::StackName":
+		return interpolate("pulumi.stack")
+	case name == "AWS::NoValue":
+		return syntax.Null()
+	}
+	for _, p := range pseudoParameters {
+		if p.name == name {
+			c.pseudo[name] = true
+			return interpolate(p.variable)
+		}
+	}
+	if strings.HasPrefix(name, "AWS::") {
+		c.error(node, fmt.Sprintf("unsupported pseudo parameter %s", name), "")
+	} else {
+		c.error(node, fmt.Sprintf("unknown reference to %s", name),
+			"References must name a parameter or resource declared by the template.")
+	}
+	return syntax.Null()
+}
+
+func (c *converter) convertGetAtt(args syntax.Node) syntax.Node {
+	list, ok := args.(*syntax.ListNode)
+	if !ok || list.Len() != 2 {
+		c.error(args, "the argument to Fn::GetAtt must be a list of a resource name and an attribute name", "")
+		return syntax.Null()
+	}
+	resource, ok := list.Index(0).(*syntax.StringNode)
+	if !ok {
+		c.error(list.Index(0), "the resource name of Fn::GetAtt must be a string", "")
+		return syntax.Null()
+	}
+	attribute, ok := list.Index(1).(*syntax.StringNode)
+	if !ok {
+		c.error(list.Index(1), "the attribute name of Fn::GetAtt must be a string", "")
+		return syntax.Null()
+	}
+	return c.getAtt(resource, resource.Value(), attribute.Value())
+}
+
+// getAtt converts a reference to an attribute of a resource. The attribute is named after the
+// matching output of the resource, and nested attributes, e.g. `Endpoint.Address`, after the
+// properties of that output's type.
+func (c *converter) getAtt(node syntax.Node, resource, attribute string) syntax.Node {
+	typ := c.resources[resource]
+	if typ == nil {
+		c.error(node, fmt.Sprintf("unknown resource %s", resource),
+			"Fn::GetAtt must name a resource declared by the template.")
+		return syntax.Null()
+	}
+
+	var properties []*schema.Property
+	if typ.hint != nil && typ.hint.Resource != nil {
+		properties = typ.hint.Resource.Properties
+	}
+	access := resource
+	for _, name := range strings.Split(attribute, ".") {
+		prop, ok := findProperty(properties, name)
+		if !ok {
+			access += "." + camelCase(name)
+			properties = nil
+			continue
+		}
+		access += "." + prop.Name
+		properties = nil
+		if t, ok := underlyingType(prop.Type).(*schema.ObjectType); ok {
+			properties = t.Properties
+		}
+	}
+	return interpolate(access)
+}
+
+// convertSub converts a call to Fn::Sub to an interpolated string. If any of the values
+// substituted into the string is not a string, the call is converted to a call to fn::join.
+func (c *converter) convertSub(args syntax.Node) syntax.Node {
+	template, vars := args, (*syntax.ObjectNode)(nil)
+	if list, ok := args.(*syntax.ListNode); ok {
+		if list.Len() != 2 {
+			c.error(args, "the argument to Fn::Sub must be a string, or a list of a string and an object", "")
+			return syntax.Null()
+		}
+		template = list.Index(0)
+		if vars, ok = c.object(list.Index(1), "the variables of Fn::Sub"); !ok {
+			return syntax.Null()
+		}
+	}
+	str, ok := template.(*syntax.StringNode)
+	if !ok {
+		c.error(template, "the template of Fn::Sub must be a string", "")
+		return syntax.Null()
+	}
+
+	var parts []syntax.Node
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() != 0 {
+			parts = append(parts, syntax.String(escape(literal.String())))
+			literal.Reset()
+		}
+	}
+	for text := str.Value(); len(text) > 0; {
+		start := strings.Index(text, "${")
+		if start < 0 {
+			literal.WriteString(text)
+			break
+		}
+		literal.WriteString(text[:start])
+		text = text[start+2:]
+		end := strings.Index(text, "}")
+		if end < 0 {
+			literal.WriteString("${" + text)
+			break
+		}
+		name := text[:end]
+		text = text[end+1:]
+
+		// `${!Literal}` is written as `${Literal}`.
+		if strings.HasPrefix(name, "!") {
+			literal.WriteString("${" + name[1:] + "}")
+			continue
+		}
+
+		var value syntax.Node
+		if v := get(vars, name); v != nil {
+			value = c.convertExpr(v, nil)
+		} else if resource, attribute, ok := strings.Cut(name, "."); ok {
+			value = c.getAtt(str, resource, attribute)
+		} else {
+			value = c.ref(str, name)
+		}
+		flush()
+		parts = append(parts, value)
+	}
+	flush()
+
+	// Converted strings are interpolations in their own right, so they may be concatenated.
+	var b strings.Builder
+	for _, part := range parts {
+		if s, ok := scalarText(part); ok {
+			if _, isString := part.(*syntax.StringNode); !isString {
+				s = escape(s)
+			}
+			b.WriteString(s)
+			continue
+		}
+		return builtin("fn::join", syntax.List(syntax.String(""), syntax.List(parts...)))
+	}
+	return syntax.String(b.String())
+}
+
+// convertOutput converts the declaration of an output to the output's value. The value of an
+// output with a condition is null when the condition is false.
+func (c *converter) convertOutput(name *syntax.StringNode, node syntax.Node) (syntax.Node, bool) {
+	output, ok := c.object(node, fmt.Sprintf("output %s", name.Value()))
+	if !ok {
+		return nil, false
+	}
+	value := get(output, "Value")
+	if value == nil {
+		c.error(name, fmt.Sprintf("output %s must have a Value", name.Value()), "")
+		return nil, false
+	}
+	converted := c.convertExpr(value, nil)
+
+	for _, kvp := range entries(output) {
+		switch kvp.Key.Value() {
+		case "Value", "Description":
+		case "Condition":
+			cond, ok := kvp.Value.(*syntax.StringNode)
+			if !ok || !c.conditions[cond.Value()] {
+				c.error(kvp.Value, "Condition must name a condition declared by the template", "")
+				continue
+			}
+			converted = builtin("fn::if", syntax.List(interpolate(cond.Value()), converted, syntax.Null()))
+		case "Export":
+			c.warning(kvp.Key, "Export is not supported and has been dropped",
+				"Every output of a Pulumi stack may be read by other stacks with a stack reference.")
+		default:
+			c.warning(kvp.Key, fmt.Sprintf("unknown output attribute %s has been dropped", kvp.Key.Value()), "")
+		}
+	}
+	return converted, true
+}
+
+// pseudoParameterVariables returns the variables that hold the values of the pseudo parameters the
+// template refers to, or nil if it refers to none.
+func (c *converter) pseudoParameterVariables() *syntax.ObjectNode {
+	var variables []syntax.ObjectPropertyDef
+	for _, p := range pseudoParameters {
+		if !c.pseudo[p.name] {
+			continue
+		}
+		invoke := syntax.Object(
+			property("function", syntax.String(p.function)),
+			property("return", syntax.String(p.result)),
+		)
+		variables = append(variables, property(p.variable, builtin("fn::invoke", invoke)))
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+	return syntax.Object(variables...)
+}
@@ -0,0 +1,226 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudformation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+)
+
+// resolveResourceType resolves the aws-native token of the resource declared by node. The token
+// of `AWS::EC2::VPC` is `aws-native:ec2:Vpc`. An error is reported if the type cannot be resolved.
+func (c *converter) resolveResourceType(node syntax.Node) *resourceType {
+	resource, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		// The error is reported when the resource is converted.
+		return &resourceType{}
+	}
+	typ, ok := get(resource, "Type").(*syntax.StringNode)
+	if !ok {
+		return &resourceType{}
+	}
+
+	parts := strings.Split(typ.Value(), "::")
+	if len(parts) != 3 || parts[0] != "AWS" {
+		c.error(typ, fmt.Sprintf("resource type %s is not supported", typ.Value()),
+			"Only resource types in the AWS namespace can be converted to aws-native resources.")
+		return &resourceType{}
+	}
+	module := strings.ToLower(parts[1])
+	for _, name := range []string{pascalCase(parts[2]), parts[2]} {
+		token, err := c.pkg.ResolveResource(PackageName + ":" + module + ":" + name)
+		if err != nil {
+			continue
+		}
+		return &resourceType{token: token, hint: c.pkg.ResourceTypeHint(token)}
+	}
+	c.error(typ, fmt.Sprintf("unable to find an aws-native resource for %s", typ.Value()), "")
+	return &resourceType{token: pulumiyaml.ResourceTypeToken(PackageName + ":" + module + ":" + pascalCase(parts[2]))}
+}
+
+// deletionPolicies are the values of DeletionPolicy and UpdateReplacePolicy that may be
+// converted. Retained resources are converted with the retainOnDelete option.
+var deletionPolicies = map[string]bool{"Delete": false, "Retain": true, "RetainExceptOnCreate": true}
+
+func (c *converter) convertResource(name *syntax.StringNode, node syntax.Node) (*syntax.ObjectNode, bool) {
+	resource, ok := c.object(node, fmt.Sprintf("resource %s", name.Value()))
+	if !ok {
+		return nil, false
+	}
+	typ := c.resources[name.Value()]
+	if typ.token == "" {
+		if _, ok := get(resource, "Type").(*syntax.StringNode); !ok {
+			c.error(name, fmt.Sprintf("resource %s must have a string Type", name.Value()), "")
+		}
+		return nil, false
+	}
+
+	decl := []syntax.ObjectPropertyDef{property("type", syntax.String(string(typ.token)))}
+	var options []syntax.ObjectPropertyDef
+	for _, kvp := range entries(resource) {
+		switch kvp.Key.Value() {
+		case "Type", "Properties":
+			// Converted below.
+		case "Condition":
+			cond, ok := kvp.Value.(*syntax.StringNode)
+			if !ok || !c.conditions[cond.Value()] {
+				c.error(kvp.Value, "Condition must name a condition declared by the template", "")
+				continue
+			}
+			decl = append(decl, property("condition", syntax.String("${"+cond.Value()+"}")))
+		case "DependsOn":
+			var names []syntax.Node
+			switch deps := kvp.Value.(type) {
+			case *syntax.StringNode:
+				names = []syntax.Node{deps}
+			case *syntax.ListNode:
+				for i := 0; i < deps.Len(); i++ {
+					names = append(names, deps.Index(i))
+				}
+			}
+			var dependsOn []syntax.Node
+			for _, dep := range names {
+				s, ok := dep.(*syntax.StringNode)
+				if !ok || c.resources[s.Value()] == nil {
+					c.error(dep, "DependsOn must name resources declared by the template", "")
+					continue
+				}
+				dependsOn = append(dependsOn, syntax.String("${"+s.Value()+"}"))
+			}
+			if len(dependsOn) != 0 {
+				options = append(options, property("dependsOn", syntax.List(dependsOn...)))
+			}
+		case "DeletionPolicy", "UpdateReplacePolicy":
+			policy, _ := kvp.Value.(*syntax.StringNode)
+			retain, ok := false, false
+			if policy != nil {
+				retain, ok = deletionPolicies[policy.Value()]
+			}
+			switch {
+			case !ok:
+				c.warning(kvp.Value, fmt.Sprintf("%s is not supported and has been dropped", kvp.Key.Value()),
+					"Only the Delete, Retain and RetainExceptOnCreate policies can be converted.")
+			case retain && kvp.Key.Value() == "DeletionPolicy":
+				options = append(options, property("retainOnDelete", syntax.Boolean(true)))
+			case retain:
+				c.warning(kvp.Key, "UpdateReplacePolicy is not supported and has been dropped",
+					"Pulumi deletes the resources that it replaces.")
+			}
+		case "Metadata", "CreationPolicy", "UpdatePolicy":
+			c.warning(kvp.Key, fmt.Sprintf("%s is not supported and has been dropped", kvp.Key.Value()), "")
+		default:
+			c.warning(kvp.Key, fmt.Sprintf("unknown resource attribute %s has been dropped", kvp.Key.Value()), "")
+		}
+	}
+
+	if props := get(resource, "Properties"); props != nil {
+		var inputs []*schema.Property
+		if typ.hint != nil && typ.hint.Resource != nil {
+			inputs = typ.hint.Resource.InputProperties
+		}
+		if obj, ok := c.object(props, fmt.Sprintf("the properties of resource %s", name.Value())); ok {
+			decl = append(decl, property("properties", c.convertProperties(obj, inputs)))
+		}
+	}
+	if len(options) != 0 {
+		decl = append(decl, property("options", syntax.Object(options...)))
+	}
+	return syntax.Object(decl...), true
+}
+
+// convertProperties converts an object whose keys are the names of properties, converting each
+// name to the name of the matching property in properties. A warning is reported for names that do
+// not match any of them.
+func (c *converter) convertProperties(obj *syntax.ObjectNode, properties []*schema.Property) *syntax.ObjectNode {
+	converted := make([]syntax.ObjectPropertyDef, 0, obj.Len())
+	for _, kvp := range entries(obj) {
+		prop, ok := findProperty(properties, kvp.Key.Value())
+		if !ok && len(properties) != 0 {
+			c.warning(kvp.Key, fmt.Sprintf("unknown property %s", kvp.Key.Value()),
+				"The property has been converted, but does not match a property of the resource's schema.")
+		}
+		var typ schema.Type
+		name := camelCase(kvp.Key.Value())
+		if ok {
+			typ, name = prop.Type, prop.Name
+		}
+		converted = append(converted, property(name, c.convertExpr(kvp.Value, typ)))
+	}
+	return syntax.Object(converted...)
+}
+
+// findProperty returns the property of properties named name, ignoring case.
+func findProperty(properties []*schema.Property, name string) (*schema.Property, bool) {
+	for _, p := range properties {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// underlyingType strips the optional and input wrappers from t.
+func underlyingType(t schema.Type) schema.Type {
+	for {
+		switch tt := t.(type) {
+		case *schema.OptionalType:
+			t = tt.ElementType
+		case *schema.InputType:
+			t = tt.ElementType
+		default:
+			return t
+		}
+	}
+}
+
+// pascalCase converts a CloudFormation name to the Pascal case used by aws-native, in which
+// acronyms are capitalized like words: `DBInstance` becomes `DbInstance`.
+func pascalCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		// A rune starts a word if it is the first rune, or an upper case rune that follows a lower
+		// case rune or precedes one.
+		startsWord := i == 0
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			startsWord = unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next)
+		}
+		if startsWord {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// camelCase converts a CloudFormation name to the camel case used by aws-native: `VPCId` becomes
+// `vpcId`.
+func camelCase(name string) string {
+	s := []rune(pascalCase(name))
+	if len(s) == 0 {
+		return ""
+	}
+	s[0] = unicode.ToLower(s[0])
+	return string(s)
+}
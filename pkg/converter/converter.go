@@ -16,18 +16,23 @@ package converter
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/blang/semver"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pulumi/pulumi-yaml/pkg/converter/cloudformation"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
 	yamlgen "github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/codegen"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 	codegenrpc "github.com/pulumi/pulumi/sdk/v3/proto/go/codegen"
@@ -44,10 +49,38 @@ func (*converter) Close() error {
 	return nil
 }
 
+// ConvertState converts the resources of a Pulumi state file, as written by `pulumi stack export`,
+// to the list of resources to import. If `--out <path>` is passed, a Pulumi YAML program
+// declaring the resources is also written to path: each custom resource imports its ID, and every
+// resource sets the inputs recorded in the state and references its parent, provider and
+// dependencies by `${...}`.
 func (*converter) ConvertState(ctx context.Context,
 	req *plugin.ConvertStateRequest,
 ) (*plugin.ConvertStateResponse, error) {
-	return nil, errors.New("not implemented")
+	path, out, err := parseStateArgs(req.Args)
+	if err != nil {
+		return nil, err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := readDeployment(source)
+	if err != nil {
+		return nil, err
+	}
+
+	program, imports, diags := convertState(deployment)
+	if out != "" {
+		bytes, err := encodeProgram(program)
+		if err != nil {
+			return nil, fmt.Errorf("encoding program: %w", err)
+		}
+		if err := os.WriteFile(out, bytes, 0o600); err != nil {
+			return nil, fmt.Errorf("writing program: %w", err)
+		}
+	}
+	return &plugin.ConvertStateResponse{Resources: imports, Diagnostics: diags}, nil
 }
 
 // writeProgram writes a project and pcl program to the given filesystem
@@ -159,22 +192,201 @@ func packageDescriptor(req *codegenrpc.GetSchemaRequest) (*schema.PackageDescrip
 	return desc, nil
 }
 
+// ConvertProgram converts a Pulumi YAML program, or a CloudFormation template, to PCL. If
+// `--language <name>` is passed, which may be repeated, identifiers that are keywords in the named
+// languages are avoided. If `--names <path>` is passed, the identifier assigned to each declaration
+// of the program, or of a plugin's components, is written to path as JSON. Other arguments are
+// ignored.
 func (*converter) ConvertProgram(ctx context.Context,
 	req *plugin.ConvertProgramRequest,
 ) (*plugin.ConvertProgramResponse, error) {
+	opts, namesPath, err := parseProgramArgs(req.Args)
+	if err != nil {
+		return nil, err
+	}
+
 	loader, err := schema.NewLoaderClient(req.LoaderTarget)
 	if err != nil {
 		return nil, err
 	}
-	proj, program, err := yamlgen.Eject(req.SourceDirectory, loader)
+
+	// A plugin declares components rather than a program. Each of its components is converted to a
+	// PCL component, in a directory named after the component, which the program instantiates.
+	if isPlugin(req.SourceDirectory) {
+		proj, program, names, err := yamlgen.EjectPlugin(req.SourceDirectory, loader, opts)
+		if err != nil {
+			return nil, fmt.Errorf("load yaml plugin: %w", err)
+		}
+		if err := writeNames(namesPath, names); err != nil {
+			return nil, err
+		}
+		fs := afero.NewBasePathFs(afero.NewOsFs(), req.TargetDirectory)
+		err = writeProgram(fs, proj, program)
+		if err != nil {
+			return nil, fmt.Errorf("write program to intermediate directory: %w", err)
+		}
+		return &plugin.ConvertProgramResponse{}, nil
+	}
+
+	// A directory without a Pulumi project may hold a CloudFormation template, which is first
+	// converted to a Pulumi YAML program.
+	sourceDirectory := req.SourceDirectory
+	var diags hcl.Diagnostics
+	template, err := findTemplate(sourceDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if template != "" {
+		source, err := os.ReadFile(template)
+		if err != nil {
+			return nil, err
+		}
+		pkgLoader := pulumiyaml.NewPackageLoaderFromSchemaLoader(schema.NewCachedLoader(loader))
+		defer pkgLoader.Close()
+		program, tdiags, err := cloudformation.ConvertToYAML(ctx, filepath.Base(template), source, pkgLoader)
+		if err != nil {
+			return nil, fmt.Errorf("convert cloudformation template: %w", err)
+		}
+		diags = tdiags.HCL()
+		if tdiags.HasErrors() {
+			return &plugin.ConvertProgramResponse{Diagnostics: diags}, nil
+		}
+
+		sourceDirectory, err = os.MkdirTemp("", "pulumi-yaml-cloudformation")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(sourceDirectory)
+		// The program declares its config with the types of Pulumi YAML, such as `List<Number>`,
+		// which a project file does not allow. It is written to Main.yaml, beside a project file
+		// that only declares the project.
+		err = writeTemplateProject(sourceDirectory, program)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(filepath.Join(sourceDirectory, pulumiyaml.MainTemplate+".yaml"), program, 0o600)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	proj, program, names, err := yamlgen.EjectWithOptions(sourceDirectory, loader, opts)
 	if err != nil {
 		return nil, fmt.Errorf("load yaml program: %w", err)
 	}
+	if err := writeNames(namesPath, names); err != nil {
+		return nil, err
+	}
 	fs := afero.NewBasePathFs(afero.NewOsFs(), req.TargetDirectory)
 	err = writeProgram(fs, proj, program)
 	if err != nil {
 		return nil, fmt.Errorf("write program to intermediate directory: %w", err)
 	}
 
-	return &plugin.ConvertProgramResponse{}, nil
+	return &plugin.ConvertProgramResponse{Diagnostics: diags}, nil
+}
+
+// writeTemplateProject writes the project file of program, a Pulumi YAML program, to dir.
+func writeTemplateProject(dir string, program []byte) error {
+	var decl struct {
+		Name        string  `yaml:"name"`
+		Description *string `yaml:"description"`
+	}
+	if err := encoding.YAML.Unmarshal(program, &decl); err != nil {
+		return fmt.Errorf("reading program: %w", err)
+	}
+	proj := workspace.Project{
+		Name:        tokens.PackageName(decl.Name),
+		Runtime:     workspace.NewProjectRuntimeInfo("yaml", nil),
+		Description: decl.Description,
+	}
+	projBytes, err := encoding.YAML.Marshal(&proj)
+	if err != nil {
+		return fmt.Errorf("marshaling project: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), projBytes, 0o600)
+}
+
+// parseProgramArgs parses the arguments passed to ConvertProgram. Both `--flag value` and
+// `--flag=value` are accepted. Arguments it does not recognise are ignored.
+func parseProgramArgs(args []string) (opts yamlgen.ImportOptions, names string, err error) {
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "language" && name != "names") {
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return yamlgen.ImportOptions{}, "", fmt.Errorf("missing value for %s", args[i])
+			}
+			i++
+			value = args[i]
+		}
+		if name == "language" {
+			opts.Languages = append(opts.Languages, value)
+		} else {
+			names = value
+		}
+	}
+	return opts, names, nil
+}
+
+// writeNames writes names to path as JSON, unless path is empty.
+func writeNames(path string, names []yamlgen.Name) error {
+	if path == "" {
+		return nil
+	}
+	report, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding names: %w", err)
+	}
+	if err := os.WriteFile(path, append(report, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing names: %w", err)
+	}
+	return nil
+}
+
+// isPlugin returns true if dir holds a PulumiPlugin.yaml rather than a Pulumi project.
+func isPlugin(dir string) bool {
+	for _, name := range []string{"Pulumi.yaml", "Pulumi.yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return false
+		}
+	}
+	_, err := os.Stat(filepath.Join(dir, "PulumiPlugin.yaml"))
+	return err == nil
+}
+
+// findTemplate returns the path of the CloudFormation template in dir, or "" if dir holds a Pulumi
+// project or no template. If there are several templates, the first by name is returned.
+func findTemplate(dir string) (string, error) {
+	for _, name := range []string{"Pulumi.yaml", "Pulumi.yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return "", nil
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	// ReadDir returns the files sorted by name.
+	for _, f := range files {
+		switch filepath.Ext(f.Name()) {
+		case ".json", ".yaml", ".yml", ".template":
+		default:
+			continue
+		}
+		if f.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if cloudformation.IsTemplate(f.Name(), source) {
+			return path, nil
+		}
+	}
+	return "", nil
 }
@@ -0,0 +1,176 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	codegenrpc "github.com/pulumi/pulumi/sdk/v3/proto/go/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	yamlgen "github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/codegen"
+)
+
+// staticLoader serves pre-imported packages by name.
+type staticLoader struct {
+	packages map[string]*schema.Package
+}
+
+func (l *staticLoader) LoadPackage(pkg string, version *semver.Version) (*schema.Package, error) {
+	p, ok := l.packages[pkg]
+	if !ok {
+		return nil, fmt.Errorf("package %q not found", pkg)
+	}
+	return p, nil
+}
+
+func (l *staticLoader) LoadPackageV2(_ context.Context, desc *schema.PackageDescriptor) (*schema.Package, error) {
+	return l.LoadPackage(desc.Name, desc.Version)
+}
+
+func (l *staticLoader) LoadPackageReference(pkg string, version *semver.Version) (schema.PackageReference, error) {
+	p, err := l.LoadPackage(pkg, version)
+	if err != nil {
+		return nil, err
+	}
+	return p.Reference(), nil
+}
+
+func (l *staticLoader) LoadPackageReferenceV2(
+	_ context.Context, desc *schema.PackageDescriptor,
+) (schema.PackageReference, error) {
+	return l.LoadPackageReference(desc.Name, desc.Version)
+}
+
+// serveLoader serves the given packages from a schema loader, and returns its address.
+func serveLoader(t *testing.T, specs ...schema.PackageSpec) string {
+	packages := map[string]*schema.Package{}
+	for _, spec := range specs {
+		pkg, err := schema.ImportSpec(spec, nil, schema.NewNullLoader(), schema.ValidationOptions{})
+		require.NoError(t, err)
+		packages[spec.Name] = pkg
+	}
+
+	srv := grpc.NewServer()
+	codegenrpc.RegisterLoaderServer(srv, schema.NewLoaderServer(&staticLoader{packages: packages}))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// TestConvertProgramCloudFormation converts a directory holding a CloudFormation template, rather
+// than a Pulumi project, to PCL.
+func TestConvertProgramCloudFormation(t *testing.T) {
+	t.Parallel()
+
+	loader := serveLoader(t, schema.PackageSpec{
+		Name:    "aws-native",
+		Version: "1.0.0",
+		Resources: map[string]schema.ResourceSpec{
+			"aws-native:s3:Bucket": {
+				ObjectTypeSpec: schema.ObjectTypeSpec{
+					Type: "object",
+					Properties: map[string]schema.PropertySpec{
+						"arn":        {TypeSpec: schema.TypeSpec{Type: "string"}},
+						"bucketName": {TypeSpec: schema.TypeSpec{Type: "string"}},
+					},
+				},
+				InputProperties: map[string]schema.PropertySpec{
+					"bucketName": {TypeSpec: schema.TypeSpec{Type: "string"}},
+				},
+			},
+		},
+	})
+
+	source, target := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "template.yaml"), []byte(`AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Name:
+    Type: String
+    Default: site
+Resources:
+  SiteBucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Ref Name
+Outputs:
+  BucketArn:
+    Value: !GetAtt SiteBucket.Arn
+`), 0o600))
+
+	resp, err := New().ConvertProgram(t.Context(), &plugin.ConvertProgramRequest{
+		SourceDirectory: source,
+		TargetDirectory: target,
+		LoaderTarget:    loader,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.Diagnostics.HasErrors(), resp.Diagnostics.Error())
+
+	project, err := os.ReadFile(filepath.Join(target, "Pulumi.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: template\nruntime: yaml\n", string(project))
+
+	program, err := os.ReadFile(filepath.Join(target, "program.pp"))
+	require.NoError(t, err)
+	assert.Equal(t, `config name string {
+	__logicalName = "Name"
+	default = "site"
+}
+
+resource siteBucket "aws-native:s3:Bucket" {
+	__logicalName = "SiteBucket"
+	bucketName = name
+}
+
+output bucketArn {
+	__logicalName = "BucketArn"
+	value = siteBucket.arn
+}
+`, string(program))
+}
+
+func TestParseProgramArgs(t *testing.T) {
+	t.Parallel()
+
+	opts, names, err := parseProgramArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, yamlgen.ImportOptions{}, opts)
+	assert.Empty(t, names)
+
+	opts, names, err = parseProgramArgs([]string{"--language", "go", "--names", "names.json", "--language", "python"})
+	require.NoError(t, err)
+	assert.Equal(t, yamlgen.ImportOptions{Languages: []string{"go", "python"}}, opts)
+	assert.Equal(t, "names.json", names)
+
+	opts, names, err = parseProgramArgs([]string{"extra", "--generate-only", "--language=go", "--names=n.json"})
+	require.NoError(t, err)
+	assert.Equal(t, yamlgen.ImportOptions{Languages: []string{"go"}}, opts)
+	assert.Equal(t, "n.json", names)
+
+	_, _, err = parseProgramArgs([]string{"--language"})
+	assert.EqualError(t, err, "missing value for --language")
+}
@@ -0,0 +1,401 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/sig"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+)
+
+// parseStateArgs parses the arguments of ConvertState: the path of the state file, and the
+// optional path given by `--out` to which the program is written.
+func parseStateArgs(args []string) (path, out string, err error) {
+	flags := flag.NewFlagSet("yaml", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&out, "out", "", "the path to write the Pulumi YAML program to")
+	if err := flags.Parse(args); err != nil {
+		return "", "", err
+	}
+	if flags.NArg() != 1 {
+		return "", "", errors.New("expected the path of a state file, as written by `pulumi stack export`")
+	}
+	return flags.Arg(0), out, nil
+}
+
+// readDeployment reads a deployment from the output of `pulumi stack export`. A bare deployment,
+// without the version envelope, is also accepted.
+func readDeployment(source []byte) (*apitype.DeploymentV3, error) {
+	var untyped apitype.UntypedDeployment
+	if err := json.Unmarshal(source, &untyped); err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	raw := []byte(untyped.Deployment)
+	if len(raw) == 0 {
+		raw = source
+	} else if untyped.Version != apitype.DeploymentSchemaVersionCurrent {
+		return nil, fmt.Errorf("reading state: unsupported deployment version %d", untyped.Version)
+	}
+
+	var deployment apitype.DeploymentV3
+	if err := json.Unmarshal(raw, &deployment); err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	return &deployment, nil
+}
+
+// stateConverter converts the resources of a deployment to the resources of a Pulumi YAML
+// program, each of which imports the resource it was converted from.
+type stateConverter struct {
+	// names maps the URN of each converted resource to the name it is declared by in the program.
+	names map[resource.URN]string
+	// taken is the set of names declared by the program.
+	taken map[string]bool
+	// versions maps the URN of each provider to its version, if the state records one.
+	versions map[resource.URN]string
+
+	diags hcl.Diagnostics
+}
+
+func (c *stateConverter) warning(summary, detail string) {
+	c.diags = append(c.diags, &hcl.Diagnostic{Severity: hcl.DiagWarning, Summary: summary, Detail: detail})
+}
+
+// convertState converts the resources of deployment to a Pulumi YAML program and the list of
+// resources for the engine to import.
+//
+// The stack resource and default providers are omitted from the program. Explicit providers are
+// declared, but are not imported. Component resources are declared and listed as components, so
+// that the engine creates them as the parents of the resources it imports.
+func convertState(deployment *apitype.DeploymentV3) (*syntax.ObjectNode, []plugin.ResourceImport, hcl.Diagnostics) {
+	c := &stateConverter{
+		names: map[resource.URN]string{},
+		// `pulumi` is the name of the builtin variable holding the stack's metadata.
+		taken:    map[string]bool{"pulumi": true},
+		versions: map[resource.URN]string{},
+	}
+
+	project := "imported"
+	var resources []syntax.ObjectPropertyDef
+	var imports []plugin.ResourceImport
+	for _, res := range deployment.Resources {
+		if res.Delete {
+			// The resource is pending deletion, and is no longer part of the stack.
+			continue
+		}
+		if providers.IsProviderType(res.Type) {
+			if version, ok := res.Inputs["version"].(string); ok {
+				c.versions[res.URN] = version
+			}
+		}
+		switch {
+		case res.Type == resource.RootStackType:
+			project = string(res.URN.Project())
+			continue
+		case providers.IsProviderType(res.Type) && providers.IsDefaultProvider(res.URN):
+			continue
+		}
+
+		name := c.declare(res.URN)
+		resources = append(resources, property(name, c.convertResource(name, res)))
+		if !providers.IsProviderType(res.Type) {
+			imp := plugin.ResourceImport{
+				Type:        string(res.Type),
+				Name:        res.URN.Name(),
+				LogicalName: name,
+				IsComponent: !res.Custom,
+				Version:     c.providerVersion(res.Provider),
+			}
+			if res.Custom {
+				imp.ID = string(res.ID)
+			}
+			imports = append(imports, imp)
+		}
+	}
+
+	program := syntax.Object(
+		property("name", syntax.String(project)),
+		property("runtime", syntax.String("yaml")),
+		property("resources", syntax.Object(resources...)),
+	)
+	return program, imports, c.diags
+}
+
+// declare chooses the name by which the resource with the given URN is declared. The name of the
+// resource is used where it can be referenced by `${...}` and is not already declared.
+func (c *stateConverter) declare(urn resource.URN) string {
+	base := strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '[', ']', '{', '}', '$', ' ':
+			return '-'
+		}
+		return r
+	}, urn.Name())
+	name := base
+	for i := 2; c.taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	c.taken[name] = true
+	c.names[urn] = name
+	return name
+}
+
+// providerVersion returns the version of the provider with the given reference, if it is known.
+func (c *stateConverter) providerVersion(provider string) string {
+	if provider == "" {
+		return ""
+	}
+	ref, err := providers.ParseReference(provider)
+	if err != nil {
+		return ""
+	}
+	return c.versions[ref.URN()]
+}
+
+func (c *stateConverter) convertResource(name string, res apitype.ResourceV3) *syntax.ObjectNode {
+	decl := []syntax.ObjectPropertyDef{property("type", syntax.String(string(res.Type)))}
+	if name != res.URN.Name() {
+		decl = append(decl, property("name", syntax.String(res.URN.Name())))
+	}
+
+	inputs := res.Inputs
+	var options []syntax.ObjectPropertyDef
+	if providers.IsProviderType(res.Type) {
+		// The version of a provider is recorded as an input, but is set by an option.
+		if version, ok := inputs["version"].(string); ok {
+			options = append(options, property("version", syntax.String(version)))
+		}
+		inputs = make(map[string]any, len(res.Inputs))
+		for k, v := range res.Inputs {
+			if k != "version" {
+				inputs[k] = v
+			}
+		}
+	} else if res.Custom {
+		options = append(options, property("import", syntax.String(escape(string(res.ID)))))
+	}
+	if props := c.convertProperties(name, inputs); props != nil {
+		decl = append(decl, property("properties", props))
+	}
+
+	if parent, ok := c.names[res.Parent]; ok {
+		options = append(options, property("parent", syntax.String("${"+parent+"}")))
+	}
+	if res.Provider != "" {
+		if ref, err := providers.ParseReference(res.Provider); err == nil {
+			if provider, ok := c.names[ref.URN()]; ok {
+				options = append(options, property("provider", syntax.String("${"+provider+"}")))
+			}
+		}
+	}
+	var dependsOn []syntax.Node
+	for _, dep := range res.Dependencies {
+		// A dependency on the parent is implied by the parent option.
+		if dep, ok := c.names[dep]; ok && dep != c.names[res.Parent] {
+			dependsOn = append(dependsOn, syntax.String("${"+dep+"}"))
+		}
+	}
+	if len(dependsOn) != 0 {
+		options = append(options, property("dependsOn", syntax.List(dependsOn...)))
+	}
+	if res.Protect {
+		options = append(options, property("protect", syntax.Boolean(true)))
+	}
+	if res.RetainOnDelete {
+		options = append(options, property("retainOnDelete", syntax.Boolean(true)))
+	}
+	if len(options) != 0 {
+		decl = append(decl, property("options", syntax.Object(options...)))
+	}
+	return syntax.Object(decl...)
+}
+
+// convertProperties converts the inputs of a resource, in order of name. Properties whose names
+// begin with `__` are internal to the provider, and are omitted.
+func (c *stateConverter) convertProperties(resource string, inputs map[string]any) *syntax.ObjectNode {
+	keys := make([]string, 0, len(inputs))
+	for k := range inputs {
+		if !strings.HasPrefix(k, "__") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	props := make([]syntax.ObjectPropertyDef, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := c.convertValue(fmt.Sprintf("%s.%s", resource, k), inputs[k]); ok {
+			props = append(props, property(k, v))
+		}
+	}
+	return syntax.Object(props...)
+}
+
+// convertValue converts a value from the state. If the value cannot be converted, a warning is
+// reported for path and false is returned.
+func (c *stateConverter) convertValue(path string, v any) (syntax.Node, bool) {
+	switch v := v.(type) {
+	case nil:
+		return syntax.Null(), true
+	case bool:
+		return syntax.Boolean(v), true
+	case float64:
+		return syntax.Number(v), true
+	case string:
+		return syntax.String(escape(v)), true
+	case []any:
+		elements := make([]syntax.Node, 0, len(v))
+		for i, e := range v {
+			if e, ok := c.convertValue(fmt.Sprintf("%s[%d]", path, i), e); ok {
+				elements = append(elements, e)
+			}
+		}
+		return syntax.List(elements...), true
+	case map[string]any:
+		if kind, ok := v[sig.Key].(string); ok {
+			return c.convertSignedValue(path, kind, v)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		entries := make([]syntax.ObjectPropertyDef, 0, len(keys))
+		for _, k := range keys {
+			if e, ok := c.convertValue(fmt.Sprintf("%s.%s", path, k), v[k]); ok {
+				entries = append(entries, property(escape(k), e))
+			}
+		}
+		return syntax.Object(entries...), true
+	default:
+		c.warning(fmt.Sprintf("%s has an unsupported value and has been dropped", path), "")
+		return nil, false
+	}
+}
+
+// convertSignedValue converts a secret, asset, archive or resource reference, which the state
+// records as an object with a signature key.
+func (c *stateConverter) convertSignedValue(path, kind string, v map[string]any) (syntax.Node, bool) {
+	call := func(name string, arg syntax.Node) (syntax.Node, bool) {
+		return syntax.Object(property(name, arg)), true
+	}
+
+	switch kind {
+	case sig.Secret:
+		plaintext, ok := v["plaintext"].(string)
+		if !ok {
+			c.warning(fmt.Sprintf("%s is an encrypted secret and has been dropped", path),
+				"Export the state with `pulumi stack export --show-secrets` to import secret values.")
+			return nil, false
+		}
+		var value any
+		if err := json.Unmarshal([]byte(plaintext), &value); err != nil {
+			c.warning(fmt.Sprintf("%s is a malformed secret and has been dropped", path), err.Error())
+			return nil, false
+		}
+		element, ok := c.convertValue(path, value)
+		if !ok {
+			return nil, false
+		}
+		return call("fn::secret", element)
+	case sig.AssetSig:
+		for _, asset := range []struct{ key, fn string }{
+			{"path", "fn::fileAsset"}, {"text", "fn::stringAsset"}, {"uri", "fn::remoteAsset"},
+		} {
+			if s, ok := v[asset.key].(string); ok {
+				return call(asset.fn, syntax.String(escape(s)))
+			}
+		}
+	case sig.ArchiveSig:
+		for _, archive := range []struct{ key, fn string }{
+			{"path", "fn::fileArchive"}, {"uri", "fn::remoteArchive"},
+		} {
+			if s, ok := v[archive.key].(string); ok {
+				return call(archive.fn, syntax.String(escape(s)))
+			}
+		}
+		if assets, ok := v["assets"].(map[string]any); ok {
+			element, ok := c.convertValue(path, assets)
+			if !ok {
+				return nil, false
+			}
+			return call("fn::assetArchive", element)
+		}
+	case sig.ResourceReference:
+		urn, _ := v["urn"].(string)
+		if name, ok := c.names[resource.URN(urn)]; ok {
+			return syntax.String("${" + name + "}"), true
+		}
+		c.warning(fmt.Sprintf("%s references resource %s, which is not imported, and has been dropped", path, urn), "")
+		return nil, false
+	case sig.OutputValue:
+		value, ok := v["value"]
+		if !ok {
+			c.warning(fmt.Sprintf("%s is unknown and has been dropped", path), "")
+			return nil, false
+		}
+		element, ok := c.convertValue(path, value)
+		if !ok {
+			return nil, false
+		}
+		if secret, _ := v["secret"].(bool); secret {
+			return call("fn::secret", element)
+		}
+		return element, true
+	}
+	c.warning(fmt.Sprintf("%s has an unsupported value and has been dropped", path), "")
+	return nil, false
+}
+
+// encodeProgram encodes a program as YAML.
+func encodeProgram(program *syntax.ObjectNode) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if diags := encoding.EncodeYAML(enc, program); diags.HasErrors() {
+		return nil, diags
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func property(key string, value syntax.Node) syntax.ObjectPropertyDef {
+	return syntax.ObjectProperty(syntax.String(key), value)
+}
+
+// escape escapes the interpolations in s, which is a literal string.
+func escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
@@ -0,0 +1,92 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+)
+
+// TestConvertState converts testdata/state/stack.json, and compares the program with the
+// Pulumi.yaml beside it. Set PULUMI_ACCEPT to update the expected program.
+func TestConvertState(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("testdata", "state")
+	out := filepath.Join(t.TempDir(), "Pulumi.yaml")
+	resp, err := New().ConvertState(t.Context(), &plugin.ConvertStateRequest{
+		Args: []string{"--out", out, filepath.Join(dir, "stack.json")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []plugin.ResourceImport{
+		{Type: "aws:s3/bucketV2:BucketV2", Name: "site", ID: "site-1234567", LogicalName: "site", Version: "6.0.0"},
+		{Type: "my:index:Site", Name: "frontend", LogicalName: "frontend", IsComponent: true},
+		{
+			Type: "aws:s3/bucketObject:BucketObject", Name: "index.html", ID: "index.html", LogicalName: "index-html",
+			Version: "6.0.0",
+		},
+		{Type: "aws:s3/bucketV2:BucketV2", Name: "logs", ID: "logs-7654321", LogicalName: "logs", Version: "6.0.0"},
+		{
+			Type: "aws:s3/bucketPolicy:BucketPolicy", Name: "site", ID: "site-1234567", LogicalName: "site-2",
+			Version: "6.0.0",
+		},
+		{Type: "aws:iam/user:User", Name: "deployer", ID: "deployer", LogicalName: "deployer", Version: "6.0.0"},
+	}, resp.Resources)
+
+	var warnings []string
+	for _, d := range resp.Diagnostics {
+		warnings = append(warnings, d.Summary)
+	}
+	assert.Equal(t, []string{
+		"deployer.pgpKey is an encrypted secret and has been dropped",
+	}, warnings)
+
+	program, err := os.ReadFile(out)
+	require.NoError(t, err)
+
+	// The program must be valid Pulumi YAML.
+	_, diags, err := pulumiyaml.LoadYAMLBytes("Pulumi.yaml", program)
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	expectedPath := filepath.Join(dir, "Pulumi.yaml")
+	if cmdutil.IsTruthy(os.Getenv("PULUMI_ACCEPT")) {
+		require.NoError(t, os.WriteFile(expectedPath, program, 0o600))
+	}
+	expected, err := os.ReadFile(expectedPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(program))
+}
+
+func TestConvertStateArgs(t *testing.T) {
+	t.Parallel()
+
+	_, err := New().ConvertState(t.Context(), &plugin.ConvertStateRequest{})
+	assert.EqualError(t, err, "expected the path of a state file, as written by `pulumi stack export`")
+
+	path := filepath.Join(t.TempDir(), "stack.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "deployment": {}}`), 0o600))
+	_, err = New().ConvertState(t.Context(), &plugin.ConvertStateRequest{Args: []string{path}})
+	assert.EqualError(t, err, "reading state: unsupported deployment version 2")
+}
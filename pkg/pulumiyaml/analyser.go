@@ -72,6 +72,10 @@ type typeCache struct {
 	exprs         map[ast.Expr]schema.Type
 	resourceNames map[string]*ast.ResourceDecl
 	variableNames map[string]ast.Expr
+
+	// optionalSymbols holds the property accesses that evaluate to null instead of failing when
+	// the property does not exist, such as the values of fn::try.
+	optionalSymbols map[*ast.SymbolExpr]bool
 }
 
 func (tc *typeCache) registerResource(name string, resource *ast.ResourceDecl, typ schema.Type) {
@@ -652,11 +656,42 @@ func (tc *typeCache) typeResource(r *Runner, node resourceNode) bool {
 		}
 	}
 
-	tc.registerResource(k, node.Value, hint)
+	var resourceType schema.Type = hint
+	if v.Count != nil || v.ForEach != nil {
+		// A ranged resource is referenced as a collection of its instances.
+		switch key, _ := tc.rangeElementTypes(v); key {
+		case schema.IntType:
+			resourceType = &schema.ArrayType{ElementType: hint}
+		case schema.StringType:
+			resourceType = &schema.MapType{ElementType: hint}
+		default:
+			resourceType = schema.AnyType
+		}
+	}
+	tc.registerResource(k, node.Value, resourceType)
 
 	if v.Get.Id != nil {
 		tc.assertTypeAssignable(ctx, v.Get.Id, schema.StringType)
 	}
+	if v.Condition != nil {
+		tc.assertTypeAssignable(ctx, v.Condition, schema.BoolType)
+	}
+	if v.Count != nil {
+		tc.assertTypeAssignable(ctx, v.Count, schema.IntType)
+		if v.ForEach != nil {
+			ctx.addErrDiag(v.ForEach.Syntax().Syntax().Range(), "a resource cannot specify both count and forEach", "")
+		}
+	} else if v.ForEach != nil {
+		forEachType := codegen.UnwrapType(tc.exprs[v.ForEach])
+		switch forEachType.(type) {
+		case *schema.ArrayType, *schema.MapType, *schema.ObjectType, *schema.InvalidType, nil:
+		default:
+			if forEachType != schema.AnyType {
+				ctx.addErrDiag(v.ForEach.Syntax().Syntax().Range(),
+					fmt.Sprintf("forEach must be a list or an object, not %s", displayType(forEachType)), "")
+			}
+		}
+	}
 
 	// State properties are the same as normal properties, but they are all optional.
 	stateProps := make([]*schema.Property, len(hint.Resource.Properties))
@@ -685,7 +720,7 @@ func (tc *typeCache) typeResource(r *Runner, node resourceNode) bool {
 	}
 	if s := v.Syntax(); s != nil {
 		if o, ok := s.(*syntax.ObjectNode); ok {
-			validKeys := append(v.Fields(), "condition", "metadata")
+			validKeys := append(v.Fields(), "metadata")
 			fmtr := yamldiags.InvalidFieldBagFormatter{
 				ParentLabel: fmt.Sprintf("Resource %s", typ.String()),
 				MaxListed:   5,
@@ -917,6 +952,115 @@ func (tc *typeCache) typeInvoke(ctx *evalContext, t *ast.InvokeExpr) bool {
 	return true
 }
 
+// typeCall checks the arguments of a call against the parameters of the function it calls. The call
+// has the type of the function's body.
+func (tc *typeCache) typeCall(ctx *evalContext, t *ast.CallExpr) bool {
+	fn, ok := ctx.t.GetFunctions().Get(t.Function.Value)
+	if !ok && t.IsShorthand() {
+		obj, diag := t.ReservedObject()
+		ctx.addWarnDiag(diag.Subject, diag.Summary, diag.Detail)
+		tc.typeExpr(ctx, obj)
+		tc.exprs[t] = tc.exprs[obj]
+		return true
+	}
+	if !ok {
+		ctx.errorf(t.Function, "function %q is not declared", t.Function.Value)
+		tc.exprs[t] = &schema.InvalidType{}
+		return true
+	}
+	if diag := t.ArgsError(); diag != nil {
+		ctx.addErrDiag(diag.Subject, diag.Summary, diag.Detail)
+		tc.exprs[t] = &schema.InvalidType{}
+		return true
+	}
+
+	params := map[string]*ast.ConfigParamDecl{}
+	for _, param := range fn.Parameters.Entries {
+		params[param.Key.Value] = param.Value
+	}
+	passed := map[string]bool{}
+	if t.CallArgs != nil {
+		for _, kvp := range t.CallArgs.Entries {
+			k, ok := kvp.Key.(*ast.StringExpr)
+			if !ok {
+				continue
+			}
+			param, ok := params[k.Value]
+			if !ok {
+				ctx.errorf(kvp.Key, "function %q has no parameter named %q", t.Function.Value, k.Value)
+				continue
+			}
+			passed[k.Value] = true
+			tc.assertTypeAssignable(ctx, kvp.Value, tc.parameterType(param))
+		}
+	}
+	for _, param := range fn.Parameters.Entries {
+		if !passed[param.Key.Value] && (param.Value == nil || param.Value.Default == nil) {
+			ctx.errorf(t, "missing argument %q to function %q", param.Key.Value, t.Function.Value)
+		}
+	}
+
+	if typ, ok := tc.exprs[fn.Body]; ok {
+		tc.exprs[t] = typ
+	} else {
+		tc.exprs[t] = schema.AnyType
+	}
+	return true
+}
+
+// typeFunction checks the parameter declarations of a function.
+func (tc *typeCache) typeFunction(r *Runner, node functionNode) bool {
+	if node.Value == nil {
+		return true
+	}
+	ctx := r.newContext(node)
+	for _, param := range node.Value.Parameters.Entries {
+		if param.Value == nil || param.Value.Type == nil {
+			continue
+		}
+		ctype, ok := ctypes.Parse(param.Value.Type.Value)
+		if !ok {
+			ctx.errorf(param.Value.Type, "unknown type %q for parameter %q", param.Value.Type.Value, param.Key.Value)
+			continue
+		}
+		if param.Value.Default != nil {
+			tc.assertTypeAssignable(ctx, param.Value.Default, ctype.Schema())
+		}
+	}
+	return true
+}
+
+// parameterType returns the type of a function parameter: its declared type, or else the type of
+// its default, or else any.
+func (tc *typeCache) parameterType(param *ast.ConfigParamDecl) schema.Type {
+	if param == nil {
+		return schema.AnyType
+	}
+	if param.Type != nil {
+		if ctype, ok := ctypes.Parse(param.Type.Value); ok {
+			return ctype.Schema()
+		}
+		return schema.AnyType
+	}
+	if typ, ok := tc.exprs[param.Default]; ok && param.Default != nil {
+		return typ
+	}
+	return schema.AnyType
+}
+
+// markOptionalSymbols records the property accesses among the arguments of x that may refer to
+// properties that do not exist.
+func (tc *typeCache) markOptionalSymbols(ctx *evalContext, x ast.BuiltinExpr) bool {
+	if x, ok := x.(*ast.TryExpr); ok {
+		for _, v := range x.Values {
+			if sym, ok := v.(*ast.SymbolExpr); ok {
+				tc.optionalSymbols[sym] = true
+			}
+		}
+	}
+	return true
+}
+
 func (tc *typeCache) typeSymbol(ctx *evalContext, t *ast.SymbolExpr) bool {
 	var typ schema.Type = &schema.InvalidType{}
 	if root, ok := tc.resourceNames[t.Property.RootName()]; ok {
@@ -928,6 +1072,35 @@ func (tc *typeCache) typeSymbol(ctx *evalContext, t *ast.SymbolExpr) bool {
 	if root, ok := tc.configuration[t.Property.RootName()]; ok {
 		typ = root
 	}
+	if node, ok := ctx.root.(functionNode); ok && node.Value != nil {
+		// Within a function's body, its parameters shadow any other name.
+		for _, param := range node.Value.Parameters.Entries {
+			if param.Key.Value == t.Property.RootName() {
+				typ = tc.parameterType(param.Value)
+			}
+		}
+	}
+	if t.Property.RootName() == RangeVarName {
+		if len(ctx.loops) != 0 {
+			key, value := collectionElementTypes(tc.exprs[ctx.loops[len(ctx.loops)-1].In])
+			typ = &schema.ObjectType{
+				Token: adhockObjectToken + RangeVarName,
+				Properties: []*schema.Property{
+					{Name: "key", Type: key},
+					{Name: "value", Type: value},
+				},
+			}
+		} else if node, ok := ctx.root.(resourceNode); ok && (node.Value.Count != nil || node.Value.ForEach != nil) {
+			key, value := tc.rangeElementTypes(node.Value)
+			typ = &schema.ObjectType{
+				Token: adhockObjectToken + RangeVarName,
+				Properties: []*schema.Property{
+					{Name: "key", Type: key},
+					{Name: "value", Type: value},
+				},
+			}
+		}
+	}
 	runningName := t.Property.RootName()
 	setError := func(summary, detail string) *schema.InvalidType {
 		diag := syntax.Error(t.Syntax().Syntax().Range(), summary, detail)
@@ -938,12 +1111,96 @@ func (tc *typeCache) typeSymbol(ctx *evalContext, t *ast.SymbolExpr) bool {
 		return typ
 	}
 
-	tc.exprs[t] = typePropertyAccess(ctx, typ, runningName, t.Property.Accessors[1:], setError)
+	tc.exprs[t] = typePropertyAccess(ctx, typ, runningName, t.Property.Accessors[1:], tc.optionalSymbols[t], setError)
 	return true
 }
 
+// rangeElementTypes returns the types of `range.key` and `range.value` within a resource declared
+// with count or forEach.
+func (tc *typeCache) rangeElementTypes(v *ast.ResourceDecl) (schema.Type, schema.Type) {
+	if v.Count != nil {
+		return schema.IntType, schema.IntType
+	}
+	return collectionElementTypes(tc.exprs[v.ForEach])
+}
+
+// collectionElementTypes returns the types of the keys and elements of a list, map or object type.
+// Both are any for other types.
+func collectionElementTypes(typ schema.Type) (schema.Type, schema.Type) {
+	switch typ := codegen.UnwrapType(typ).(type) {
+	case *schema.ArrayType:
+		return schema.IntType, typ.ElementType
+	case *schema.MapType:
+		return schema.StringType, typ.ElementType
+	case *schema.ObjectType:
+		types := make([]schema.Type, len(typ.Properties))
+		for i, prop := range typ.Properties {
+			types[i] = prop.Type
+		}
+		return schema.StringType, unionOf(types...)
+	default:
+		return schema.AnyType, schema.AnyType
+	}
+}
+
+// unionOf returns the union of the distinct types given, or any if none are given.
+func unionOf(types ...schema.Type) schema.Type {
+	var set OrderedTypeSet
+	for _, typ := range types {
+		if union, ok := typ.(*schema.UnionType); ok {
+			for _, t := range union.ElementTypes {
+				set.Add(t)
+			}
+			continue
+		}
+		set.Add(typ)
+	}
+	switch set.Len() {
+	case 0:
+		return schema.AnyType
+	case 1:
+		return set.First()
+	default:
+		return &schema.UnionType{ElementTypes: set.Values()}
+	}
+}
+
+// validUnionOf is like unionOf, but skips types that are missing or invalid, such as the type of
+// null. It returns an invalid type if no valid types are given.
+func validUnionOf(types ...schema.Type) schema.Type {
+	valid := make([]schema.Type, 0, len(types))
+	for _, typ := range types {
+		if _, invalid := typ.(*schema.InvalidType); typ != nil && !invalid {
+			valid = append(valid, typ)
+		}
+	}
+	if len(valid) == 0 {
+		return &schema.InvalidType{}
+	}
+	return unionOf(valid...)
+}
+
+// nestedElementTypes returns the union of the element types of each collection type that typ may
+// be. Passing a list of lists gives the type of the elements of the inner lists.
+func nestedElementTypes(typ schema.Type) schema.Type {
+	_, elem := collectionElementTypes(typ)
+	members := []schema.Type{elem}
+	if union, ok := elem.(*schema.UnionType); ok {
+		members = union.ElementTypes
+	}
+	types := make([]schema.Type, len(members))
+	for i, member := range members {
+		_, types[i] = collectionElementTypes(member)
+	}
+	return unionOf(types...)
+}
+
+// typePropertyAccess returns the type of the property at the end of accessors, starting from a value of type root.
+// If optional is set, as it is for the arguments of fn::try, a property that does not exist evaluates to null rather
+// than being an error, so its type is left unconstrained. Other errors, such as accessing a property of a string, are
+// reported with setError either way.
 func typePropertyAccess(ctx *evalContext, root schema.Type,
-	runningName string, accessors []ast.PropertyAccessor,
+	runningName string, accessors []ast.PropertyAccessor, optional bool,
 	setError func(summary, detail string) *schema.InvalidType,
 ) schema.Type {
 	if len(accessors) == 0 {
@@ -959,7 +1216,7 @@ func typePropertyAccess(ctx *evalContext, root schema.Type,
 		var possibilities OrderedTypeSet
 		errs := []*notAssignable{}
 		for _, subtypes := range root.ElementTypes {
-			t := typePropertyAccess(ctx, subtypes, runningName, accessors,
+			t := typePropertyAccess(ctx, subtypes, runningName, accessors, optional,
 				func(summary, detail string) *schema.InvalidType {
 					errs = append(errs, &notAssignable{reason: summary, property: subtypes.String()})
 					return &schema.InvalidType{}
@@ -1015,6 +1272,9 @@ func typePropertyAccess(ctx *evalContext, root schema.Type,
 		}
 		// We handle the actual property access here
 		newType, ok := properties[accessor.Name]
+		if !ok && optional {
+			return &schema.InvalidType{}
+		}
 		if !ok {
 			propertyList := make([]string, 0, len(properties))
 			for k := range properties {
@@ -1029,7 +1289,7 @@ func typePropertyAccess(ctx *evalContext, root schema.Type,
 			summary, detail := fmtr.MessageWithDetail(accessor.Name, accessor.Name)
 			return setError(summary, detail)
 		}
-		return typePropertyAccess(ctx, newType, runningName+"."+accessor.Name, accessors[1:], setError)
+		return typePropertyAccess(ctx, newType, runningName+"."+accessor.Name, accessors[1:], optional, setError)
 	case *ast.PropertySubscript:
 		err := func(typ, msg string) *schema.InvalidType {
 			return setError(
@@ -1045,14 +1305,14 @@ func typePropertyAccess(ctx *evalContext, root schema.Type,
 			}
 			return typePropertyAccess(ctx, root.ElementType,
 				runningName+fmt.Sprintf("[%d]", accessor.Index.(int)),
-				accessors[1:], setError)
+				accessors[1:], optional, setError)
 		case *schema.MapType:
 			if _, ok := accessor.Index.(int); ok {
 				return err(" via number", "Index via number is only allowed on Arrays")
 			}
 			return typePropertyAccess(ctx, root.ElementType,
 				runningName+fmt.Sprintf("[%q]", accessor.Index.(string)),
-				accessors[1:], setError)
+				accessors[1:], optional, setError)
 		case *schema.InvalidType:
 			return &schema.InvalidType{}
 		default:
@@ -1072,6 +1332,8 @@ func (tc *typeCache) typeExpr(ctx *evalContext, t ast.Expr) bool {
 	switch t := t.(type) {
 	case *ast.InvokeExpr:
 		return tc.typeInvoke(ctx, t)
+	case *ast.CallExpr:
+		return tc.typeCall(ctx, t)
 	case *ast.SymbolExpr:
 		return tc.typeSymbol(ctx, t)
 	case *ast.StringExpr:
@@ -1087,8 +1349,14 @@ func (tc *typeCache) typeExpr(ctx *evalContext, t ast.Expr) bool {
 	case *ast.InterpolateExpr:
 		// TODO: verify that internal access can be coerced into a string
 		tc.exprs[t] = schema.StringType
-	case *ast.ToJSONExpr:
+	case *ast.ToJSONExpr, *ast.ToYAMLExpr:
 		tc.exprs[t] = schema.StringType
+	case *ast.FromJSONExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.AnyType
+	case *ast.FromYAMLExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.AnyType
 	case *ast.JoinExpr:
 		tc.assertTypeAssignable(ctx, t.Delimiter, schema.StringType)
 		tc.exprs[t] = schema.StringType
@@ -1150,6 +1418,143 @@ func (tc *typeCache) typeExpr(ctx *evalContext, t ast.Expr) bool {
 		tc.assertTypeAssignable(ctx, t.Delimiter, schema.StringType)
 		tc.assertTypeAssignable(ctx, t.Source, schema.StringType)
 		tc.exprs[t] = &schema.ArrayType{ElementType: schema.StringType}
+	case *ast.ReplaceExpr:
+		tc.assertTypeAssignable(ctx, t.Source, schema.StringType)
+		tc.assertTypeAssignable(ctx, t.Search, schema.StringType)
+		tc.assertTypeAssignable(ctx, t.Replacement, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.UpperExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.LowerExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.TrimExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.MimeTypeExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.SubstringExpr:
+		tc.assertTypeAssignable(ctx, t.Source, schema.StringType)
+		tc.assertTypeAssignable(ctx, t.Start, schema.IntType)
+		tc.assertTypeAssignable(ctx, t.Length, schema.IntType)
+		tc.exprs[t] = schema.StringType
+	case *ast.FormatExpr:
+		tc.assertTypeAssignable(ctx, t.Format, schema.StringType)
+		tc.exprs[t] = schema.StringType
+	case *ast.MergeExpr:
+		tc.exprs[t] = &schema.MapType{ElementType: nestedElementTypes(tc.exprs[t.Value])}
+	case *ast.KeysExpr:
+		tc.exprs[t] = &schema.ArrayType{ElementType: schema.StringType}
+	case *ast.ValuesExpr:
+		_, elem := collectionElementTypes(tc.exprs[t.Value])
+		tc.exprs[t] = &schema.ArrayType{ElementType: elem}
+	case *ast.FlattenExpr:
+		tc.assertTypeAssignable(ctx, t.Value, &schema.ArrayType{ElementType: schema.AnyType})
+		var flatten func(typ schema.Type) schema.Type
+		flatten = func(typ schema.Type) schema.Type {
+			switch typ := codegen.UnwrapType(typ).(type) {
+			case *schema.ArrayType:
+				return flatten(typ.ElementType)
+			case *schema.UnionType:
+				types := make([]schema.Type, len(typ.ElementTypes))
+				for i, t := range typ.ElementTypes {
+					types[i] = flatten(t)
+				}
+				return unionOf(types...)
+			default:
+				return typ
+			}
+		}
+		tc.exprs[t] = &schema.ArrayType{ElementType: flatten(tc.exprs[t.Value])}
+	case *ast.ConcatExpr:
+		tc.assertTypeAssignable(ctx, t.Value, &schema.ArrayType{ElementType: schema.AnyType})
+		tc.exprs[t] = &schema.ArrayType{ElementType: nestedElementTypes(tc.exprs[t.Value])}
+	case *ast.DistinctExpr:
+		tc.assertTypeAssignable(ctx, t.Value, &schema.ArrayType{ElementType: schema.AnyType})
+		_, elem := collectionElementTypes(tc.exprs[t.Value])
+		tc.exprs[t] = &schema.ArrayType{ElementType: elem}
+	case *ast.ContainsExpr:
+		tc.assertTypeAssignable(ctx, t.Values, &schema.ArrayType{ElementType: schema.AnyType})
+		tc.exprs[t] = schema.BoolType
+	case *ast.ZipExpr:
+		tc.assertTypeAssignable(ctx, t.Value, &schema.ArrayType{ElementType: schema.AnyType})
+		tc.exprs[t] = &schema.ArrayType{
+			ElementType: &schema.ArrayType{ElementType: nestedElementTypes(tc.exprs[t.Value])},
+		}
+	case *ast.LookupExpr:
+		tc.assertTypeAssignable(ctx, t.Key, schema.StringType)
+		_, valueType := collectionElementTypes(tc.exprs[t.Map])
+		obj, isObject := codegen.UnwrapType(tc.exprs[t.Map]).(*schema.ObjectType)
+		if key, isLiteral := t.Key.(*ast.StringExpr); isObject && isLiteral {
+			// A key that the object does not have is not an error: it selects the default.
+			valueType = nil
+			if prop, ok := obj.Property(key.Value); ok {
+				valueType = prop.Type
+			}
+		}
+		tc.exprs[t] = validUnionOf(valueType, tc.exprs[t.Default])
+	case *ast.FindInMapExpr:
+		tc.assertTypeAssignable(ctx, t.TopLevelKey, schema.StringType)
+		tc.assertTypeAssignable(ctx, t.SecondLevelKey, schema.StringType)
+		if _, ok := ctx.t.GetMappings().Get(t.MapName.Value); !ok {
+			ctx.errorf(t.MapName, "mapping %q is not declared", t.MapName.Value)
+		}
+		tc.exprs[t] = schema.AnyType
+	case *ast.ForExpr:
+		if t.If != nil {
+			tc.assertTypeAssignable(ctx, t.If, schema.BoolType)
+		}
+		if t.Key != nil {
+			tc.assertTypeAssignable(ctx, t.Key, schema.StringType)
+			tc.exprs[t] = &schema.MapType{ElementType: tc.exprs[t.Value]}
+		} else {
+			tc.exprs[t] = &schema.ArrayType{ElementType: tc.exprs[t.Value]}
+		}
+	case *ast.TryExpr:
+		types := make([]schema.Type, len(t.Values))
+		for i, v := range t.Values {
+			types[i] = tc.exprs[v]
+		}
+		tc.exprs[t] = validUnionOf(types...)
+	case *ast.RegexExpr:
+		tc.assertTypeAssignable(ctx, t.Pattern, schema.StringType)
+		tc.assertTypeAssignable(ctx, t.Source, schema.StringType)
+		switch t.Operator {
+		case ast.RegexMatch:
+			tc.exprs[t] = schema.BoolType
+		case ast.RegexReplace:
+			tc.assertTypeAssignable(ctx, t.Replacement, schema.StringType)
+			tc.exprs[t] = schema.StringType
+		case ast.RegexFind:
+			tc.exprs[t] = &schema.ArrayType{ElementType: schema.StringType}
+		}
+	case *ast.IfExpr:
+		tc.assertTypeAssignable(ctx, t.Condition, schema.BoolType)
+		// A branch without a valid type, such as null, does not constrain the result.
+		tc.exprs[t] = validUnionOf(tc.exprs[t.Then], tc.exprs[t.Else])
+	case *ast.NotExpr:
+		tc.assertTypeAssignable(ctx, t.Value, schema.BoolType)
+		tc.exprs[t] = schema.BoolType
+	case *ast.LogicalExpr:
+		for _, v := range t.Values {
+			tc.assertTypeAssignable(ctx, v, schema.BoolType)
+		}
+		tc.exprs[t] = schema.BoolType
+	case *ast.ComparisonExpr:
+		if t.Operator != ast.Equals && t.Operator != ast.NotEquals {
+			// Values are ordered as numbers or as strings.
+			ordered := &schema.UnionType{ElementTypes: []schema.Type{schema.NumberType, schema.StringType}}
+			tc.assertTypeAssignable(ctx, t.Left, ordered)
+			tc.assertTypeAssignable(ctx, t.Right, ordered)
+		}
+		tc.exprs[t] = schema.BoolType
+	case *ast.ArithmeticExpr:
+		for _, v := range t.Values {
+			tc.assertTypeAssignable(ctx, v, schema.NumberType)
+		}
+		tc.exprs[t] = schema.NumberType
 	case *ast.SelectExpr:
 		tc.assertTypeAssignable(ctx, t.Index, schema.IntType)
 		tc.assertTypeAssignable(ctx, t.Values,
@@ -1183,6 +1588,13 @@ func (tc *typeCache) typeExpr(ctx *evalContext, t ast.Expr) bool {
 func (tc *typeCache) typeVariable(r *Runner, node variableNode) bool {
 	k, v := node.Key.Value, node.Value
 	tc.variableNames[k] = v
+	// Conditions are evaluated as variables, but must be booleans.
+	for _, entry := range r.t.GetConditions().Entries {
+		if entry.Key.Value == k {
+			tc.assertTypeAssignable(r.newContext(node), v, schema.BoolType)
+			break
+		}
+	}
 	return true
 }
 
@@ -1297,7 +1709,8 @@ func newTypeCache() *typeCache {
 		variableNames: map[string]ast.Expr{
 			PulumiVarName: pulumiExpr,
 		},
-		outputs: map[string]schema.Type{},
+		outputs:         map[string]schema.Type{},
+		optionalSymbols: map[*ast.SymbolExpr]bool{},
 	}
 }
 
@@ -1308,8 +1721,10 @@ func TypeCheck(r *Runner) (Typing, syntax.Diagnostics) {
 	diags := r.Run(walker{
 		VisitResource: types.typeResource,
 		VisitExpr:     types.typeExpr,
+		VisitBuiltin:  types.markOptionalSymbols,
 		VisitVariable: types.typeVariable,
 		VisitConfig:   types.typeConfig,
+		VisitFunction: types.typeFunction,
 		VisitMissing:  types.typeMissing,
 		VisitOutput:   types.typeOutput,
 	})
@@ -1322,8 +1737,11 @@ type walker struct {
 	VisitVariable func(r *Runner, node variableNode) bool
 	VisitOutput   func(r *Runner, node ast.PropertyMapEntry) bool
 	VisitResource func(r *Runner, node resourceNode) bool
+	VisitFunction func(r *Runner, node functionNode) bool
 	VisitMissing  func(r *Runner, node missingNode) bool
 	VisitExpr     func(*evalContext, ast.Expr) bool
+	// VisitBuiltin, if set, is called on a builtin before its arguments are walked.
+	VisitBuiltin func(*evalContext, ast.BuiltinExpr) bool
 }
 
 func (e walker) walk(ctx *evalContext, x ast.Expr) bool {
@@ -1348,7 +1766,26 @@ func (e walker) walk(ctx *evalContext, x ast.Expr) bool {
 			}
 		}
 	case *ast.InterpolateExpr, *ast.SymbolExpr:
+	case *ast.ForExpr:
+		if e.VisitBuiltin != nil && !e.VisitBuiltin(ctx, x) {
+			return false
+		}
+		if !e.walk(ctx, x.Name()) || !e.walk(ctx, x.In) {
+			return false
+		}
+		// The collection is walked first, outside of the loop, so that the type of `range` is known
+		// within the other arguments.
+		ctx.loops = append(ctx.loops, x)
+		for _, arg := range []ast.Expr{x.Key, x.Value, x.If} {
+			if !e.walk(ctx, arg) {
+				return false
+			}
+		}
+		ctx.loops = ctx.loops[:len(ctx.loops)-1]
 	case ast.BuiltinExpr:
+		if e.VisitBuiltin != nil && !e.VisitBuiltin(ctx, x) {
+			return false
+		}
 		if !e.walk(ctx, x.Name()) {
 			return false
 		}
@@ -1446,6 +1883,15 @@ func (e walker) EvalResource(r *Runner, node resourceNode) bool {
 		if !e.walk(ctx, v.Type) {
 			return false
 		}
+		if !e.walk(ctx, v.Condition) {
+			return false
+		}
+		if !e.walk(ctx, v.Count) {
+			return false
+		}
+		if !e.walk(ctx, v.ForEach) {
+			return false
+		}
 		if v.Properties.PropertyMap != nil {
 			if !e.walkPropertyMap(ctx, *v.Properties.PropertyMap) {
 				return false
@@ -1469,6 +1915,26 @@ func (e walker) EvalResource(r *Runner, node resourceNode) bool {
 	return true
 }
 
+func (e walker) EvalFunction(r *Runner, node functionNode) bool {
+	if e.VisitExpr != nil && node.Value != nil {
+		ctx := r.newContext(node)
+		for _, param := range node.Value.Parameters.Entries {
+			if param.Value != nil && !e.walk(ctx, param.Value.Default) {
+				return false
+			}
+		}
+		if !e.walk(ctx, node.Value.Body) {
+			return false
+		}
+	}
+	if e.VisitFunction != nil {
+		if !e.VisitFunction(r, node) {
+			return false
+		}
+	}
+	return true
+}
+
 func (e walker) EvalMissing(r *Runner, node missingNode) bool {
 	if e.VisitMissing != nil {
 		if !e.VisitMissing(r, node) {
@@ -299,7 +299,7 @@ func TestTypePropertyAccess(t *testing.T) {
 				actualMsg += m + ":" + s + "\n"
 				return &schema.InvalidType{}
 			}
-			actualType := typePropertyAccess(nil, c.root, "start", c.list, setError)
+			actualType := typePropertyAccess(nil, c.root, "start", c.list, false, setError)
 			assert.Equal(t, c.expectedType, displayType(actualType))
 			assert.Equal(t, c.errMsg, strings.TrimSuffix(actualMsg, "\n"))
 		})
@@ -622,3 +622,125 @@ resources:
 		}}, diags)
 	})
 }
+
+func TestCollectionBuiltinTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  tags:
+    fn::merge:
+      - team: infra
+      - count: 3
+  tagKeys:
+    fn::keys: ${tags}
+  subnets:
+    fn::concat: [ [ "a", "b" ], [ "c" ] ]
+  flat:
+    fn::flatten: [ [ 1, [ 2 ] ] ]
+  pairs:
+    fn::zip: [ [ "a" ], [ "b" ] ]
+  hasA:
+    fn::contains: [ "${subnets}", "a" ]
+`
+	tmpl := yamlTemplate(t, text)
+	typing, diags := TypeCheck(newRunner(tmpl, newMockPackageMap()))
+	requireNoErrors(t, tmpl, diags)
+
+	for name, expected := range map[string]string{
+		"tags":    "Map<Union<string, number>>",
+		"tagKeys": "List<string>",
+		"subnets": "List<string>",
+		"flat":    "List<number>",
+		"pairs":   "List<List<string>>",
+		"hasA":    "boolean",
+	} {
+		assert.Equal(t, expected, displayType(typing.TypeVariable(name)), name)
+	}
+}
+
+func TestFunctionCallTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  qualify:
+    parameters:
+      name:
+        type: string
+      replicas:
+        type: number
+    body:
+      fn::format: [ "%s-%d", "${name}", "${replicas}" ]
+variables:
+  good:
+    fn::qualify:
+      name: web
+      replicas: 2
+  bad:
+    fn::qualify:
+      name: [ "web" ]
+      replicas: 2
+      extra: true
+`
+	tmpl := yamlTemplate(t, text)
+	typing, diags := TypeCheck(newRunner(tmpl, newMockPackageMap()))
+	require.True(t, diags.HasErrors())
+
+	var summaries []string
+	for _, d := range diags {
+		if d.Severity == hcl.DiagError {
+			summaries = append(summaries, d.Summary)
+		}
+	}
+	assert.Contains(t, summaries, `function "qualify" has no parameter named "extra"`)
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, "string", displayType(typing.TypeVariable("good")))
+}
+
+func TestTryTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	errorSummaries := func(text string) []string {
+		tmpl := yamlTemplate(t, text)
+		_, diags := TypeCheck(newRunner(tmpl, newMockPackageMap()))
+		var summaries []string
+		for _, d := range diags {
+			if d.Severity == hcl.DiagError {
+				summaries = append(summaries, d.Summary)
+			}
+		}
+		return summaries
+	}
+
+	// A property that does not exist is null under fn::try, but other type errors are still reported.
+	assert.Equal(t, []string{
+		"cannot access a property on 'bucket.foo' (type string)",
+		"Cannot index into 'bucket.foo' (type string)",
+	}, errorSummaries(`name: test-yaml
+runtime: yaml
+resources:
+  bucket:
+    type: test:resource:type
+variables:
+  missing:
+    fn::try: [ "${bucket.scaling.replicas}", 1 ]
+  property:
+    fn::try: [ "${bucket.foo.length}", 1 ]
+  index:
+    fn::try: [ "${bucket.foo[0]}", 1 ]
+`))
+
+	// A misspelled name is not a missing property.
+	assert.Equal(t, []string{`resource, variable, or config value "bukcet" not found`}, errorSummaries(`name: test-yaml
+runtime: yaml
+resources:
+  bucket:
+    type: test:resource:type
+variables:
+  typo:
+    fn::try: [ "${bukcet.foo}", 1 ]
+`))
+}
@@ -14,6 +14,8 @@ import (
 
 var fnInvokeRegex = regexp.MustCompile("fn::[^:]+:[^:]+(:[^:]+)?$")
 
+var fnCallRegex = regexp.MustCompile("^fn::[a-zA-Z_][a-zA-Z0-9_]*$")
+
 // Expr represents a Pulumi YAML expression. Expressions may be literals, interpolated strings, symbols, or builtin
 // functions.
 type Expr interface {
@@ -472,6 +474,78 @@ func Invoke(token string, callArgs *ObjectExpr, callOpts InvokeOptionsDecl, ret
 	}
 }
 
+// CallExpr calls a function declared in the template's functions section. It is written either as
+// `fn::call: {function: name, args: {...}}` or, as the function is declared, as `fn::name: {...}`.
+type CallExpr struct {
+	builtinNode
+
+	Function *StringExpr
+	CallArgs *ObjectExpr
+
+	// shorthand is set if the call is written as `fn::${function}` rather than with fn::call.
+	shorthand bool
+}
+
+func CallSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, function *StringExpr, callArgs *ObjectExpr) *CallExpr {
+	return &CallExpr{
+		builtinNode: builtin(node, name, args),
+		Function:    function,
+		CallArgs:    callArgs,
+	}
+}
+
+// IsShorthand returns true if the call is written as `fn::${function}` rather than with fn::call.
+func (x *CallExpr) IsShorthand() bool {
+	return x.shorthand
+}
+
+// ReservedObject returns the object that a shorthand call denotes when its template declares no function of that
+// name, along with the warning that its key has the reserved `fn::` prefix. A key such as `fn::jion` is then read as
+// an object key, as it would be if the template declared no functions at all.
+func (x *CallExpr) ReservedObject() (*ObjectExpr, *syntax.Diagnostic) {
+	node, _ := x.syntax.(*syntax.ObjectNode)
+	var kvp syntax.ObjectPropertyDef
+	if node != nil && node.Len() == 1 {
+		kvp = node.Index(0)
+	}
+	obj := ObjectSyntax(node, ObjectProperty{syntax: kvp, Key: x.name, Value: x.args})
+	return obj, reservedPrefixWarning(x.name)
+}
+
+// ArgsError returns an error if x is a shorthand call whose argument is neither an object of function arguments nor
+// null.
+func (x *CallExpr) ArgsError() *syntax.Diagnostic {
+	if !x.shorthand || x.CallArgs != nil {
+		return nil
+	}
+	if _, ok := x.args.(*NullExpr); ok {
+		return nil
+	}
+	return ExprError(x.args, fmt.Sprintf("the argument to %s must be an object of function arguments", x.name.Value), "")
+}
+
+// reservedPrefixWarning returns the warning for key, which has the reserved `fn::` prefix but names no builtin.
+func reservedPrefixWarning(key *StringExpr) *syntax.Diagnostic {
+	var rng *hcl.Range
+	if key.Syntax() != nil {
+		rng = key.Syntax().Syntax().Range()
+	}
+	return syntax.Warning(rng, "'fn::' is a reserved prefix",
+		fmt.Sprintf("If you need to use the raw key '%s',"+
+			" please open an issue at https://github.com/pulumi/pulumi-yaml/issues", key.Value))
+}
+
+func Call(function string, callArgs *ObjectExpr) *CallExpr {
+	name, fn := String("fn::call"), String(function)
+
+	entries := []ObjectProperty{{Key: String("function"), Value: fn}}
+	if callArgs != nil {
+		entries = append(entries, ObjectProperty{Key: String("args"), Value: callArgs})
+	}
+
+	return CallSyntax(nil, name, Object(entries...), fn, callArgs)
+}
+
 // ToJSON returns the underlying structure as a json string.
 type ToJSONExpr struct {
 	builtinNode
@@ -491,6 +565,63 @@ func ToJSON(value Expr) *ToJSONExpr {
 	return ToJSONSyntax(nil, name, value)
 }
 
+// FromJSONExpr parses a JSON string into the structure it encodes.
+type FromJSONExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func FromJSONSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FromJSONExpr {
+	return &FromJSONExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func FromJSON(value Expr) *FromJSONExpr {
+	name := String("fn::fromJSON")
+	return FromJSONSyntax(nil, name, value)
+}
+
+// FromYAMLExpr parses a YAML string into the structure it encodes.
+type FromYAMLExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func FromYAMLSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FromYAMLExpr {
+	return &FromYAMLExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func FromYAML(value Expr) *FromYAMLExpr {
+	name := String("fn::fromYAML")
+	return FromYAMLSyntax(nil, name, value)
+}
+
+// ToYAMLExpr returns the underlying structure as a YAML string.
+type ToYAMLExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func ToYAMLSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToYAMLExpr {
+	return &ToYAMLExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func ToYAML(value Expr) *ToYAMLExpr {
+	name := String("fn::toYAML")
+	return ToYAMLSyntax(nil, name, value)
+}
+
 // JoinExpr appends a set of values into a single value, separated by the specified delimiter.
 // If a delimiter is the empty string, the set of values are concatenated with no delimiter.
 type JoinExpr struct {
@@ -544,6 +675,179 @@ func Split(delimiter, source Expr) *SplitExpr {
 	}
 }
 
+// ReplaceExpr replaces every occurrence of Search in Source with Replacement.
+type ReplaceExpr struct {
+	builtinNode
+
+	Source      Expr
+	Search      Expr
+	Replacement Expr
+}
+
+func ReplaceSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *ReplaceExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+	return &ReplaceExpr{
+		builtinNode: builtin(node, name, args),
+		Source:      elems[0],
+		Search:      elems[1],
+		Replacement: elems[2],
+	}
+}
+
+func Replace(source, search, replacement Expr) *ReplaceExpr {
+	return ReplaceSyntax(nil, String("fn::replace"), List(source, search, replacement))
+}
+
+// UpperExpr converts a string to upper case.
+type UpperExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func UpperSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *UpperExpr {
+	return &UpperExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func Upper(value Expr) *UpperExpr {
+	return UpperSyntax(nil, String("fn::upper"), value)
+}
+
+// LowerExpr converts a string to lower case.
+type LowerExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func LowerSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *LowerExpr {
+	return &LowerExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func Lower(value Expr) *LowerExpr {
+	return LowerSyntax(nil, String("fn::lower"), value)
+}
+
+// TrimExpr removes leading and trailing whitespace from a string.
+type TrimExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func TrimSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *TrimExpr {
+	return &TrimExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func Trim(value Expr) *TrimExpr {
+	return TrimSyntax(nil, String("fn::trim"), value)
+}
+
+// SubstringExpr returns at most Length characters of Source, starting at Start. A Length of -1
+// selects the rest of the string.
+type SubstringExpr struct {
+	builtinNode
+
+	Source Expr
+	Start  Expr
+	Length Expr
+}
+
+func SubstringSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *SubstringExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+	return &SubstringExpr{
+		builtinNode: builtin(node, name, args),
+		Source:      elems[0],
+		Start:       elems[1],
+		Length:      elems[2],
+	}
+}
+
+func Substring(source, start, length Expr) *SubstringExpr {
+	return SubstringSyntax(nil, String("fn::substring"), List(source, start, length))
+}
+
+// FormatExpr formats its arguments according to a printf-style format string.
+type FormatExpr struct {
+	builtinNode
+
+	Format Expr
+	Values []Expr
+}
+
+func FormatSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *FormatExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) >= 1, "Must have at least 1 element")
+	return &FormatExpr{
+		builtinNode: builtin(node, name, args),
+		Format:      elems[0],
+		Values:      elems[1:],
+	}
+}
+
+func Format(format Expr, args ...Expr) *FormatExpr {
+	return FormatSyntax(nil, String("fn::format"), List(append([]Expr{format}, args...)...))
+}
+
+// RegexOperator is the operation performed by a RegexExpr. Its value is the name of the builtin
+// without the `fn::` prefix.
+type RegexOperator string
+
+const (
+	// RegexMatch reports whether the pattern matches the source.
+	RegexMatch RegexOperator = "regexMatch"
+	// RegexReplace replaces each match of the pattern in the source. The replacement may refer to
+	// capture groups as `$1` or `$${name}`; the `$` of a braced reference is doubled so that it is
+	// not read as an interpolation.
+	RegexReplace RegexOperator = "regexReplace"
+	// RegexFind returns the list of matches of the pattern in the source.
+	RegexFind RegexOperator = "regexFind"
+)
+
+// RegexExpr applies an RE2 regular expression to a string. Replacement is only set for
+// fn::regexReplace.
+type RegexExpr struct {
+	builtinNode
+
+	Operator    RegexOperator
+	Pattern     Expr
+	Source      Expr
+	Replacement Expr
+}
+
+func RegexSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr, op RegexOperator) *RegexExpr {
+	elems := args.Elements
+	x := &RegexExpr{
+		builtinNode: builtin(node, name, args),
+		Operator:    op,
+		Pattern:     elems[0],
+		Source:      elems[1],
+	}
+	if op == RegexReplace {
+		contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+		x.Replacement = elems[2]
+	} else {
+		contract.Assertf(len(elems) == 2, "Must have exactly 2 elements")
+	}
+	return x
+}
+
+func Regex(op RegexOperator, pattern, source Expr, replacement ...Expr) *RegexExpr {
+	name := String("fn::" + string(op))
+	return RegexSyntax(nil, name, List(append([]Expr{pattern, source}, replacement...)...), op)
+}
+
 // SelectExpr returns a single object from a list of objects by index.
 type SelectExpr struct {
 	builtinNode
@@ -569,6 +873,28 @@ func Select(index Expr, values Expr) *SelectExpr {
 	}
 }
 
+// MimeTypeExpr returns the MIME type of the file at the path Value, as guessed from its extension.
+type MimeTypeExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func MimeTypeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *MimeTypeExpr {
+	return &MimeTypeExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func MimeType(value Expr) *MimeTypeExpr {
+	return MimeTypeSyntax(nil, String("fn::mimeType"), value)
+}
+
+func parseMimeType(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return MimeTypeSyntax(node, name, args), nil
+}
+
 type ToBase64Expr struct {
 	builtinNode
 
@@ -832,6 +1158,331 @@ func parseSingleOrNone(node *syntax.ObjectNode, name *StringExpr, args Expr) (Ex
 	return SingleOrNoneSyntax(node, name, args), nil
 }
 
+// MergeExpr combines a list of objects into one object. Where objects share a key, the value from
+// the last object wins.
+type MergeExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func MergeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *MergeExpr {
+	return &MergeExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseMerge(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return MergeSyntax(node, name, args), nil
+}
+
+// KeysExpr returns the keys of an object in sorted order.
+type KeysExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func KeysSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *KeysExpr {
+	return &KeysExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseKeys(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return KeysSyntax(node, name, args), nil
+}
+
+// ValuesExpr returns the values of an object, ordered by their keys.
+type ValuesExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func ValuesSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ValuesExpr {
+	return &ValuesExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseValues(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ValuesSyntax(node, name, args), nil
+}
+
+// FlattenExpr replaces any list nested in a list with its elements, recursively.
+type FlattenExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func FlattenSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FlattenExpr {
+	return &FlattenExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseFlatten(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FlattenSyntax(node, name, args), nil
+}
+
+// ConcatExpr joins a list of lists into a single list.
+type ConcatExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func ConcatSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ConcatExpr {
+	return &ConcatExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseConcat(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ConcatSyntax(node, name, args), nil
+}
+
+// DistinctExpr removes duplicate elements from a list, keeping the first occurrence of each.
+type DistinctExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func DistinctSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *DistinctExpr {
+	return &DistinctExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseDistinct(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return DistinctSyntax(node, name, args), nil
+}
+
+// ZipExpr combines a list of equal-length lists into a list of tuples, where the nth tuple holds the
+// nth element of each list.
+type ZipExpr struct {
+	builtinNode
+	Value Expr
+}
+
+func ZipSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ZipExpr {
+	return &ZipExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func parseZip(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ZipSyntax(node, name, args), nil
+}
+
+// ContainsExpr is true if Values contains an element equal to Value.
+type ContainsExpr struct {
+	builtinNode
+
+	Values Expr
+	Value  Expr
+}
+
+func ContainsSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *ContainsExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 2, "Must have exactly 2 elements")
+	return &ContainsExpr{
+		builtinNode: builtin(node, name, args),
+		Values:      elems[0],
+		Value:       elems[1],
+	}
+}
+
+func parseContains(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 2 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::contains must be a two-valued list", "")}
+	}
+
+	return ContainsSyntax(node, name, list), nil
+}
+
+// LookupExpr returns the value of Key in Map, or Default if Map has no such key or its value is null.
+type LookupExpr struct {
+	builtinNode
+
+	Map     Expr
+	Key     Expr
+	Default Expr
+}
+
+func LookupSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *LookupExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+	return &LookupExpr{
+		builtinNode: builtin(node, name, args),
+		Map:         elems[0],
+		Key:         elems[1],
+		Default:     elems[2],
+	}
+}
+
+func Lookup(m, key, def Expr) *LookupExpr {
+	return LookupSyntax(nil, String("fn::lookup"), List(m, key, def))
+}
+
+func parseLookup(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 3 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::lookup must be a three-valued list", "")}
+	}
+
+	return LookupSyntax(node, name, list), nil
+}
+
+// FindInMapExpr returns the value of SecondLevelKey within the value of TopLevelKey in the mapping named MapName.
+type FindInMapExpr struct {
+	builtinNode
+
+	MapName        *StringExpr
+	TopLevelKey    Expr
+	SecondLevelKey Expr
+}
+
+func FindInMapSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr, mapName *StringExpr) *FindInMapExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+	return &FindInMapExpr{
+		builtinNode:    builtin(node, name, args),
+		MapName:        mapName,
+		TopLevelKey:    elems[1],
+		SecondLevelKey: elems[2],
+	}
+}
+
+func FindInMap(mapName string, topLevelKey, secondLevelKey Expr) *FindInMapExpr {
+	mapNameX := String(mapName)
+	return FindInMapSyntax(nil, String("fn::findInMap"), List(mapNameX, topLevelKey, secondLevelKey), mapNameX)
+}
+
+func parseFindInMap(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 3 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::findInMap must be a three-valued list", "")}
+	}
+	mapName, ok := list.Elements[0].(*StringExpr)
+	if !ok {
+		return nil, syntax.Diagnostics{ExprError(list.Elements[0],
+			"the first argument to fn::findInMap must be the name of a mapping", "")}
+	}
+
+	return FindInMapSyntax(node, name, list, mapName), nil
+}
+
+// TryExpr evaluates to the first of its values that is not null. A value that is a property access,
+// such as `${config.settings.size}`, is null rather than an error if the property does not exist.
+type TryExpr struct {
+	builtinNode
+
+	Values []Expr
+}
+
+func TrySyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *TryExpr {
+	return &TryExpr{
+		builtinNode: builtin(node, name, args),
+		Values:      args.Elements,
+	}
+}
+
+func Try(values ...Expr) *TryExpr {
+	return TrySyntax(nil, String("fn::try"), List(values...))
+}
+
+func parseTry(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) < 2 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::try must be a list of at least two values", "")}
+	}
+
+	return TrySyntax(node, name, list), nil
+}
+
+// ForExpr evaluates Value for each element of In, within which `range.key` is the element's index
+// or key and `range.value` is the element. The result is a list of the values, or an object if Key
+// is set, in which case Key is evaluated for the key of each value. Elements for which If is false
+// are skipped.
+type ForExpr struct {
+	builtinNode
+
+	In    Expr
+	Key   Expr
+	Value Expr
+	If    Expr
+}
+
+func ForSyntax(node *syntax.ObjectNode, name *StringExpr, args *ObjectExpr, in, key, value, cond Expr) *ForExpr {
+	return &ForExpr{
+		builtinNode: builtin(node, name, args),
+		In:          in,
+		Key:         key,
+		Value:       value,
+		If:          cond,
+	}
+}
+
+func For(in, key, value, cond Expr) *ForExpr {
+	entries := []ObjectProperty{{Key: String("in"), Value: in}}
+	if key != nil {
+		entries = append(entries, ObjectProperty{Key: String("key"), Value: key})
+	}
+	entries = append(entries, ObjectProperty{Key: String("value"), Value: value})
+	if cond != nil {
+		entries = append(entries, ObjectProperty{Key: String("if"), Value: cond})
+	}
+	return ForSyntax(nil, String("fn::for"), Object(entries...), in, key, value, cond)
+}
+
+func parseFor(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::for must be an object containing 'in' and 'value'", "")}
+	}
+
+	var in, key, value, cond Expr
+	var diags syntax.Diagnostics
+	for _, kvp := range obj.Entries {
+		if str, ok := kvp.Key.(*StringExpr); ok {
+			switch strings.ToLower(str.Value) {
+			case "in":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "in", str.GetValue()))
+				in = kvp.Value
+			case "key":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "key", str.GetValue()))
+				key = kvp.Value
+			case "value":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "value", str.GetValue()))
+				value = kvp.Value
+			case "if":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "if", str.GetValue()))
+				cond = kvp.Value
+			default:
+				diags.Extend(ExprError(str, fmt.Sprintf("unknown property %q of fn::for; expected 'in', 'key', 'value' or 'if'", str.Value), ""))
+			}
+		}
+	}
+	if in == nil {
+		diags.Extend(ExprError(obj, "missing collection ('in')", ""))
+	}
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')", ""))
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return ForSyntax(node, name, obj, in, key, value, cond), diags
+}
+
 type PulumiResourceNameExpr struct {
 	builtinNode
 	Resource Expr
@@ -864,6 +1515,318 @@ func parsePulumiResourceType(node *syntax.ObjectNode, name *StringExpr, args Exp
 	return PulumiResourceTypeSyntax(node, name, args), nil
 }
 
+// IfExpr evaluates to Then if Condition is true, and to Else otherwise.
+type IfExpr struct {
+	builtinNode
+
+	Condition Expr
+	Then      Expr
+	Else      Expr
+}
+
+func IfSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr) *IfExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 3, "Must have exactly 3 elements")
+	return &IfExpr{
+		builtinNode: builtin(node, name, args),
+		Condition:   elems[0],
+		Then:        elems[1],
+		Else:        elems[2],
+	}
+}
+
+func If(condition, then, els Expr) *IfExpr {
+	name := String("fn::if")
+	return &IfExpr{
+		builtinNode: builtin(nil, name, List(condition, then, els)),
+		Condition:   condition,
+		Then:        then,
+		Else:        els,
+	}
+}
+
+func parseIf(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 3 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::if must be a three-valued list", "")}
+	}
+
+	return IfSyntax(node, name, list), nil
+}
+
+// NotExpr negates a boolean value.
+type NotExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func NotSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *NotExpr {
+	return &NotExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func Not(value Expr) *NotExpr {
+	return NotSyntax(nil, String("fn::not"), value)
+}
+
+func parseNot(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return NotSyntax(node, name, args), nil
+}
+
+// LogicalOperator is the operator applied by a LogicalExpr. Its value is the name of the builtin
+// without the `fn::` prefix.
+type LogicalOperator string
+
+const (
+	LogicalAnd LogicalOperator = "and"
+	LogicalOr  LogicalOperator = "or"
+)
+
+// LogicalExpr is true if all (fn::and) or any (fn::or) of its boolean values are true.
+type LogicalExpr struct {
+	builtinNode
+
+	Operator LogicalOperator
+	Values   []Expr
+}
+
+func LogicalSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr, op LogicalOperator) *LogicalExpr {
+	return &LogicalExpr{
+		builtinNode: builtin(node, name, args),
+		Operator:    op,
+		Values:      args.Elements,
+	}
+}
+
+func Logical(op LogicalOperator, values ...Expr) *LogicalExpr {
+	name := String("fn::" + string(op))
+	return LogicalSyntax(nil, name, List(values...), op)
+}
+
+func parseLogical(op LogicalOperator) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		list, ok := args.(*ListExpr)
+		if !ok || len(list.Elements) < 2 {
+			return nil, syntax.Diagnostics{ExprError(args,
+				fmt.Sprintf("the argument to fn::%s must be a list of at least two values", op), "")}
+		}
+
+		return LogicalSyntax(node, name, list, op), nil
+	}
+}
+
+// ComparisonOperator is the operator applied by a ComparisonExpr. Its value is the name of the
+// builtin without the `fn::` prefix.
+type ComparisonOperator string
+
+const (
+	Equals             ComparisonOperator = "equals"
+	NotEquals          ComparisonOperator = "notEquals"
+	LessThan           ComparisonOperator = "lessThan"
+	LessThanOrEqual    ComparisonOperator = "lessThanOrEqual"
+	GreaterThan        ComparisonOperator = "greaterThan"
+	GreaterThanOrEqual ComparisonOperator = "greaterThanOrEqual"
+)
+
+// ComparisonExpr compares two values. Any two values may be tested for equality, while ordering
+// comparisons require two numbers or two strings.
+type ComparisonExpr struct {
+	builtinNode
+
+	Operator ComparisonOperator
+	Left     Expr
+	Right    Expr
+}
+
+func ComparisonSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr, op ComparisonOperator) *ComparisonExpr {
+	elems := args.Elements
+	contract.Assertf(len(elems) == 2, "Must have exactly 2 elements")
+	return &ComparisonExpr{
+		builtinNode: builtin(node, name, args),
+		Operator:    op,
+		Left:        elems[0],
+		Right:       elems[1],
+	}
+}
+
+func Comparison(op ComparisonOperator, left, right Expr) *ComparisonExpr {
+	name := String("fn::" + string(op))
+	return ComparisonSyntax(nil, name, List(left, right), op)
+}
+
+func parseComparison(op ComparisonOperator) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		list, ok := args.(*ListExpr)
+		if !ok || len(list.Elements) != 2 {
+			return nil, syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to fn::%s must be a two-valued list", op), "")}
+		}
+
+		return ComparisonSyntax(node, name, list, op), nil
+	}
+}
+
+// ArithmeticOperator is the operator applied by an ArithmeticExpr. Its value is the name of the
+// builtin without the `fn::` prefix.
+type ArithmeticOperator string
+
+const (
+	Add      ArithmeticOperator = "add"
+	Subtract ArithmeticOperator = "sub"
+	Multiply ArithmeticOperator = "mul"
+	Divide   ArithmeticOperator = "div"
+	Modulo   ArithmeticOperator = "mod"
+	Minimum  ArithmeticOperator = "min"
+	Maximum  ArithmeticOperator = "max"
+)
+
+// ArithmeticExpr applies an arithmetic operator to a list of numbers. The operator is applied from
+// left to right, so `fn::sub: [10, 3, 2]` evaluates to 5.
+type ArithmeticExpr struct {
+	builtinNode
+
+	Operator ArithmeticOperator
+	Values   []Expr
+}
+
+func ArithmeticSyntax(node *syntax.ObjectNode, name *StringExpr, args *ListExpr, op ArithmeticOperator) *ArithmeticExpr {
+	return &ArithmeticExpr{
+		builtinNode: builtin(node, name, args),
+		Operator:    op,
+		Values:      args.Elements,
+	}
+}
+
+func Arithmetic(op ArithmeticOperator, values ...Expr) *ArithmeticExpr {
+	name := String("fn::" + string(op))
+	return ArithmeticSyntax(nil, name, List(values...), op)
+}
+
+func parseArithmetic(op ArithmeticOperator) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		list, ok := args.(*ListExpr)
+		if !ok || len(list.Elements) < 2 {
+			return nil, syntax.Diagnostics{ExprError(args,
+				fmt.Sprintf("the argument to fn::%s must be a list of at least two values", op), "")}
+		}
+
+		return ArithmeticSyntax(node, name, list, op), nil
+	}
+}
+
+// builtinParser parses a call to a builtin function. args is the value of the builtin's `fn::` key.
+type builtinParser func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics)
+
+// builtinFunction is an entry in the registry of builtin functions.
+type builtinFunction struct {
+	// name is the canonical spelling of the builtin's name, e.g. `fn::toJSON`.
+	name string
+	// parse parses a call to the builtin. It is nil for the asset and archive builtins, which are parsed by
+	// tryParseAssetOrArchive.
+	parse builtinParser
+}
+
+// builtins is the registry of builtin functions. It is the one list of builtins, shared by the parser and by the YAML
+// tag decoder.
+var builtins = []builtinFunction{
+	{"fn::invoke", parseInvoke},
+	{"fn::call", parseCall},
+	{"fn::join", parseJoin},
+	{"fn::toJSON", parseToJSON},
+	{"fn::fromJSON", parseFromJSON},
+	{"fn::fromYAML", parseFromYAML},
+	{"fn::toYAML", parseToYAML},
+	{"fn::toBase64", parseToBase64},
+	{"fn::fromBase64", parseFromBase64},
+	{"fn::select", parseSelect},
+	{"fn::split", parseSplit},
+	{"fn::replace", parseReplace},
+	{"fn::upper", parseUpper},
+	{"fn::lower", parseLower},
+	{"fn::trim", parseTrim},
+	{"fn::substring", parseSubstring},
+	{"fn::format", parseFormat},
+	{"fn::regexMatch", parseRegex(RegexMatch)},
+	{"fn::regexReplace", parseRegex(RegexReplace)},
+	{"fn::regexFind", parseRegex(RegexFind)},
+	{"fn::stackReference", parseStackReference},
+	{"fn::assetArchive", parseAssetArchive},
+	{"fn::stringAsset", nil},
+	{"fn::fileAsset", nil},
+	{"fn::remoteAsset", nil},
+	{"fn::fileArchive", nil},
+	{"fn::remoteArchive", nil},
+	{"fn::secret", parseSecret},
+	{"fn::unsecret", parseUnsecret},
+	{"fn::readFile", parseReadFile},
+	{"fn::filebase64", parseFileBase64},
+	{"fn::filebase64sha256", parseFileBase64Sha256},
+	{"fn::sha1", parseSha1},
+	{"fn::mimeType", parseMimeType},
+	{"fn::length", parseLength},
+	{"fn::singleOrNone", parseSingleOrNone},
+	{"fn::merge", parseMerge},
+	{"fn::keys", parseKeys},
+	{"fn::values", parseValues},
+	{"fn::flatten", parseFlatten},
+	{"fn::concat", parseConcat},
+	{"fn::distinct", parseDistinct},
+	{"fn::contains", parseContains},
+	{"fn::zip", parseZip},
+	{"fn::lookup", parseLookup},
+	{"fn::findInMap", parseFindInMap},
+	{"fn::try", parseTry},
+	{"fn::for", parseFor},
+	{"fn::pulumiResourceName", parsePulumiResourceName},
+	{"fn::pulumiResourceType", parsePulumiResourceType},
+	{"fn::if", parseIf},
+	{"fn::not", parseNot},
+	{"fn::and", parseLogical(LogicalAnd)},
+	{"fn::or", parseLogical(LogicalOr)},
+	{"fn::equals", parseComparison(Equals)},
+	{"fn::notEquals", parseComparison(NotEquals)},
+	{"fn::lessThan", parseComparison(LessThan)},
+	{"fn::lessThanOrEqual", parseComparison(LessThanOrEqual)},
+	{"fn::greaterThan", parseComparison(GreaterThan)},
+	{"fn::greaterThanOrEqual", parseComparison(GreaterThanOrEqual)},
+	{"fn::add", parseArithmetic(Add)},
+	{"fn::sub", parseArithmetic(Subtract)},
+	{"fn::mul", parseArithmetic(Multiply)},
+	{"fn::div", parseArithmetic(Divide)},
+	{"fn::mod", parseArithmetic(Modulo)},
+	{"fn::min", parseArithmetic(Minimum)},
+	{"fn::max", parseArithmetic(Maximum)},
+}
+
+// builtinsByName indexes builtins by their lowercased names, as builtin names are matched without regard to case. It
+// is built by init, as the parsers of builtins themselves parse builtins.
+var builtinsByName map[string]builtinFunction
+
+func init() {
+	builtinsByName = make(map[string]builtinFunction, len(builtins))
+	for _, b := range builtins {
+		builtinsByName[strings.ToLower(b.name)] = b
+	}
+}
+
+// BuiltinName returns the canonical spelling of the builtin named by key, e.g. `fn::toJSON` for `Fn::ToJson`.
+// Builtin names are matched without regard to case. It returns false if key does not name a builtin.
+func BuiltinName(key string) (string, bool) {
+	b, ok := builtinsByName[strings.ToLower(key)]
+	return b.name, ok
+}
+
+// BuiltinNames returns the canonical names of the builtin functions, e.g. `fn::join`.
+func BuiltinNames() []string {
+	names := make([]string, len(builtins))
+	for i, b := range builtins {
+		names[i] = b.name
+	}
+	return names
+}
+
 func tryParseFunction(node *syntax.ObjectNode) (Expr, syntax.Diagnostics, bool) {
 	if node.Len() != 1 {
 		return nil, nil, false
@@ -876,54 +1839,20 @@ func tryParseFunction(node *syntax.ObjectNode) (Expr, syntax.Diagnostics, bool)
 		return nil, nil, false
 	}
 
-	var parse func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics)
+	var parse builtinParser
 	var diags syntax.Diagnostics
-	set := func(expected string, parseFn func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics)) {
+	set := func(expected string, parseFn builtinParser) {
 		diags.Extend(syntax.UnexpectedCasing(kvp.Key.Syntax().Range(), expected, kvp.Key.Value()))
 		parse = parseFn
 	}
-	switch strings.ToLower(kvp.Key.Value()) {
-	case "fn::invoke":
-		set("fn::invoke", parseInvoke)
-	case "fn::join":
-		set("fn::join", parseJoin)
-	case "fn::tojson":
-		set("fn::toJSON", parseToJSON)
-	case "fn::tobase64":
-		set("fn::toBase64", parseToBase64)
-	case "fn::frombase64":
-		set("fn::fromBase64", parseFromBase64)
-	case "fn::select":
-		set("fn::select", parseSelect)
-	case "fn::split":
-		set("fn::split", parseSplit)
-	case "fn::stackreference":
-		set("fn::stackReference", parseStackReference)
-		diags = append(diags, syntax.Warning(kvp.Key.Syntax().Range(),
-			`'fn::stackReference' is deprecated; please use 'pulumi:pulumi:StackReference' instead`,
-			`see "https://www.pulumi.com/docs/intro/concepts/stack/#stackreferences for more info.`))
-	case "fn::assetarchive":
-		set("fn::assetArchive", parseAssetArchive)
-	case "fn::secret":
-		set("fn::secret", parseSecret)
-	case "fn::unsecret":
-		set("fn::unsecret", parseUnsecret)
-	case "fn::readfile":
-		set("fn::readFile", parseReadFile)
-	case "fn::filebase64":
-		set("fn::filebase64", parseFileBase64)
-	case "fn::filebase64sha256":
-		set("fn::filebase64sha256", parseFileBase64Sha256)
-	case "fn::sha1":
-		set("fn::sha1", parseSha1)
-	case "fn::length":
-		set("fn::length", parseLength)
-	case "fn::singleornone":
-		set("fn::singleOrNone", parseSingleOrNone)
-	case "fn::pulumiresourcename":
-		set("fn::pulumiResourceName", parsePulumiResourceName)
-	case "fn::pulumiresourcetype":
-		set("fn::pulumiResourceType", parsePulumiResourceType)
+	switch b, ok := builtinsByName[strings.ToLower(kvp.Key.Value())]; {
+	case ok && b.parse != nil:
+		set(b.name, b.parse)
+		if b.name == "fn::stackReference" {
+			diags = append(diags, syntax.Warning(kvp.Key.Syntax().Range(),
+				`'fn::stackReference' is deprecated; please use 'pulumi:pulumi:StackReference' instead`,
+				`see "https://www.pulumi.com/docs/intro/concepts/stack/#stackreferences for more info.`))
+		}
 	default:
 		k := kvp.Key.Value()
 		// fn::invoke can be called as fn::${pkg}:${module}(:${name})?
@@ -952,11 +1881,13 @@ func tryParseFunction(node *syntax.ObjectNode) (Expr, syntax.Diagnostics, bool)
 			}
 			parse = parseInvoke
 			break
+		} else if fnCallRegex.MatchString(k) {
+			// Any other fn::${name} calls the function declared with that name. Whether the template declares
+			// it is only known once the template is complete; see CallExpr.ReservedObject.
+			parse = parseCallShorthand(k[4:])
+			break
 		} else if strings.HasPrefix(strings.ToLower(k), "fn::") {
-			diags = append(diags, syntax.Warning(kvp.Key.Syntax().Range(),
-				"'fn::' is a reserved prefix",
-				fmt.Sprintf("If you need to use the raw key '%s',"+
-					" please open an issue at https://github.com/pulumi/pulumi-yaml/issues", k)))
+			diags = append(diags, reservedPrefixWarning(StringSyntax(kvp.Key)))
 		}
 		return nil, diags, false
 	}
@@ -1039,6 +1970,63 @@ func parseInvoke(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, sy
 	return InvokeSyntax(node, name, obj, function, arguments, opts, ret), diags
 }
 
+func parseCall(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::call must be an object containing 'function' and 'args'", "")}
+	}
+
+	var functionExpr, argsExpr Expr
+	var diags syntax.Diagnostics
+	for _, kvp := range obj.Entries {
+		if str, ok := kvp.Key.(*StringExpr); ok {
+			switch strings.ToLower(str.Value) {
+			case "function":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "function", str.GetValue()))
+				functionExpr = kvp.Value
+			case "args":
+				diags.Extend(syntax.UnexpectedCasing(str.syntax.Syntax().Range(), "args", str.GetValue()))
+				argsExpr = kvp.Value
+			default:
+				diags.Extend(ExprError(str, fmt.Sprintf("unknown property %q of fn::call; expected 'function' or 'args'", str.Value), ""))
+			}
+		}
+	}
+
+	function, ok := functionExpr.(*StringExpr)
+	if !ok {
+		if functionExpr == nil {
+			diags.Extend(ExprError(obj, "missing function name ('function')", ""))
+		} else {
+			diags.Extend(ExprError(functionExpr, "function name must be a string literal", ""))
+		}
+	}
+
+	callArgs, ok := argsExpr.(*ObjectExpr)
+	if !ok && argsExpr != nil {
+		diags.Extend(ExprError(argsExpr, "function arguments ('args') must be an object", ""))
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return CallSyntax(node, name, obj, function, callArgs), diags
+}
+
+// parseCallShorthand returns the parser for fn::${function}, which calls the named function with
+// the object given as its arguments. Its arguments are checked when it is known to call a declared
+// function.
+func parseCallShorthand(function string) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		callArgs, _ := args.(*ObjectExpr)
+		fn := StringSyntax(syntax.StringSyntax(name.Syntax().Syntax(), function))
+		call := CallSyntax(node, name, args, fn, callArgs)
+		call.shorthand = true
+		return call, nil
+	}
+}
+
 func parseJoin(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
 	list, ok := args.(*ListExpr)
 	if !ok || len(list.Elements) != 2 {
@@ -1052,6 +2040,18 @@ func parseToJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, sy
 	return ToJSONSyntax(node, name, args), nil
 }
 
+func parseFromJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromJSONSyntax(node, name, args), nil
+}
+
+func parseFromYAML(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromYAMLSyntax(node, name, args), nil
+}
+
+func parseToYAML(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToYAMLSyntax(node, name, args), nil
+}
+
 func parseSelect(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
 	list, ok := args.(*ListExpr)
 	if !ok || len(list.Elements) != 2 {
@@ -1072,6 +2072,68 @@ func parseSplit(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syn
 	return SplitSyntax(node, name, list), nil
 }
 
+func parseReplace(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 3 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::replace must be a three-valued list", "")}
+	}
+
+	return ReplaceSyntax(node, name, list), nil
+}
+
+func parseUpper(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return UpperSyntax(node, name, args), nil
+}
+
+func parseLower(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return LowerSyntax(node, name, args), nil
+}
+
+func parseTrim(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return TrimSyntax(node, name, args), nil
+}
+
+func parseSubstring(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) != 3 {
+		return nil, syntax.Diagnostics{ExprError(args, "the argument to fn::substring must be a three-valued list", "")}
+	}
+
+	return SubstringSyntax(node, name, list), nil
+}
+
+func parseFormat(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ListExpr)
+	if !ok || len(list.Elements) == 0 {
+		return nil, syntax.Diagnostics{ExprError(args,
+			"the argument to fn::format must be a list whose first value is the format string", "")}
+	}
+
+	return FormatSyntax(node, name, list), nil
+}
+
+func parseRegex(op RegexOperator) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		arity, desc := 2, "two-valued"
+		if op == RegexReplace {
+			arity, desc = 3, "three-valued"
+		}
+		list, ok := args.(*ListExpr)
+		if !ok || len(list.Elements) != arity {
+			return nil, syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to fn::%s must be a %s list", op, desc), "")}
+		}
+
+		// A literal pattern is checked now rather than when the program is evaluated.
+		if pattern, ok := list.Elements[0].(*StringExpr); ok {
+			if _, err := regexp.Compile(pattern.Value); err != nil {
+				return nil, syntax.Diagnostics{ExprError(pattern, fmt.Sprintf("invalid regular expression: %v", err), "")}
+			}
+		}
+
+		return RegexSyntax(node, name, list, op), nil
+	}
+}
+
 func parseToBase64(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
 	return ToBase64Syntax(node, name, args), nil
 }
@@ -0,0 +1,255 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/packages"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// SelfInput is the name of the input through which a method reads the outputs of the component instance that it is
+// called on, e.g. `${self.kubeconfig}`.
+const SelfInput = "self"
+
+type MethodsMapEntry struct {
+	syntax syntax.ObjectPropertyDef
+	Key    *StringExpr
+	Value  *MethodDecl
+}
+
+type MethodsMapDecl struct {
+	declNode
+
+	Entries []MethodsMapEntry
+}
+
+func (d *MethodsMapDecl) defaultValue() interface{} {
+	return &MethodsMapDecl{}
+}
+
+func (d *MethodsMapDecl) parse(name string, node syntax.Node) syntax.Diagnostics {
+	obj, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		return syntax.Diagnostics{syntax.NodeError(node, fmt.Sprintf("%v must be an object", name), "")}
+	}
+
+	var diags syntax.Diagnostics
+
+	entries := make([]MethodsMapEntry, obj.Len())
+	for i := range entries {
+		kvp := obj.Index(i)
+
+		var v *MethodDecl
+		vname := fmt.Sprintf("%s.%s", name, kvp.Key.Value())
+		vdiags := parseField(vname, reflect.ValueOf(&v).Elem(), kvp.Value)
+		diags.Extend(vdiags...)
+		if v != nil {
+			v.Name = StringSyntax(kvp.Key)
+			diags.Extend(checkOutputTypes(vname, v.OutputTypes, v.Outputs)...)
+			for _, input := range v.Inputs.Entries {
+				if input.Key.Value == SelfInput {
+					diags.Extend(ExprError(input.Key, fmt.Sprintf("%s.inputs.%s is reserved for the component instance",
+						vname, SelfInput), ""))
+				}
+			}
+		}
+
+		entries[i] = MethodsMapEntry{
+			syntax: kvp,
+			Key:    StringSyntax(kvp.Key),
+			Value:  v,
+		}
+	}
+	d.Entries = entries
+
+	return diags
+}
+
+// Get returns the method with the given name, if it is declared.
+func (d MethodsMapDecl) Get(name string) (*MethodDecl, bool) {
+	for _, entry := range d.Entries {
+		if entry.Key.Value == name && entry.Value != nil {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// A MethodDecl represents a method of a component, which is called on an instance of the component. Its body is
+// evaluated like a component's, with its inputs, and the outputs of the instance as `self`. A method declares no
+// resources.
+type MethodDecl struct {
+	declNode
+
+	Name        *StringExpr
+	Description *StringExpr
+	Inputs      ConfigMapDecl
+	Variables   VariablesMapDecl
+	Outputs     PropertyMapDecl
+	OutputTypes ConfigMapDecl
+
+	// component is the component that declares the method.
+	component *ComponentParamDecl
+}
+
+func (d *MethodDecl) recordSyntax() *syntax.Node {
+	return &d.syntax
+}
+
+// Component returns the component that declares the method.
+func (d *MethodDecl) Component() *ComponentParamDecl {
+	if d == nil {
+		return nil
+	}
+	return d.component
+}
+
+func (d *MethodDecl) GetName() *StringExpr {
+	if d == nil {
+		return nil
+	}
+	return d.Name
+}
+
+func (d *MethodDecl) GetDescription() *StringExpr {
+	if d == nil {
+		return nil
+	}
+	return d.Description
+}
+
+// GetPulumi returns the settings of the component that declares the method.
+func (d *MethodDecl) GetPulumi() PulumiDecl {
+	if d == nil {
+		return PulumiDecl{}
+	}
+	return d.component.GetPulumi()
+}
+
+// GetConfig returns the inputs of the method, preceded by SelfInput.
+func (d *MethodDecl) GetConfig() ConfigMapDecl {
+	if d == nil {
+		return ConfigMapDecl{}
+	}
+	self := ConfigMapEntry{Key: String(SelfInput), Value: &ConfigParamDecl{Type: String("object")}}
+	return ConfigMapDecl{Entries: append([]ConfigMapEntry{self}, d.Inputs.Entries...)}
+}
+
+func (d *MethodDecl) GetVariables() VariablesMapDecl {
+	if d == nil {
+		return VariablesMapDecl{}
+	}
+	return d.Variables
+}
+
+// GetFunctions returns the functions of the template that declares the method's component.
+func (d *MethodDecl) GetFunctions() FunctionsMapDecl {
+	if d == nil {
+		return FunctionsMapDecl{}
+	}
+	return d.component.GetFunctions()
+}
+
+// GetMappings returns the mappings of the template that declares the method's component.
+func (d *MethodDecl) GetMappings() MappingsMapDecl {
+	if d == nil {
+		return MappingsMapDecl{}
+	}
+	return d.component.GetMappings()
+}
+
+// GetConditions returns no conditions, as a component has none.
+func (d *MethodDecl) GetConditions() VariablesMapDecl {
+	return VariablesMapDecl{}
+}
+
+// GetResources returns no resources: a method reads the state of its component, and does not change it.
+func (d *MethodDecl) GetResources() ResourcesMapDecl {
+	return ResourcesMapDecl{}
+}
+
+func (d *MethodDecl) GetOutputs() PropertyMapDecl {
+	if d == nil {
+		return PropertyMapDecl{}
+	}
+	return d.Outputs
+}
+
+// OutputType returns the type that the `outputTypes` section declares for the named output, or nil if it declares
+// none.
+func (d *MethodDecl) OutputType(name string) *ConfigParamDecl {
+	if d == nil {
+		return nil
+	}
+	return d.OutputTypes.Get(name)
+}
+
+func (d *MethodDecl) GetSdks() []packages.PackageDecl {
+	if d == nil {
+		return nil
+	}
+	return d.component.GetSdks()
+}
+
+func (d *MethodDecl) NewDiagnosticWriter(w io.Writer, width uint, color bool) hcl.DiagnosticWriter {
+	return d.component.NewDiagnosticWriter(w, width, color)
+}
+
+// functionSpec returns the schema of the function that implements the method. The function takes the instance of the
+// component that it is called on, whose type token is componentType, as its `__self__` argument. name is the prefix of
+// the names of the types that the method's inputs and outputs declare.
+func (d *MethodDecl) functionSpec(types *typeSpecs, componentType, name string) (schema.FunctionSpec, error) {
+	inputs := &schema.ObjectTypeSpec{
+		Type: "object",
+		Properties: map[string]schema.PropertySpec{
+			"__self__": {TypeSpec: schema.TypeSpec{Ref: "#/resources/" + componentType}},
+		},
+		Required: []string{"__self__"},
+	}
+	for _, input := range d.Inputs.Entries {
+		k, v := input.Key.Value, input.Value
+		typeSpec, err := types.typeSpec(v, name+TypeName(k))
+		if err != nil {
+			return schema.FunctionSpec{}, fmt.Errorf("input %s: %w", k, err)
+		}
+		def := schemaDefaultValue(v.Default)
+		inputs.Properties[k] = schema.PropertySpec{
+			TypeSpec: typeSpec,
+			Default:  def,
+			Secret:   v.Secret != nil && v.Secret.Value,
+		}
+		if def == nil {
+			inputs.Required = append(inputs.Required, k)
+		}
+	}
+
+	outputs := &schema.ObjectTypeSpec{
+		Type:       "object",
+		Properties: map[string]schema.PropertySpec{},
+	}
+	for _, output := range d.Outputs.Entries {
+		k := output.Key.Value
+		typeSpec := anyTypeSpec
+		if decl := d.OutputType(k); decl != nil {
+			var err error
+			if typeSpec, err = types.typeSpec(decl, name+TypeName(k)); err != nil {
+				return schema.FunctionSpec{}, fmt.Errorf("output %s: %w", k, err)
+			}
+		}
+		outputs.Properties[k] = schema.PropertySpec{TypeSpec: typeSpec}
+		outputs.Required = append(outputs.Required, k)
+	}
+
+	spec := schema.FunctionSpec{Inputs: inputs, Outputs: outputs}
+	if d.Description != nil {
+		spec.Description = d.Description.Value
+	}
+	return spec, nil
+}
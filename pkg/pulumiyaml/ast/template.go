@@ -5,6 +5,7 @@ package ast
 import (
 	"fmt"
 	"io"
+	"path"
 	"reflect"
 	"strings"
 	"unicode"
@@ -95,6 +96,84 @@ func (d *StringListDecl) parse(name string, node syntax.Node) syntax.Diagnostics
 	return diags
 }
 
+// ImportDecl includes the variables, resources and outputs of another template file. Their names
+// are prefixed by the import's namespace, so `vpc` in an import named `net` is referred to as
+// `${net.vpc}`.
+type ImportDecl struct {
+	declNode
+
+	Path *StringExpr
+	// Namespace defaults to the name of the imported file without its extension.
+	Namespace *StringExpr
+}
+
+func (d *ImportDecl) recordSyntax() *syntax.Node {
+	return &d.syntax
+}
+
+// GetNamespace returns the namespace under which the import's entities are declared.
+func (d *ImportDecl) GetNamespace() string {
+	if d.Namespace != nil {
+		return d.Namespace.Value
+	}
+	if d.Path == nil {
+		return ""
+	}
+	base := path.Base(d.Path.Value)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+func ImportSyntax(node syntax.Node, importPath, namespace *StringExpr) *ImportDecl {
+	return &ImportDecl{
+		declNode:  decl(node),
+		Path:      importPath,
+		Namespace: namespace,
+	}
+}
+
+func Import(importPath, namespace *StringExpr) *ImportDecl {
+	return ImportSyntax(nil, importPath, namespace)
+}
+
+type ImportListDecl struct {
+	declNode
+
+	Entries []*ImportDecl
+}
+
+func (d *ImportListDecl) defaultValue() interface{} {
+	return &ImportListDecl{}
+}
+
+func (d *ImportListDecl) parse(name string, node syntax.Node) syntax.Diagnostics {
+	list, ok := node.(*syntax.ListNode)
+	if !ok {
+		return syntax.Diagnostics{syntax.NodeError(node, fmt.Sprintf("%v must be a list", name), "")}
+	}
+
+	var diags syntax.Diagnostics
+
+	entries := make([]*ImportDecl, list.Len())
+	for i := range entries {
+		elem := list.Index(i)
+		// An import may be written as just its path.
+		if str, ok := elem.(*syntax.StringNode); ok {
+			entries[i] = ImportSyntax(str, StringSyntax(str), nil)
+			continue
+		}
+
+		ename := fmt.Sprintf("%s[%d]", name, i)
+		ediags := parseField(ename, reflect.ValueOf(&entries[i]).Elem(), elem)
+		diags.Extend(ediags...)
+		if entries[i] != nil && entries[i].Path == nil {
+			diags.Extend(syntax.NodeError(elem, fmt.Sprintf("%v is missing a path", ename), ""))
+		}
+	}
+	d.Entries = entries
+
+	return diags
+}
+
 type ConfigMapEntry struct {
 	syntax syntax.ObjectPropertyDef
 	Key    *StringExpr
@@ -150,6 +229,16 @@ func (d *ConfigMapDecl) parse(name string, node syntax.Node) syntax.Diagnostics
 	return diags
 }
 
+// Get returns the parameter with the given name, or nil if it is not declared.
+func (d ConfigMapDecl) Get(name string) *ConfigParamDecl {
+	for _, entry := range d.Entries {
+		if entry.Key.Value == name {
+			return entry.Value
+		}
+	}
+	return nil
+}
+
 type VariablesMapEntry struct {
 	syntax syntax.ObjectPropertyDef
 	Key    *StringExpr
@@ -236,6 +325,155 @@ func (d *ResourcesMapDecl) parse(name string, node syntax.Node) syntax.Diagnosti
 	return diags
 }
 
+type FunctionsMapEntry struct {
+	syntax syntax.ObjectPropertyDef
+	Key    *StringExpr
+	Value  *FunctionDecl
+}
+
+type FunctionsMapDecl struct {
+	declNode
+
+	Entries []FunctionsMapEntry
+}
+
+func (d *FunctionsMapDecl) defaultValue() interface{} {
+	return &FunctionsMapDecl{}
+}
+
+func (d *FunctionsMapDecl) parse(name string, node syntax.Node) syntax.Diagnostics {
+	obj, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		return syntax.Diagnostics{syntax.NodeError(node, fmt.Sprintf("%v must be an object", name), "")}
+	}
+
+	var diags syntax.Diagnostics
+
+	entries := make([]FunctionsMapEntry, obj.Len())
+	for i := range entries {
+		kvp := obj.Index(i)
+
+		var v *FunctionDecl
+		vname := fmt.Sprintf("%s.%s", name, kvp.Key.Value())
+		vdiags := parseField(vname, reflect.ValueOf(&v).Elem(), kvp.Value)
+		diags.Extend(vdiags...)
+
+		entries[i] = FunctionsMapEntry{
+			syntax: kvp,
+			Key:    StringSyntax(kvp.Key),
+			Value:  v,
+		}
+	}
+	d.Entries = entries
+
+	return diags
+}
+
+// Get returns the function with the given name, if it is declared.
+func (d FunctionsMapDecl) Get(name string) (*FunctionDecl, bool) {
+	for _, entry := range d.Entries {
+		if entry.Key.Value == name && entry.Value != nil {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+type MappingsMapEntry struct {
+	syntax syntax.ObjectPropertyDef
+	Key    *StringExpr
+	Value  *ObjectExpr
+}
+
+// MappingsMapDecl is the mappings section of a template: named, static maps of maps that are read with
+// fn::findInMap.
+type MappingsMapDecl struct {
+	declNode
+
+	Entries []MappingsMapEntry
+}
+
+func (d *MappingsMapDecl) defaultValue() interface{} {
+	return &MappingsMapDecl{}
+}
+
+func (d *MappingsMapDecl) parse(name string, node syntax.Node) syntax.Diagnostics {
+	obj, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		return syntax.Diagnostics{syntax.NodeError(node, fmt.Sprintf("%v must be an object", name), "")}
+	}
+
+	var diags syntax.Diagnostics
+
+	entries := make([]MappingsMapEntry, 0, obj.Len())
+	for i := 0; i < obj.Len(); i++ {
+		kvp := obj.Index(i)
+
+		v, vdiags := ParseExpr(kvp.Value)
+		diags.Extend(vdiags...)
+		if vdiags.HasErrors() {
+			continue
+		}
+
+		m, ok := v.(*ObjectExpr)
+		if !ok {
+			diags.Extend(syntax.NodeError(kvp.Value, fmt.Sprintf("%s.%s must be an object", name, kvp.Key.Value()), ""))
+			continue
+		}
+		if x := firstNonLiteral(m); x != nil {
+			diags.Extend(ExprError(x, "mappings may only contain literal values",
+				"Mappings are static; use a variable for values that are computed."))
+			continue
+		}
+
+		entries = append(entries, MappingsMapEntry{
+			syntax: kvp,
+			Key:    StringSyntax(kvp.Key),
+			Value:  m,
+		})
+	}
+	d.Entries = entries
+
+	return diags
+}
+
+// Get returns the mapping with the given name, if it is declared.
+func (d MappingsMapDecl) Get(name string) (*ObjectExpr, bool) {
+	for _, entry := range d.Entries {
+		if entry.Key.Value == name {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// firstNonLiteral returns the first expression within x that is not a literal, or nil if x is entirely literal.
+func firstNonLiteral(x Expr) Expr {
+	switch x := x.(type) {
+	case *NullExpr, *BooleanExpr, *NumberExpr, *StringExpr:
+		return nil
+	case *ListExpr:
+		for _, e := range x.Elements {
+			if nl := firstNonLiteral(e); nl != nil {
+				return nl
+			}
+		}
+		return nil
+	case *ObjectExpr:
+		for _, kvp := range x.Entries {
+			if nl := firstNonLiteral(kvp.Key); nl != nil {
+				return nl
+			}
+			if nl := firstNonLiteral(kvp.Value); nl != nil {
+				return nl
+			}
+		}
+		return nil
+	default:
+		return x
+	}
+}
+
 type PropertyMapEntry struct {
 	syntax syntax.ObjectPropertyDef
 	Key    *StringExpr
@@ -343,6 +581,15 @@ type ConfigParamDecl struct {
 	Default Expr
 	Value   Expr
 	Items   *ConfigParamDecl
+
+	// Properties and Required declare the properties of an `object`, and AdditionalProperties the values of a `map`.
+	Properties           ConfigMapDecl
+	Required             *StringListDecl
+	AdditionalProperties *ConfigParamDecl
+	// Enum lists the values that the parameter is allowed to take.
+	Enum *ListExpr
+	// Ref refers to a type declared in the `types` section of the template, e.g. `#/types/Network`.
+	Ref *StringExpr `yaml:"$ref"`
 }
 
 func (d *ConfigParamDecl) recordSyntax() *syntax.Node {
@@ -500,6 +747,14 @@ type ResourceDecl struct {
 	Properties      PropertyMapOrExprDecl
 	Options         ResourceOptionsDecl
 	Get             GetResourceDecl
+	// Condition is an optional boolean expression. If it evaluates to false, the resource is not registered.
+	Condition Expr
+	// Count is an optional number of instances of the resource to register. Within the resource,
+	// `range.key` and `range.value` are both the index of the instance being registered.
+	Count Expr
+	// ForEach is an optional list or map with one instance of the resource registered per element.
+	// Within the resource, `range.key` is the element's index or key and `range.value` is the element.
+	ForEach Expr
 }
 
 func (d *ResourceDecl) recordSyntax() *syntax.Node {
@@ -508,11 +763,11 @@ func (d *ResourceDecl) recordSyntax() *syntax.Node {
 
 // The names of exported fields.
 func (*ResourceDecl) Fields() []string {
-	return []string{"type", "name", "defaultprovider", "properties", "options", "get"}
+	return []string{"type", "name", "defaultprovider", "properties", "options", "get", "condition", "count", "foreach"}
 }
 
 func ResourceSyntax(node *syntax.ObjectNode, typ *StringExpr, name *StringExpr, defaultProvider *BooleanExpr,
-	properties PropertyMapOrExprDecl, options ResourceOptionsDecl, get GetResourceDecl,
+	properties PropertyMapOrExprDecl, options ResourceOptionsDecl, get GetResourceDecl, condition, count, forEach Expr,
 ) *ResourceDecl {
 	return &ResourceDecl{
 		declNode:        decl(node),
@@ -522,6 +777,9 @@ func ResourceSyntax(node *syntax.ObjectNode, typ *StringExpr, name *StringExpr,
 		Properties:      properties,
 		Options:         options,
 		Get:             get,
+		Condition:       condition,
+		Count:           count,
+		ForEach:         forEach,
 	}
 }
 
@@ -532,8 +790,40 @@ func Resource(
 	properties PropertyMapOrExprDecl,
 	options ResourceOptionsDecl,
 	get GetResourceDecl,
+	condition Expr,
+	count Expr,
+	forEach Expr,
 ) *ResourceDecl {
-	return ResourceSyntax(nil, typ, name, defaultProvider, properties, options, get)
+	return ResourceSyntax(nil, typ, name, defaultProvider, properties, options, get, condition, count, forEach)
+}
+
+// FunctionDecl declares a reusable expression. It is called with `fn::call` or `fn::<name>`, and its
+// parameters are in scope only within its body.
+type FunctionDecl struct {
+	declNode
+
+	Description *StringExpr
+	// Parameters declares the arguments of the function. Each parameter may have a type, which call
+	// sites are checked against, and a default, which makes it optional.
+	Parameters ConfigMapDecl
+	Body       Expr
+}
+
+func (d *FunctionDecl) recordSyntax() *syntax.Node {
+	return &d.syntax
+}
+
+func FunctionSyntax(node *syntax.ObjectNode, description *StringExpr, parameters ConfigMapDecl, body Expr) *FunctionDecl {
+	return &FunctionDecl{
+		declNode:    decl(node),
+		Description: description,
+		Parameters:  parameters,
+		Body:        body,
+	}
+}
+
+func Function(description *StringExpr, parameters ConfigMapDecl, body Expr) *FunctionDecl {
+	return FunctionSyntax(nil, description, parameters, body)
 }
 
 type CustomTimeoutsDecl struct {
@@ -569,6 +859,9 @@ type Template interface {
 	GetPulumi() PulumiDecl
 	GetConfig() ConfigMapDecl
 	GetVariables() VariablesMapDecl
+	GetFunctions() FunctionsMapDecl
+	GetMappings() MappingsMapDecl
+	GetConditions() VariablesMapDecl
 	GetResources() ResourcesMapDecl
 	GetOutputs() PropertyMapDecl
 	GetSdks() []packages.PackageDecl
@@ -593,6 +886,8 @@ type ComponentParamDecl struct {
 	Variables   VariablesMapDecl
 	Resources   ResourcesMapDecl
 	Outputs     PropertyMapDecl
+	OutputTypes ConfigMapDecl
+	Methods     MethodsMapDecl
 	Template    *TemplateDecl
 }
 
@@ -631,6 +926,28 @@ func (d *ComponentParamDecl) GetVariables() VariablesMapDecl {
 	return d.Variables
 }
 
+// GetFunctions returns the functions of the template that declares the component.
+func (d *ComponentParamDecl) GetFunctions() FunctionsMapDecl {
+	if d == nil || d.Template == nil {
+		return FunctionsMapDecl{}
+	}
+	return d.Template.Functions
+}
+
+// GetMappings returns the mappings of the template that declares the component.
+func (d *ComponentParamDecl) GetMappings() MappingsMapDecl {
+	if d == nil || d.Template == nil {
+		return MappingsMapDecl{}
+	}
+	return d.Template.Mappings
+}
+
+// GetConditions returns no conditions: the template's conditions may refer to its config, which is not in scope
+// within a component.
+func (d *ComponentParamDecl) GetConditions() VariablesMapDecl {
+	return VariablesMapDecl{}
+}
+
 func (d *ComponentParamDecl) GetResources() ResourcesMapDecl {
 	if d == nil {
 		return ResourcesMapDecl{}
@@ -645,6 +962,15 @@ func (d *ComponentParamDecl) GetOutputs() PropertyMapDecl {
 	return d.Outputs
 }
 
+// OutputType returns the type that the `outputTypes` section declares for the named output, or nil if it declares
+// none.
+func (d *ComponentParamDecl) OutputType(name string) *ConfigParamDecl {
+	if d == nil {
+		return nil
+	}
+	return d.OutputTypes.Get(name)
+}
+
 func (d *ComponentParamDecl) GetSdks() []packages.PackageDecl {
 	if d == nil {
 		return nil
@@ -688,8 +1014,12 @@ func (d *ComponentListDecl) parse(name string, node syntax.Node) syntax.Diagnost
 		if diags.HasErrors() {
 			return diags
 		}
+		diags.Extend(checkOutputTypes(logname, v.OutputTypes, v.Outputs)...)
 
 		v.Name = String(kvp.Key.Value())
+		for _, method := range v.Methods.Entries {
+			method.Value.component = v
+		}
 		entries[i] = ComponentDecl{
 			syntax: kvp,
 			Key:    StringSyntax(kvp.Key),
@@ -701,10 +1031,30 @@ func (d *ComponentListDecl) parse(name string, node syntax.Node) syntax.Diagnost
 	return diags
 }
 
+// checkOutputTypes reports the entries of the `outputTypes` section of a component or method that name no output.
+func checkOutputTypes(name string, outputTypes ConfigMapDecl, outputs PropertyMapDecl) syntax.Diagnostics {
+	var diags syntax.Diagnostics
+	for _, entry := range outputTypes.Entries {
+		k := entry.Key.Value
+		declared := false
+		for _, output := range outputs.Entries {
+			declared = declared || output.Key.Value == k
+		}
+		if !declared {
+			diags.Extend(ExprError(entry.Key, fmt.Sprintf("%s.outputTypes.%s does not name an output", name, k),
+				"Each entry of outputTypes declares the type of the output of the same name."))
+		}
+	}
+	return diags
+}
+
 // A TemplateDecl represents a Pulumi YAML template.
 type TemplateDecl struct {
 	source []byte
 
+	// sources holds the source text of any templates that have been merged into this one, keyed by filename.
+	sources map[string][]byte
+
 	syntax syntax.Node
 
 	Name          *StringExpr
@@ -712,13 +1062,19 @@ type TemplateDecl struct {
 	Description   *StringExpr
 	Version       *StringExpr
 	Pulumi        PulumiDecl
+	Imports       ImportListDecl
 	Configuration ConfigMapDecl
 	Config        ConfigMapDecl
 	Variables     VariablesMapDecl
+	Functions     FunctionsMapDecl
+	Mappings      MappingsMapDecl
+	Conditions    VariablesMapDecl
 	Resources     ResourcesMapDecl
 	Outputs       PropertyMapDecl
 	Sdks          []packages.PackageDecl
 	Components    ComponentListDecl
+	// Types declares the object and enum types that the inputs and outputs of components may refer to.
+	Types ConfigMapDecl
 }
 
 func (d *TemplateDecl) GetName() *StringExpr {
@@ -772,6 +1128,28 @@ func (d *TemplateDecl) GetVariables() VariablesMapDecl {
 	return d.Variables
 }
 
+func (d *TemplateDecl) GetFunctions() FunctionsMapDecl {
+	if d == nil {
+		return FunctionsMapDecl{}
+	}
+	return d.Functions
+}
+
+func (d *TemplateDecl) GetMappings() MappingsMapDecl {
+	if d == nil {
+		return MappingsMapDecl{}
+	}
+	return d.Mappings
+}
+
+// GetConditions returns the template's conditions: named boolean expressions that are evaluated like variables.
+func (d *TemplateDecl) GetConditions() VariablesMapDecl {
+	if d == nil {
+		return VariablesMapDecl{}
+	}
+	return d.Conditions
+}
+
 func (d *TemplateDecl) GetResources() ResourcesMapDecl {
 	if d == nil {
 		return ResourcesMapDecl{}
@@ -808,64 +1186,131 @@ func (d *TemplateDecl) recordSyntax() *syntax.Node {
 // template.
 func (d *TemplateDecl) NewDiagnosticWriter(w io.Writer, width uint, color bool) hcl.DiagnosticWriter {
 	fileMap := map[string]*hcl.File{}
+	for filename, source := range d.sourceFiles() {
+		fileMap[filename] = &hcl.File{Bytes: source}
+	}
+	return newDiagnosticWriter(w, fileMap, width, color)
+}
+
+// sourceFiles returns the source text of the template and of every template merged into it, keyed by filename.
+func (d *TemplateDecl) sourceFiles() map[string][]byte {
+	files := map[string][]byte{}
 	if d.source != nil {
 		if s := d.syntax; s != nil {
-			fileMap[s.Syntax().Range().Filename] = &hcl.File{Bytes: d.source}
+			files[s.Syntax().Range().Filename] = d.source
 		}
 	}
-	return newDiagnosticWriter(w, fileMap, width, color)
+	for filename, source := range d.sources {
+		files[filename] = source
+	}
+	return files
 }
 
+// Merge merges the contents of other into the template. Top-level sections are concatenated, with the entries of
+// other following those of d. If the same key or top-level field is declared by both templates, the returned error is
+// a syntax.Diagnostics that points at both declarations.
 func (d *TemplateDecl) Merge(other *TemplateDecl) error {
 	if other == nil {
 		return nil
 	}
+
+	var diags syntax.Diagnostics
+	// duplicate reports an error at other, the second declaration of the named field or key, that points at prev.
+	duplicate := func(summary, name string, prev, other syntax.Node) {
+		detail := ""
+		if prev != nil {
+			detail = fmt.Sprintf("%s was previously declared at %s", name, prev.Syntax().Range())
+		}
+		diags.Extend(syntax.NodeError(other, summary, detail))
+	}
+	// checkField reports an error if both templates declare the named top-level field.
+	checkField := func(name string, prev, other *StringExpr) {
+		if prev != nil && other != nil {
+			duplicate(fmt.Sprintf("found duplicate %s", name), name, prev.Syntax(), other.Syntax())
+		}
+	}
+	// checkUnique reports an error for each key of the given section that is declared by both templates.
+	checkUnique := func(kind string, keys, otherKeys []*StringExpr) {
+		declared := map[string]*StringExpr{}
+		for _, key := range keys {
+			declared[key.Value] = key
+		}
+		for _, key := range otherKeys {
+			if prev, ok := declared[key.Value]; ok {
+				name := fmt.Sprintf("%s %s", kind, key.Value)
+				duplicate("found duplicate "+name, name, prev.Syntax(), key.Syntax())
+				continue
+			}
+			declared[key.Value] = key
+		}
+	}
+
+	checkField("name", d.Name, other.Name)
+	checkField("description", d.Description, other.Description)
+	checkField("namespace", d.Namespace, other.Namespace)
+	checkField("version", d.Version, other.Version)
+	if d.Pulumi.HasSettings() && other.Pulumi.HasSettings() {
+		duplicate("found duplicate pulumi settings", "pulumi", d.Pulumi.Syntax(), other.Pulumi.Syntax())
+	}
+	checkUnique("config", entryKeys(d.GetConfig().Entries), entryKeys(other.GetConfig().Entries))
+	checkUnique("variable", entryKeys(d.Variables.Entries), entryKeys(other.Variables.Entries))
+	checkUnique("function", entryKeys(d.Functions.Entries), entryKeys(other.Functions.Entries))
+	checkUnique("mapping", entryKeys(d.Mappings.Entries), entryKeys(other.Mappings.Entries))
+	checkUnique("condition", entryKeys(d.Conditions.Entries), entryKeys(other.Conditions.Entries))
+	checkUnique("resource", entryKeys(d.Resources.Entries), entryKeys(other.Resources.Entries))
+	checkUnique("output", entryKeys(d.Outputs.Entries), entryKeys(other.Outputs.Entries))
+	checkUnique("component", entryKeys(d.Components.Entries), entryKeys(other.Components.Entries))
+	checkUnique("type", entryKeys(d.Types.Entries), entryKeys(other.Types.Entries))
+	if diags.HasErrors() {
+		return diags
+	}
+
 	if d.Name == nil {
 		d.Name = other.Name
-	} else if other.Name != nil {
-		return fmt.Errorf("cannot merge templates with different names")
 	}
 	if d.Description == nil {
 		d.Description = other.Description
-	} else if other.Description != nil {
-		return fmt.Errorf("cannot merge templates with different descriptions")
 	}
 	if d.Namespace == nil {
 		d.Namespace = other.Namespace
-	} else if other.Namespace != nil {
-		return fmt.Errorf("cannot merge templates with different namespaces")
 	}
+	if d.Version == nil {
+		d.Version = other.Version
+	}
+	if !d.Pulumi.HasSettings() {
+		d.Pulumi = other.Pulumi
+	}
+	d.Configuration.Entries = append(d.Configuration.Entries, other.Configuration.Entries...)
 	d.Config.Entries = append(d.Config.Entries, other.Config.Entries...)
+	d.Variables.Entries = append(d.Variables.Entries, other.Variables.Entries...)
+	d.Functions.Entries = append(d.Functions.Entries, other.Functions.Entries...)
+	d.Mappings.Entries = append(d.Mappings.Entries, other.Mappings.Entries...)
+	d.Conditions.Entries = append(d.Conditions.Entries, other.Conditions.Entries...)
+	d.Resources.Entries = append(d.Resources.Entries, other.Resources.Entries...)
+	d.Outputs.Entries = append(d.Outputs.Entries, other.Outputs.Entries...)
 	d.Components.Entries = append(d.Components.Entries, other.Components.Entries...)
+	d.Types.Entries = append(d.Types.Entries, other.Types.Entries...)
+	for i := range other.Components.Entries {
+		other.Components.Entries[i].Value.Template = d
+	}
+
+	for filename, source := range other.sourceFiles() {
+		if d.sources == nil {
+			d.sources = map[string][]byte{}
+		}
+		d.sources[filename] = source
+	}
 	return nil
 }
 
-func parseTypeSpec(configDecl *ConfigParamDecl) (schema.TypeSpec, error) {
-	typeSpec := schema.TypeSpec{}
-	if configDecl.Type == nil {
-		return typeSpec, fmt.Errorf("missing type")
-	}
-	switch configDecl.Type.Value {
-	case "string":
-		typeSpec.Type = "string"
-	case "integer":
-		typeSpec.Type = "integer"
-	case "boolean":
-		typeSpec.Type = "boolean"
-	case "array":
-		if configDecl.Items == nil {
-			return typeSpec, fmt.Errorf("missing items")
-		}
-		itemsTypeSpec, err := parseTypeSpec(configDecl.Items)
-		if err != nil {
-			return typeSpec, err
-		}
-		typeSpec.Type = "array"
-		typeSpec.Items = &itemsTypeSpec
-	default:
-		return typeSpec, fmt.Errorf("unknown type: %s", configDecl.Type.Value)
+// entryKeys returns the keys of the entries of a section of a template, such as a []ResourcesMapEntry.
+func entryKeys(entries interface{}) []*StringExpr {
+	v := reflect.ValueOf(entries)
+	keys := make([]*StringExpr, v.Len())
+	for i := range keys {
+		keys[i] = v.Index(i).FieldByName("Key").Interface().(*StringExpr)
 	}
-	return typeSpec, nil
+	return keys
 }
 
 func (d *TemplateDecl) GenerateSchema() (schema.PackageSpec, error) {
@@ -895,7 +1340,20 @@ func (d *TemplateDecl) GenerateSchema() (schema.PackageSpec, error) {
 		},
 	}
 
+	types := typeSpecs{
+		template:   d,
+		types:      map[string]schema.ComplexTypeSpec{},
+		refs:       map[string]string{},
+		components: d.ComponentTypeTokens(),
+	}
+	for _, entry := range d.Types.Entries {
+		if _, err := types.refType(typesRefPrefix + entry.Key.Value); err != nil {
+			return schema.PackageSpec{}, fmt.Errorf("type %s: %w", entry.Key.Value, err)
+		}
+	}
+
 	resourcesDef := make(map[string]schema.ResourceSpec)
+	functionsDef := make(map[string]schema.FunctionSpec)
 	for _, component := range d.Components.Entries {
 		componentType := d.Name.Value + ":index:" + component.Key.Value
 		resourceDef := schema.ResourceSpec{
@@ -913,9 +1371,9 @@ func (d *TemplateDecl) GenerateSchema() (schema.PackageSpec, error) {
 
 		for _, input := range component.Value.Inputs.Entries {
 			k, v := input.Key.Value, input.Value
-			typeSpec, err := parseTypeSpec(input.Value)
+			typeSpec, err := types.typeSpec(v, TypeName(component.Key.Value, k))
 			if err != nil {
-				return schema.PackageSpec{}, err
+				return schema.PackageSpec{}, fmt.Errorf("input %s of component %s: %w", k, component.Key.Value, err)
 			}
 			def := schemaDefaultValue(v.Default)
 
@@ -936,10 +1394,16 @@ func (d *TemplateDecl) GenerateSchema() (schema.PackageSpec, error) {
 		for _, output := range component.Value.Outputs.Entries {
 			k := output.Key.Value
 
-			// TODO: evaluate actual type. For the first cut we're just returning `Any` here.
+			// Outputs whose type is not declared are typed as `Any`. pulumiyaml.GenerateSchema infers their types.
 			typeSpec := schema.TypeSpec{
 				Ref: "pulumi.json#/Any",
 			}
+			if decl := component.Value.OutputType(k); decl != nil {
+				var err error
+				if typeSpec, err = types.typeSpec(decl, TypeName(component.Key.Value, k)); err != nil {
+					return schema.PackageSpec{}, fmt.Errorf("output %s of component %s: %w", k, component.Key.Value, err)
+				}
+			}
 
 			properties[k] = schema.PropertySpec{
 				TypeSpec: typeSpec,
@@ -948,10 +1412,30 @@ func (d *TemplateDecl) GenerateSchema() (schema.PackageSpec, error) {
 		}
 		resourceDef.Properties = properties
 
+		for _, method := range component.Value.Methods.Entries {
+			k := method.Key.Value
+			token := componentType + "/" + k
+			function, err := method.Value.functionSpec(&types, componentType, TypeName(component.Key.Value, k))
+			if err != nil {
+				return schema.PackageSpec{}, fmt.Errorf("method %s of component %s: %w", k, component.Key.Value, err)
+			}
+			if resourceDef.Methods == nil {
+				resourceDef.Methods = map[string]string{}
+			}
+			resourceDef.Methods[k] = token
+			functionsDef[token] = function
+		}
+
 		resourcesDef[componentType] = resourceDef
 	}
 
 	schemaDef.Resources = resourcesDef
+	if len(functionsDef) != 0 {
+		schemaDef.Functions = functionsDef
+	}
+	if len(types.types) != 0 {
+		schemaDef.Types = types.types
+	}
 
 	return schemaDef, nil
 }
@@ -991,6 +1475,8 @@ func ParseTemplate(source []byte, node syntax.Node) (*TemplateDecl, syntax.Diagn
 	template := TemplateDecl{source: source}
 
 	diags := parseRecord("template", &template, node, false)
+	diags.Extend(checkStackConfig("configuration", template.Configuration)...)
+	diags.Extend(checkStackConfig("config", template.Config)...)
 	// Ensure that all components have a reference back to the template they belong to.
 	for i := range template.Components.Entries {
 		template.Components.Entries[i].Value.Template = &template
@@ -998,6 +1484,53 @@ func ParseTemplate(source []byte, node syntax.Node) (*TemplateDecl, syntax.Diagn
 	return &template, diags
 }
 
+// checkStackConfig reports the keys of the parameters of a stack's configuration that are only supported on the inputs
+// of components and the declarations of the `types` section, such as `enum`. Configuration values are not validated
+// against them.
+func checkStackConfig(name string, config ConfigMapDecl) syntax.Diagnostics {
+	var diags syntax.Diagnostics
+	for _, entry := range config.Entries {
+		diags.Extend(checkStackConfigParam(fmt.Sprintf("%s.%s", name, entry.Key.Value), entry.Value)...)
+	}
+	return diags
+}
+
+func checkStackConfigParam(name string, decl *ConfigParamDecl) syntax.Diagnostics {
+	if decl == nil {
+		return nil
+	}
+
+	var keys []string
+	var nodes []syntax.Node
+	if len(decl.Properties.Entries) != 0 {
+		keys, nodes = append(keys, "properties"), append(nodes, decl.Properties.Syntax())
+	}
+	if decl.Required != nil {
+		keys, nodes = append(keys, "required"), append(nodes, decl.Required.Syntax())
+	}
+	if decl.AdditionalProperties != nil {
+		keys, nodes = append(keys, "additionalProperties"), append(nodes, decl.AdditionalProperties.Syntax())
+	}
+	if decl.Enum != nil {
+		keys, nodes = append(keys, "enum"), append(nodes, decl.Enum.Syntax())
+	}
+	if decl.Ref != nil {
+		keys, nodes = append(keys, "$ref"), append(nodes, decl.Ref.Syntax())
+	}
+
+	var diags syntax.Diagnostics
+	for i, k := range keys {
+		node := nodes[i]
+		if node == nil {
+			node = decl.Syntax()
+		}
+		diags.Extend(syntax.NodeError(node, fmt.Sprintf("%s.%s is only supported on the inputs of components", name, k),
+			"Stack configuration is not validated against it."))
+	}
+	diags.Extend(checkStackConfigParam(name+".items", decl.Items)...)
+	return diags
+}
+
 var (
 	parseDeclType  = reflect.TypeOf((*parseDecl)(nil)).Elem()
 	nonNilDeclType = reflect.TypeOf((*nonNilDecl)(nil)).Elem()
@@ -1082,9 +1615,9 @@ func parseRecord(objName string, dest recordDecl, node syntax.Node, noMatchWarni
 		key := kvp.Key.Value()
 		var hasMatch bool
 		for _, f := range reflect.VisibleFields(t) {
-			if f.IsExported() && strings.EqualFold(f.Name, key) {
-				diags.Extend(syntax.UnexpectedCasing(kvp.Key.Syntax().Range(), camel(f.Name), key))
-				diags.Extend(parseField(camel(f.Name), v.FieldByIndex(f.Index), kvp.Value)...)
+			if name := fieldName(f); f.IsExported() && strings.EqualFold(name, key) {
+				diags.Extend(syntax.UnexpectedCasing(kvp.Key.Syntax().Range(), name, key))
+				diags.Extend(parseField(name, v.FieldByIndex(f.Index), kvp.Value)...)
 				hasMatch = true
 				break
 			}
@@ -1095,7 +1628,7 @@ func parseRecord(objName string, dest recordDecl, node syntax.Node, noMatchWarni
 			for i := 0; i < t.NumField(); i++ {
 				f := t.Field(i)
 				if f.IsExported() {
-					fieldNames = append(fieldNames, fmt.Sprintf("'%s'", camel(f.Name)))
+					fieldNames = append(fieldNames, fmt.Sprintf("'%s'", fieldName(f)))
 				}
 			}
 			formatter := yamldiags.NonExistentFieldFormatter{
@@ -1140,6 +1673,15 @@ func exprFieldTypeMismatchError(name string, expected interface{}, actual Expr)
 	return ExprError(actual, fmt.Sprintf("%v must be %v", name, typeName), "")
 }
 
+// fieldName returns the key that a field of a record is parsed from: the field's `yaml` tag, if it has one, or else
+// its name in camel case.
+func fieldName(f reflect.StructField) string {
+	if name, ok := f.Tag.Lookup("yaml"); ok {
+		return name
+	}
+	return camel(f.Name)
+}
+
 func camel(s string) string {
 	if s == "" {
 		return ""
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
 )
 
@@ -285,6 +286,390 @@ func TestComponentSchemaGeneration(t *testing.T) {
 	require.JSONEq(t, expectedSchema, string(marshalled))
 }
 
+func TestComponentOutputTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+components:
+  aComponent:
+    outputs:
+      typed: [ "a", "b" ]
+      plain: abcd
+      record:
+        type: string
+        value: efgh
+    outputTypes:
+      typed:
+        type: array
+        items:
+          type: string
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	template, diags := ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 0)
+
+	component := template.Components.Entries[0].Value
+	require.Len(t, component.Outputs.Entries, 3)
+	assert.IsType(t, &ListExpr{}, component.Outputs.Entries[0].Value)
+	assert.Equal(t, "array", component.OutputType("typed").Type.Value)
+	assert.Nil(t, component.OutputType("plain"))
+	// An object output is output whole, even if its keys are those of a type declaration.
+	record, ok := component.Outputs.Entries[2].Value.(*ObjectExpr)
+	require.True(t, ok)
+	require.Len(t, record.Entries, 2)
+	assert.Equal(t, "type", record.Entries[0].Key.(*StringExpr).Value)
+	assert.Equal(t, "value", record.Entries[1].Key.(*StringExpr).Value)
+	assert.Nil(t, component.OutputType("record"))
+
+	spec, err := template.GenerateSchema()
+	require.NoError(t, err)
+	properties := spec.Resources["yaml-plugin:index:aComponent"].Properties
+	assert.Equal(t, "array", properties["typed"].Type)
+	assert.Equal(t, "string", properties["typed"].Items.Type)
+	assert.Equal(t, "pulumi.json#/Any", properties["plain"].Ref)
+	assert.Equal(t, "pulumi.json#/Any", properties["record"].Ref)
+}
+
+func TestComponentOutputTypesUnknownOutput(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+components:
+  aComponent:
+    outputs:
+      url: abcd
+    outputTypes:
+      uri:
+        type: string
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	_, diags = ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "components.aComponent.outputTypes.uri does not name an output", diags[0].Summary)
+}
+
+func TestComponentRichInputTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+types:
+  Subnet:
+    type: object
+    properties:
+      cidr:
+        type: string
+      public:
+        type: boolean
+    required: [ cidr ]
+components:
+  cluster:
+    inputs:
+      networkConfig:
+        type: object
+        properties:
+          subnets:
+            type: array
+            items:
+              $ref: "#/types/Subnet"
+          mtu:
+            type: number
+        required: [ subnets ]
+      tier:
+        enum: [ small, medium, large ]
+        default: small
+      replicas:
+        type: integer
+        enum: [ 1, 3, 5 ]
+      labels:
+        type: map
+        additionalProperties:
+          type: string
+    outputs:
+      subnet: ${networkConfig.subnets[0]}
+    outputTypes:
+      subnet:
+        $ref: "#/types/Subnet"
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	template, diags := ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 0)
+	require.Len(t, template.Types.Entries, 1)
+	inputs := template.Components.Entries[0].Value.Inputs.Entries
+	require.Len(t, inputs, 4)
+	assert.Equal(t, "#/types/Subnet", inputs[0].Value.Properties.Entries[0].Value.Items.Ref.Value)
+
+	spec, err := template.GenerateSchema()
+	require.NoError(t, err)
+
+	marshalled, err := json.Marshal(spec.Types)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "yaml-plugin:index:Subnet": {
+    "type": "object",
+    "properties": {
+      "cidr": { "type": "string" },
+      "public": { "type": "boolean" }
+    },
+    "required": [ "cidr" ]
+  },
+  "yaml-plugin:index:ClusterNetworkConfig": {
+    "type": "object",
+    "properties": {
+      "mtu": { "type": "number" },
+      "subnets": {
+        "type": "array",
+        "items": { "$ref": "#/types/yaml-plugin:index:Subnet" }
+      }
+    },
+    "required": [ "subnets" ]
+  },
+  "yaml-plugin:index:ClusterTier": {
+    "type": "string",
+    "enum": [ { "value": "small" }, { "value": "medium" }, { "value": "large" } ]
+  },
+  "yaml-plugin:index:ClusterReplicas": {
+    "type": "integer",
+    "enum": [ { "value": 1 }, { "value": 3 }, { "value": 5 } ]
+  }
+}`, string(marshalled))
+
+	resource := spec.Resources["yaml-plugin:index:cluster"]
+	assert.Equal(t, "#/types/yaml-plugin:index:ClusterNetworkConfig", resource.InputProperties["networkConfig"].Ref)
+	assert.Equal(t, "#/types/yaml-plugin:index:ClusterTier", resource.InputProperties["tier"].Ref)
+	assert.Equal(t, "object", resource.InputProperties["labels"].Type)
+	assert.Equal(t, "string", resource.InputProperties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, []string{"networkConfig", "replicas", "labels"}, resource.RequiredInputs)
+	assert.Equal(t, "#/types/yaml-plugin:index:Subnet", resource.Properties["subnet"].Ref)
+}
+
+func TestComponentInputTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "{ $ref: \"#/types/Missing\" }",
+			expected: `input a of component aComponent: unknown type reference "#/types/Missing"`,
+		},
+		{
+			input:    "{ enum: [ small, 1 ] }",
+			expected: "input a of component aComponent: enum values must all have the same type",
+		},
+		{
+			input:    "{ type: integer, enum: [ 1.5 ] }",
+			expected: "input a of component aComponent: enum of type integer cannot have number values",
+		},
+		{
+			input:    "{ type: object, properties: { b: { type: string } }, required: [ c ] }",
+			expected: "input a of component aComponent: required property c is not declared",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			text := "name: yaml-plugin\nruntime: yaml\ncomponents:\n  aComponent:\n    inputs:\n      a: " + tt.input + "\n"
+			syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+			require.Len(t, diags, 0)
+			template, diags := ParseTemplate([]byte(text), syntax)
+			require.Len(t, diags, 0)
+
+			_, err := template.GenerateSchema()
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestStackConfigRejectsComponentInputKeys(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+config:
+  tier:
+    type: string
+    enum: [ small, large ]
+  network:
+    $ref: "#/types/Network"
+  zones:
+    type: array
+    items:
+      type: object
+      properties:
+        name:
+          type: string
+      required: [ name ]
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	_, diags = ParseTemplate([]byte(text), syntax)
+	var summaries []string
+	for _, diag := range diags {
+		summaries = append(summaries, diag.Summary)
+		assert.NotNil(t, diag.Subject)
+	}
+	assert.Equal(t, []string{
+		"config.tier.enum is only supported on the inputs of components",
+		"config.network.$ref is only supported on the inputs of components",
+		"config.zones.items.properties is only supported on the inputs of components",
+		"config.zones.items.required is only supported on the inputs of components",
+	}, summaries)
+}
+
+func TestComponentTypeNameCollisions(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+types:
+  WebSite:
+    type: object
+    properties:
+      url:
+        type: string
+components:
+  WebSite:
+    inputs:
+      settings:
+        $ref: "#/types/WebSite"
+  Web:
+    inputs:
+      site:
+        type: object
+        properties:
+          name:
+            type: string
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+	template, diags := ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 0)
+
+	spec, err := template.GenerateSchema()
+	require.NoError(t, err)
+	assert.Contains(t, spec.Resources, "yaml-plugin:index:WebSite")
+	assert.NotContains(t, spec.Types, "yaml-plugin:index:WebSite")
+	assert.Equal(t, "#/types/yaml-plugin:index:WebSite2",
+		spec.Resources["yaml-plugin:index:WebSite"].InputProperties["settings"].Ref)
+	assert.Equal(t, "#/types/yaml-plugin:index:WebSite3",
+		spec.Resources["yaml-plugin:index:Web"].InputProperties["site"].Ref)
+}
+
+func TestComponentMethods(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+components:
+  cluster:
+    inputs:
+      name:
+        type: string
+    outputs:
+      kubeconfig: ${name}
+    methods:
+      getKubeconfig:
+        description: Returns a kubeconfig for the given profile.
+        inputs:
+          profile:
+            type: string
+          ttl:
+            type: integer
+            default: 3600
+        variables:
+          config: ${self.kubeconfig}-${profile}
+        outputs:
+          kubeconfig: ${config}
+          expires: ${ttl}
+        outputTypes:
+          kubeconfig:
+            type: string
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	template, diags := ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 0)
+	component := template.Components.Entries[0].Value
+	method, ok := component.Methods.Get("getKubeconfig")
+	require.True(t, ok)
+	assert.Same(t, component, method.Component())
+	assert.Equal(t, "getKubeconfig", method.GetName().Value)
+	config := method.GetConfig().Entries
+	require.Len(t, config, 3)
+	assert.Equal(t, SelfInput, config[0].Key.Value)
+	assert.Equal(t, "string", method.OutputType("kubeconfig").Type.Value)
+	assert.Nil(t, method.OutputType("expires"))
+
+	spec, err := template.GenerateSchema()
+	require.NoError(t, err)
+
+	token := "yaml-plugin:index:cluster/getKubeconfig"
+	assert.Equal(t, map[string]string{"getKubeconfig": token}, spec.Resources["yaml-plugin:index:cluster"].Methods)
+	marshalled, err := json.Marshal(spec.Functions[token])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "description": "Returns a kubeconfig for the given profile.",
+  "inputs": {
+    "type": "object",
+    "properties": {
+      "__self__": { "$ref": "#/resources/yaml-plugin:index:cluster" },
+      "profile": { "type": "string" },
+      "ttl": { "type": "integer", "default": 3600 }
+    },
+    "required": [ "__self__", "profile" ]
+  },
+  "outputs": {
+    "type": "object",
+    "properties": {
+      "kubeconfig": { "type": "string" },
+      "expires": { "$ref": "pulumi.json#/Any" }
+    },
+    "required": [ "kubeconfig", "expires" ]
+  }
+}`, string(marshalled))
+}
+
+func TestComponentMethodReservedInput(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: yaml-plugin
+runtime: yaml
+components:
+  cluster:
+    methods:
+      getKubeconfig:
+        inputs:
+          self:
+            type: string
+`
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), nil)
+	require.Len(t, diags, 0)
+
+	_, diags = ParseTemplate([]byte(text), syntax)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "methods.getKubeconfig.inputs.self is reserved for the component instance", diags[0].Summary)
+}
+
 const oldCasingAssetExample = `
 name: simple-yaml
 runtime: yaml
@@ -300,6 +685,43 @@ resources:
 // TestOldCasingAssetWarns verifies that legacy PascalCase asset/archive function
 // names (e.g. fn::FileAsset) are still accepted but emit a miscapitalization
 // warning steering users toward the canonical camelCase form (fn::fileAsset).
+func TestTemplateMergeDuplicates(t *testing.T) {
+	t.Parallel()
+
+	parse := func(filename, text string) *TemplateDecl {
+		syntax, diags := encoding.DecodeYAML(filename, yaml.NewDecoder(strings.NewReader(text)), nil)
+		require.Len(t, diags, 0)
+		template, diags := ParseTemplate([]byte(text), syntax)
+		require.Len(t, diags, 0)
+		return template
+	}
+
+	template := parse("PulumiPlugin.yaml", `name: yaml-plugin
+components:
+  web:
+    outputs:
+      url: abcd
+`)
+	other := parse("web.yaml", `name: yaml-plugin
+components:
+  web:
+    outputs:
+      url: efgh
+`)
+
+	err := template.Merge(other)
+	diags, ok := err.(syntax.Diagnostics)
+	require.True(t, ok, "expected diagnostics, got %v", err)
+	require.Len(t, diags, 2)
+	assert.Equal(t, "found duplicate name", diags[0].Summary)
+	assert.Equal(t, "name was previously declared at PulumiPlugin.yaml:1,7-18", diags[0].Detail)
+	assert.Equal(t, "web.yaml", diags[0].Subject.Filename)
+	assert.Equal(t, "found duplicate component web", diags[1].Summary)
+	assert.Equal(t, "component web was previously declared at PulumiPlugin.yaml:3,3-6", diags[1].Detail)
+	assert.Equal(t, "web.yaml", diags[1].Subject.Filename)
+	assert.Len(t, template.Components.Entries, 1)
+}
+
 func TestOldCasingAssetWarns(t *testing.T) {
 	t.Parallel()
 
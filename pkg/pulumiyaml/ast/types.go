@@ -0,0 +1,261 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// typesRefPrefix is the prefix of a reference to a type declared in the `types` section of a template.
+const typesRefPrefix = "#/types/"
+
+// LookupType returns the declaration of the type that ref, e.g. `#/types/Network`, refers to.
+func (d *TemplateDecl) LookupType(ref string) (*ConfigParamDecl, bool) {
+	name, ok := strings.CutPrefix(ref, typesRefPrefix)
+	if d == nil || !ok {
+		return nil, false
+	}
+	for _, entry := range d.Types.Entries {
+		if entry.Key.Value == name && entry.Value != nil {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// EnumValues returns the type and the allowed values of a parameter that declares an `enum`. The type is the
+// parameter's `type`, if it has one, or else is inferred from the values.
+func (d *ConfigParamDecl) EnumValues() (string, []interface{}, error) {
+	if d.Enum == nil || len(d.Enum.Elements) == 0 {
+		return "", nil, errors.New("an enum must have at least one value")
+	}
+
+	values := make([]interface{}, len(d.Enum.Elements))
+	kinds := map[string]bool{}
+	for i, e := range d.Enum.Elements {
+		switch e := e.(type) {
+		case *StringExpr:
+			values[i], kinds["string"] = e.Value, true
+		case *NumberExpr:
+			if math.Mod(e.Value, 1) == 0 {
+				values[i], kinds["integer"] = e.Value, true
+			} else {
+				values[i], kinds["number"] = e.Value, true
+			}
+		case *BooleanExpr:
+			values[i], kinds["boolean"] = e.Value, true
+		default:
+			return "", nil, errors.New("enum values must be strings, numbers or booleans")
+		}
+	}
+
+	var typ string
+	switch {
+	case d.Type != nil:
+		typ = d.Type.Value
+	case len(kinds) == 1:
+		for kind := range kinds {
+			typ = kind
+		}
+	case len(kinds) == 2 && kinds["integer"] && kinds["number"]:
+		typ = "number"
+	default:
+		return "", nil, errors.New("enum values must all have the same type")
+	}
+
+	for kind := range kinds {
+		if kind != typ && !(typ == "number" && kind == "integer") {
+			return "", nil, fmt.Errorf("enum of type %s cannot have %s values", typ, kind)
+		}
+	}
+	return typ, values, nil
+}
+
+// TypeName joins names in upper camel case for use in a type token, e.g. `web-site` and `tags` become `WebSiteTags`.
+func TypeName(names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		upper := true
+		for _, r := range name {
+			switch {
+			case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+				upper = true
+			case upper:
+				b.WriteRune(unicode.ToUpper(r))
+				upper = false
+			default:
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// ComponentTypeTokens returns the tokens of the types of the package that would have the same class name in an SDK as
+// one of its components, e.g. `pkg:index:WebSite` for a component `web-site`. No type may be given one of them.
+func (d *TemplateDecl) ComponentTypeTokens() map[string]bool {
+	tokens := map[string]bool{}
+	for _, component := range d.Components.Entries {
+		tokens[fmt.Sprintf("%s:index:%s", d.Name.Value, TypeName(component.Key.Value))] = true
+	}
+	return tokens
+}
+
+// typeSpecs converts the type declarations of a template, such as the types of the inputs of its components, to
+// schema type specs.
+type typeSpecs struct {
+	template *TemplateDecl
+	// types holds the object and enum types of the package, by token.
+	types map[string]schema.ComplexTypeSpec
+	// refs holds the token of each type of the `types` section that has been, or is being, converted.
+	refs map[string]string
+	// components holds the tokens that would collide with the components of the package, as ComponentTypeTokens.
+	components map[string]bool
+}
+
+var anyTypeSpec = schema.TypeSpec{Ref: "pulumi.json#/Any"}
+
+// typeSpec returns the schema type spec of decl. name is the name given to decl if it declares an object or an enum
+// type, which must be added to the package.
+func (s *typeSpecs) typeSpec(decl *ConfigParamDecl, name string) (schema.TypeSpec, error) {
+	switch {
+	case decl.Ref != nil:
+		token, err := s.refType(decl.Ref.Value)
+		return schema.TypeSpec{Ref: typesRefPrefix + token}, err
+	case decl.Enum != nil:
+		token, err := s.enumType(decl, name, "")
+		return schema.TypeSpec{Ref: typesRefPrefix + token}, err
+	case decl.Type == nil:
+		return schema.TypeSpec{}, errors.New("missing type")
+	}
+
+	switch decl.Type.Value {
+	case "string", "integer", "number", "boolean":
+		return schema.TypeSpec{Type: decl.Type.Value}, nil
+	case "array":
+		if decl.Items == nil {
+			return schema.TypeSpec{}, errors.New("missing items")
+		}
+		items, err := s.typeSpec(decl.Items, name+"Item")
+		if err != nil {
+			return schema.TypeSpec{}, err
+		}
+		return schema.TypeSpec{Type: "array", Items: &items}, nil
+	case "object":
+		if len(decl.Properties.Entries) != 0 {
+			if decl.AdditionalProperties != nil {
+				return schema.TypeSpec{}, errors.New("an object cannot have both properties and additionalProperties")
+			}
+			token, err := s.objectType(decl, name, "")
+			return schema.TypeSpec{Ref: typesRefPrefix + token}, err
+		}
+		// An object without properties is a map.
+		fallthrough
+	case "map":
+		values := anyTypeSpec
+		if decl.AdditionalProperties != nil {
+			var err error
+			if values, err = s.typeSpec(decl.AdditionalProperties, name+"Value"); err != nil {
+				return schema.TypeSpec{}, err
+			}
+		}
+		return schema.TypeSpec{Type: "object", AdditionalProperties: &values}, nil
+	default:
+		return schema.TypeSpec{}, fmt.Errorf("unknown type: %s", decl.Type.Value)
+	}
+}
+
+// refType adds the type of the `types` section that ref refers to to the package, and returns its token.
+func (s *typeSpecs) refType(ref string) (string, error) {
+	if token, ok := s.refs[ref]; ok {
+		return token, nil
+	}
+	decl, ok := s.template.LookupType(ref)
+	if !ok {
+		return "", fmt.Errorf("unknown type reference %q", ref)
+	}
+
+	name := TypeName(strings.TrimPrefix(ref, typesRefPrefix))
+	switch {
+	case decl.Enum != nil:
+		return s.enumType(decl, name, ref)
+	case decl.Type != nil && decl.Type.Value == "object" && decl.AdditionalProperties == nil:
+		return s.objectType(decl, name, ref)
+	default:
+		return "", fmt.Errorf("type %s must be an object with properties or an enum", name)
+	}
+}
+
+// declare reserves a token for a type of the package named name. ref is the reference to the type, if it is declared
+// in the `types` section.
+func (s *typeSpecs) declare(name, ref string) string {
+	token := fmt.Sprintf("%s:index:%s", s.template.Name.Value, name)
+	for i := 2; ; i++ {
+		if _, ok := s.types[token]; !ok && !s.components[token] {
+			break
+		}
+		token = fmt.Sprintf("%s:index:%s%d", s.template.Name.Value, name, i)
+	}
+	s.types[token] = schema.ComplexTypeSpec{}
+	if ref != "" {
+		s.refs[ref] = token
+	}
+	return token
+}
+
+// objectType adds the object type that decl declares to the package, and returns its token.
+func (s *typeSpecs) objectType(decl *ConfigParamDecl, name, ref string) (string, error) {
+	// Declare the type before its properties are converted, in case it refers to itself.
+	token := s.declare(name, ref)
+
+	spec := schema.ObjectTypeSpec{
+		Type:       "object",
+		Properties: map[string]schema.PropertySpec{},
+	}
+	for _, property := range decl.Properties.Entries {
+		k, v := property.Key.Value, property.Value
+		typeSpec, err := s.typeSpec(v, name+TypeName(k))
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", k, err)
+		}
+		spec.Properties[k] = schema.PropertySpec{
+			TypeSpec: typeSpec,
+			Secret:   v.Secret != nil && v.Secret.Value,
+		}
+	}
+	if decl.Required != nil {
+		for _, k := range decl.Required.Elements {
+			if _, ok := spec.Properties[k.Value]; !ok {
+				return "", fmt.Errorf("required property %s is not declared", k.Value)
+			}
+			spec.Required = append(spec.Required, k.Value)
+		}
+	}
+	s.types[token] = schema.ComplexTypeSpec{ObjectTypeSpec: spec}
+	return token, nil
+}
+
+// enumType adds the enum type that decl declares to the package, and returns its token.
+func (s *typeSpecs) enumType(decl *ConfigParamDecl, name, ref string) (string, error) {
+	typ, values, err := decl.EnumValues()
+	if err != nil {
+		return "", err
+	}
+
+	spec := schema.ComplexTypeSpec{ObjectTypeSpec: schema.ObjectTypeSpec{Type: typ}}
+	for _, v := range values {
+		if typ == "integer" {
+			v = int(v.(float64))
+		}
+		spec.Enum = append(spec.Enum, schema.EnumValueSpec{Value: v})
+	}
+	token := s.declare(name, ref)
+	s.types[token] = spec
+	return token, nil
+}
@@ -27,6 +27,13 @@ import (
 type GenerateFunc func(program *pcl.Program) (map[string][]byte, hcl.Diagnostics, error)
 
 func ConvertTemplateIL(template *ast.TemplateDecl, loader schema.ReferenceLoader) (string, hcl.Diagnostics, error) {
+	programText, _, diags, err := convertTemplateIL(template, loader, ImportOptions{})
+	return programText, diags, err
+}
+
+func convertTemplateIL(
+	template *ast.TemplateDecl, loader schema.ReferenceLoader, opts ImportOptions,
+) (string, []Name, hcl.Diagnostics, error) {
 	contract.Assertf(loader != nil, "loader must be non-nil")
 	var diags hcl.Diagnostics
 
@@ -34,19 +41,19 @@ func ConvertTemplateIL(template *ast.TemplateDecl, loader schema.ReferenceLoader
 	// nil runner passed in since template is not executed and we can use pkgLoader
 	_, tdiags, err := pulumiyaml.PrepareTemplate(template, nil, pkgLoader)
 	if err != nil {
-		return "", diags, err
+		return "", nil, diags, err
 	}
 	diags = diags.Extend(tdiags.HCL())
 
-	templateBody, tdiags := ImportTemplate(template, pkgLoader)
+	templateBody, names, tdiags := ImportTemplateWithOptions(template, pkgLoader, opts)
 	diags = diags.Extend(tdiags.HCL())
 	if templateBody == nil {
 		// This is a irrecoverable error, so we make sure the error field is non-nil
-		return "", diags, diags
+		return "", nil, diags, diags
 	}
 	programText := fmt.Sprintf("%v", templateBody)
 
-	return programText, diags, nil
+	return programText, names, diags, nil
 }
 
 // InputHints returns the input property types declared by the resource, function, or provider
@@ -208,20 +215,111 @@ func ImportSnippetAttributes(
 }
 
 func EjectProgram(template *ast.TemplateDecl, loader schema.ReferenceLoader) (*pcl.Program, hcl.Diagnostics, error) {
-	programText, yamlDiags, err := ConvertTemplateIL(template, loader)
+	program, _, diags, err := ejectProgram(template, loader, ImportOptions{})
+	return program, diags, err
+}
+
+func ejectProgram(
+	template *ast.TemplateDecl, loader schema.ReferenceLoader, opts ImportOptions,
+) (*pcl.Program, []Name, hcl.Diagnostics, error) {
+	programText, names, yamlDiags, err := convertTemplateIL(template, loader, opts)
+	if err != nil {
+		return nil, nil, yamlDiags, err
+	}
+
+	program, diags, err := bindProgram(programText, loader)
+	return program, names, append(yamlDiags, diags...), err
+}
+
+// EjectComponents converts each component declared by a YAML plugin template to the program of a PCL
+// component, keyed by the component's name. A PCL component declares its inputs as config and its
+// outputs as outputs. It also returns the identifier assigned to each of the components' declarations.
+func EjectComponents(
+	template *ast.TemplateDecl, loader schema.ReferenceLoader, opts ImportOptions,
+) (map[string]*pcl.Program, []Name, hcl.Diagnostics, error) {
+	contract.Assertf(loader != nil, "loader must be non-nil")
+	var diags hcl.Diagnostics
+
+	pkgLoader := pulumiyaml.NewPackageLoaderFromSchemaLoader(loader)
+	_, tdiags, err := pulumiyaml.PrepareTemplate(template, nil, pkgLoader)
 	if err != nil {
-		return nil, yamlDiags, err
+		return nil, nil, diags, err
+	}
+	diags = diags.Extend(tdiags.HCL())
+
+	components := map[string]*pcl.Program{}
+	var names []Name
+	for _, c := range template.Components.Entries {
+		body, cnames, cdiags := ImportTemplateWithOptions(c.Value, pkgLoader, opts)
+		diags = diags.Extend(cdiags.HCL())
+		if body == nil {
+			return nil, nil, diags, diags
+		}
+		program, pdiags, err := bindProgram(fmt.Sprintf("%v", body), loader)
+		diags = diags.Extend(pdiags)
+		if err != nil {
+			return nil, nil, diags, fmt.Errorf("component %s: %w", c.Key.Value, err)
+		}
+		components[c.Key.Value] = program
+		for _, n := range cnames {
+			n.Component = c.Key.Value
+			names = append(names, n)
+		}
+	}
+	return components, names, diags, nil
+}
+
+// bindPluginProgram binds the main program of a YAML plugin converted to PCL. The program declares
+// an instance of each of the plugin's components, so that the components are reachable from it. The
+// inputs of each component that have no default are set from config named after the component and
+// the input, such as `siteName`.
+func bindPluginProgram(
+	dir string, template *ast.TemplateDecl, components map[string]*pcl.Program, loader schema.ReferenceLoader,
+) (*pcl.Program, hcl.Diagnostics, error) {
+	var text strings.Builder
+	for _, c := range template.Components.Entries {
+		name := c.Key.Value
+		ident := makeLegalIdentifier(name)
+		var inputs strings.Builder
+		for _, n := range components[name].Nodes {
+			config, ok := n.(*pcl.ConfigVariable)
+			if !ok || config.DefaultValue != nil {
+				continue
+			}
+			typ := "string"
+			if labels := config.Definition.Labels; len(labels) > 1 {
+				typ = labels[1]
+			}
+			id := ident + strings.ToUpper(config.Name()[:1]) + config.Name()[1:]
+			fmt.Fprintf(&text, "config %s %q {}\n\n", id, typ)
+			fmt.Fprintf(&inputs, "\t%s = %s\n", config.LogicalName(), id)
+		}
+		fmt.Fprintf(&text, "component %s %q {\n%s}\n\n", ident, "./"+name, inputs.String())
+	}
+
+	binder := func(args pcl.ComponentProgramBinderArgs) (*pcl.Program, hcl.Diagnostics, error) {
+		program, ok := components[strings.TrimPrefix(args.ComponentSource, "./")]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown component %s", args.ComponentSource)
+		}
+		return program, nil, nil
 	}
+	return bindProgram(text.String(), loader, pcl.DirPath(dir), pcl.ComponentBinder(binder))
+}
 
+// bindProgram parses and binds the text of a PCL program imported from YAML.
+func bindProgram(
+	programText string, loader schema.ReferenceLoader, opts ...pcl.BindOption,
+) (*pcl.Program, hcl.Diagnostics, error) {
 	parser := hclsyntax.NewParser()
 	if programText != "" {
 		if err := parser.ParseFile(strings.NewReader(programText), "program.pp"); err != nil {
-			return nil, yamlDiags, err
+			return nil, nil, err
 		}
 	}
 	diags := parser.Diagnostics
 	if diags.HasErrors() {
-		return nil, append(yamlDiags, diags...), diags
+		return nil, diags, diags
 	}
 
 	bindOpts := []pcl.BindOption{
@@ -229,14 +327,14 @@ func EjectProgram(template *ast.TemplateDecl, loader schema.ReferenceLoader) (*p
 		pcl.AllowMissingProperties,
 		pcl.AllowMissingVariables,
 	}
-	program, pdiags, err := pcl.BindProgram(parser.Files, loader, bindOpts...)
+	program, pdiags, err := pcl.BindProgram(parser.Files, loader, append(bindOpts, opts...)...)
 	diags = diags.Extend(pdiags)
 	if err != nil {
-		return nil, append(yamlDiags, diags...), err
+		return nil, diags, err
 	}
 	if pdiags.HasErrors() || program == nil {
-		return nil, append(yamlDiags, diags...), fmt.Errorf("internal error: %w", pdiags)
+		return nil, diags, fmt.Errorf("internal error: %w", pdiags)
 	}
 
-	return program, append(yamlDiags, diags...), nil
+	return program, diags, nil
 }
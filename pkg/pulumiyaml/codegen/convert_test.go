@@ -5,12 +5,17 @@ package codegen
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
 )
@@ -326,3 +331,353 @@ func TestImportSnippetAttributes_Empty(t *testing.T) {
 	require.Falsef(t, diags.HasErrors(), "unexpected diagnostics: %v", diags)
 	assert.Nil(t, out)
 }
+
+func TestEjectComponents(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: plugin
+runtime: yaml
+components:
+  site:
+    inputs:
+      idea:
+        type: string
+    resources:
+      thing:
+        type: other:index:Thing
+        properties:
+          idea: ${idea}
+    outputs:
+      id: ${thing.id}
+`
+	template, diags, err := pulumiyaml.LoadYAMLBytes("PulumiPlugin.yaml", []byte(text))
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags)
+
+	loader := schema.NewPluginLoader(newPluginContext()).(schema.ReferenceLoader)
+	components, _, hdiags, err := EjectComponents(template, loader, ImportOptions{})
+	require.NoError(t, err)
+	require.False(t, hdiags.HasErrors(), hdiags)
+	require.Contains(t, components, "site")
+
+	var names []string
+	for _, n := range components["site"].Nodes {
+		names = append(names, n.Name())
+	}
+	assert.ElementsMatch(t, []string{"idea", "thing", "id"}, names)
+}
+
+func TestEjectPlugin(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: plugin
+runtime: yaml
+components:
+  site:
+    inputs:
+      range:
+        type: string
+      greeting:
+        type: string
+        default: hello
+    outputs:
+      message: ${greeting} ${range}
+`
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "PulumiPlugin.yaml"), []byte(text), 0o600))
+
+	loader := schema.NewPluginLoader(newPluginContext()).(schema.ReferenceLoader)
+	proj, program, names, err := EjectPlugin(dir, loader, ImportOptions{Languages: []string{"go"}})
+	require.NoError(t, err)
+	assert.Equal(t, "plugin", string(proj.Name))
+
+	var component *pcl.Component
+	var configs []string
+	for _, n := range program.Nodes {
+		switch n := n.(type) {
+		case *pcl.Component:
+			component = n
+		case *pcl.ConfigVariable:
+			configs = append(configs, n.Name())
+		}
+	}
+	require.NotNil(t, component)
+	assert.Equal(t, "site", component.Name())
+	assert.Equal(t, "site", filepath.Base(component.DirPath()))
+	// Only the input without a default is set from config. Within the component, the input was
+	// renamed to avoid the Go keyword, and the config is named after the renamed input.
+	require.Len(t, component.Inputs, 1)
+	assert.Equal(t, "range", component.Inputs[0].Name)
+	var renamed string
+	for _, c := range component.Program.ConfigVariables() {
+		if c.LogicalName() == "range" {
+			renamed = c.Name()
+		}
+	}
+	assert.NotEqual(t, "range", renamed)
+	assert.Equal(t, []string{"site" + strings.ToUpper(renamed[:1]) + renamed[1:]}, configs)
+	assert.Contains(t, names, Name{Kind: "config", LogicalName: "range", Identifier: renamed, Component: "site"})
+}
+
+// stdFunctions declares the functions of the std package that builtins are imported as, by name,
+// with the types of their arguments and result.
+var stdFunctions = map[string]struct {
+	args   map[string]schema.TypeSpec
+	result schema.TypeSpec
+}{
+	"upper":     {map[string]schema.TypeSpec{"input": {Type: "string"}}, schema.TypeSpec{Type: "string"}},
+	"lower":     {map[string]schema.TypeSpec{"input": {Type: "string"}}, schema.TypeSpec{Type: "string"}},
+	"trimspace": {map[string]schema.TypeSpec{"input": {Type: "string"}}, schema.TypeSpec{Type: "string"}},
+	"replace": {
+		map[string]schema.TypeSpec{"text": {Type: "string"}, "search": {Type: "string"}, "replace": {Type: "string"}},
+		schema.TypeSpec{Type: "string"},
+	},
+	"substr": {
+		map[string]schema.TypeSpec{"input": {Type: "string"}, "offset": {Type: "integer"}, "length": {Type: "integer"}},
+		schema.TypeSpec{Type: "string"},
+	},
+	"regexall": {
+		map[string]schema.TypeSpec{"pattern": {Type: "string"}, "string": {Type: "string"}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"merge": {
+		map[string]schema.TypeSpec{"input": {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}}},
+		schema.TypeSpec{Type: "object", AdditionalProperties: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"keys": {
+		map[string]schema.TypeSpec{"input": {Ref: "pulumi.json#/Any"}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Type: "string"}},
+	},
+	"values": {
+		map[string]schema.TypeSpec{"input": {Ref: "pulumi.json#/Any"}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"flatten": {
+		map[string]schema.TypeSpec{"input": {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"concat": {
+		map[string]schema.TypeSpec{"input": {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"distinct": {
+		map[string]schema.TypeSpec{"input": {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}}},
+		schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	},
+	"contains": {
+		map[string]schema.TypeSpec{
+			"input":   {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+			"element": {Ref: "pulumi.json#/Any"},
+		},
+		schema.TypeSpec{Type: "boolean"},
+	},
+	"jsondecode": {map[string]schema.TypeSpec{"input": {Type: "string"}}, schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+	"format": {
+		map[string]schema.TypeSpec{
+			"input": {Type: "string"},
+			"args":  {Type: "array", Items: &schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+		},
+		schema.TypeSpec{Type: "string"},
+	},
+}
+
+// stdLoader serves a std package that declares stdFunctions, and loads any other package from the
+// test schemas.
+type stdLoader struct {
+	schema.ReferenceLoader
+
+	std *schema.Package
+}
+
+func newStdLoader(t *testing.T) *stdLoader {
+	spec := schema.PackageSpec{Name: "std", Version: "2.2.0", Functions: map[string]schema.FunctionSpec{}}
+	for name, f := range stdFunctions {
+		inputs := &schema.ObjectTypeSpec{Type: "object", Properties: map[string]schema.PropertySpec{}}
+		for arg, typ := range f.args {
+			inputs.Properties[arg] = schema.PropertySpec{TypeSpec: typ}
+			inputs.Required = append(inputs.Required, arg)
+		}
+		spec.Functions["std:index:"+name] = schema.FunctionSpec{
+			Inputs: inputs,
+			Outputs: &schema.ObjectTypeSpec{
+				Type:       "object",
+				Properties: map[string]schema.PropertySpec{"result": {TypeSpec: f.result}},
+				Required:   []string{"result"},
+			},
+		}
+	}
+	std, err := schema.ImportSpec(spec, nil, schema.NewNullLoader(), schema.ValidationOptions{})
+	require.NoError(t, err)
+	return &stdLoader{ReferenceLoader: rootPluginLoader.(schema.ReferenceLoader), std: std}
+}
+
+func (l *stdLoader) LoadPackage(pkg string, version *semver.Version) (*schema.Package, error) {
+	if pkg == "std" {
+		return l.std, nil
+	}
+	return l.ReferenceLoader.LoadPackage(pkg, version)
+}
+
+func (l *stdLoader) LoadPackageV2(ctx context.Context, desc *schema.PackageDescriptor) (*schema.Package, error) {
+	if desc.Name == "std" {
+		return l.std, nil
+	}
+	return l.ReferenceLoader.LoadPackageV2(ctx, desc)
+}
+
+func (l *stdLoader) LoadPackageReference(pkg string, version *semver.Version) (schema.PackageReference, error) {
+	if pkg == "std" {
+		return l.std.Reference(), nil
+	}
+	return l.ReferenceLoader.LoadPackageReference(pkg, version)
+}
+
+func (l *stdLoader) LoadPackageReferenceV2(
+	ctx context.Context, desc *schema.PackageDescriptor,
+) (schema.PackageReference, error) {
+	if desc.Name == "std" {
+		return l.std.Reference(), nil
+	}
+	return l.ReferenceLoader.LoadPackageReferenceV2(ctx, desc)
+}
+
+// TestEjectProgramBuiltins checks that the PCL each builtin is imported as binds.
+func TestEjectProgramBuiltins(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"upper", `{"fn::upper": "${name}"}`},
+		{"lower", `{"fn::lower": "${name}"}`},
+		{"trim", `{"fn::trim": " ${name} "}`},
+		{"replace", `{"fn::replace": ["${name}", "_", "-"]}`},
+		{"substring", `{"fn::substring": ["${name}", 0, 5]}`},
+		{"format", `{"fn::format": ["%s-%d", "${name}", 1]}`},
+		{"regexMatch", `{"fn::regexMatch": ["^[a-z]+$", "${name}"]}`},
+		{"regexReplace", `{"fn::regexReplace": ["(?P<first>[a-z])", "${name}", "$${first}-"]}`},
+		{"regexReplace with a computed pattern", `{"fn::regexReplace": ["${name}", "my-${name}", ""]}`},
+		{"regexFind", `{"fn::regexFind": ["[a-z]+", "${name}"]}`},
+		{"merge", `{"fn::merge": [{"a": "${name}"}, {"b": 1}]}`},
+		{"keys", `{"fn::keys": {"a": "${name}"}}`},
+		{"values", `{"fn::values": {"a": "${name}"}}`},
+		{"flatten", `{"fn::flatten": [["${name}"], [[1]]]}`},
+		{"concat", `{"fn::concat": [["${name}"], [1]]}`},
+		{"distinct", `{"fn::distinct": ["${name}", "${name}"]}`},
+		{"contains", `{"fn::contains": [["a", "b"], "${name}"]}`},
+		{"zip", `{"fn::zip": [["${name}"], [1]]}`},
+		{"lookup", `{"fn::lookup": [{"a": 1}, "${name}", 0]}`},
+		{"fromJSON", `{"fn::fromJSON": "[\"${name}\"]"}`},
+		{"fromYAML", `{"fn::fromYAML": "- ${name}"}`},
+		{"toYAML", `{"fn::toYAML": ["${name}"]}`},
+		{"mimeType", `{"fn::mimeType": "${name}.html"}`},
+	}
+	loader := newStdLoader(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			text := "name: test\nruntime: yaml\nconfig:\n  name:\n    type: string\noutputs:\n  result: " + tt.expr + "\n"
+			template, diags, err := pulumiyaml.LoadYAMLBytes("Pulumi.yaml", []byte(text))
+			require.NoError(t, err)
+			require.False(t, diags.HasErrors(), diags)
+
+			program, hdiags, err := EjectProgram(template, loader)
+			require.NoError(t, err)
+			require.False(t, hdiags.HasErrors(), hdiags)
+			assert.Len(t, program.OutputVariables(), 1)
+		})
+	}
+}
+
+// The builtins that are converted to invokes of std functions are converted back when the program is generated.
+func TestEjectProgramBuiltinsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		expr string
+		// expected is the generated expression, if it is not expr.
+		expected string
+	}{
+		{name: "upper", expr: `{"fn::upper": "${name}"}`},
+		{name: "lower", expr: `{"fn::lower": "${name}"}`},
+		{name: "trim", expr: `{"fn::trim": " ${name} "}`},
+		{name: "replace", expr: `{"fn::replace": ["${name}", "_", "-"]}`},
+		{
+			name:     "replace slashes",
+			expr:     `{"fn::replace": ["${name}", "/a.b/", "$"]}`,
+			expected: `{"fn::regexReplace": ["/a\\.b/", "${name}", "$$"]}`,
+		},
+		{name: "substring", expr: `{"fn::substring": ["${name}", 0, 5]}`},
+		{name: "format", expr: `{"fn::format": ["%s-%d", "${name}", 1]}`},
+		{name: "regexMatch", expr: `{"fn::regexMatch": ["^[a-z]+$", "${name}"]}`},
+		{name: "regexReplace", expr: `{"fn::regexReplace": ["(?P<first>[a-z])", "${name}", "$${first}-"]}`},
+		{name: "regexFind", expr: `{"fn::regexFind": ["[a-z]+", "${name}"]}`},
+		{name: "merge", expr: `{"fn::merge": [{"a": "${name}"}, {"b": 1}]}`},
+		{name: "keys", expr: `{"fn::keys": {"a": "${name}"}}`},
+		{name: "values", expr: `{"fn::values": {"a": "${name}"}}`},
+		{name: "flatten", expr: `{"fn::flatten": [["${name}"], [[1]]]}`},
+		{name: "concat", expr: `{"fn::concat": [["${name}"], [1]]}`},
+		{name: "distinct", expr: `{"fn::distinct": ["${name}", "${name}"]}`},
+		{name: "contains", expr: `{"fn::contains": [["a", "b"], "${name}"]}`},
+		{name: "fromJSON", expr: `{"fn::fromJSON": "[\"${name}\"]"}`},
+		{
+			name: "format with an unsupported verb",
+			expr: `{"fn::invoke": {"function": "std:index:format", ` +
+				`"arguments": {"input": "%t-%s", "args": [true, "${name}"]}, "return": "result"}}`,
+			expected: `{"fn::invoke": {"function": "std:format", ` +
+				`"arguments": {"input": "%t-%s", "args": [true, "${name}"]}, "return": "result"}}`,
+		},
+		{
+			name: "format with an argument index",
+			expr: `{"fn::invoke": {"function": "std:index:format", ` +
+				`"arguments": {"input": "%[1]s", "args": ["${name}"]}, "return": "result"}}`,
+			expected: `{"fn::invoke": {"function": "std:format", ` +
+				`"arguments": {"input": "%[1]s", "args": ["${name}"]}, "return": "result"}}`,
+		},
+		{
+			name: "substr with a negative offset",
+			expr: `{"fn::invoke": {"function": "std:index:substr", ` +
+				`"arguments": {"input": "${name}", "offset": -3, "length": 2}, "return": "result"}}`,
+			expected: `{"fn::invoke": {"function": "std:substr", ` +
+				`"arguments": {"input": "${name}", "offset": -3, "length": 2}, "return": "result"}}`,
+		},
+	}
+	loader := newStdLoader(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			text := "name: test\nruntime: yaml\nconfig:\n  name:\n    type: string\noutputs:\n  result: " + tt.expr + "\n"
+			template, diags, err := pulumiyaml.LoadYAMLBytes("Pulumi.yaml", []byte(text))
+			require.NoError(t, err)
+			require.False(t, diags.HasErrors(), diags)
+
+			program, hdiags, err := EjectProgram(template, loader)
+			require.NoError(t, err)
+			require.False(t, hdiags.HasErrors(), hdiags)
+
+			files, hdiags, err := GenerateProgram(program)
+			require.NoError(t, err)
+			require.False(t, hdiags.HasErrors(), hdiags)
+
+			var generated struct {
+				Outputs struct {
+					Result interface{} `yaml:"result"`
+				} `yaml:"outputs"`
+			}
+			require.NoError(t, yaml.Unmarshal(files["Main.yaml"], &generated))
+			expected := tt.expected
+			if expected == "" {
+				expected = tt.expr
+			}
+			var expr interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(expected), &expr))
+			assert.Equal(t, expr, generated.Outputs.Result)
+		})
+	}
+}
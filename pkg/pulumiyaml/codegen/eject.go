@@ -14,6 +14,7 @@ import (
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -23,6 +24,15 @@ var ProjectKeysToOmit = []string{"configuration", "resources", "outputs", "varia
 // Eject on a YAML program directory returns a Pulumi Project and a YAML program which has been
 // parsed and converted to the intermediate PCL language
 func Eject(dir string, loader schema.ReferenceLoader) (*workspace.Project, *pcl.Program, error) {
+	proj, program, _, err := EjectWithOptions(dir, loader, ImportOptions{})
+	return proj, program, err
+}
+
+// EjectWithOptions is Eject with options controlling the import to PCL. It also returns the identifier
+// assigned to each of the program's declarations.
+func EjectWithOptions(
+	dir string, loader schema.ReferenceLoader, opts ImportOptions,
+) (*workspace.Project, *pcl.Program, []Name, error) {
 	// `*pcl.Program`'s maintains an internal reference to the loader that was used during
 	// its creation. This means the lifetime of the returned program is tied to the
 	// lifetime of the loader passed to EjectProgram and ultimately to the host that
@@ -34,10 +44,10 @@ func Eject(dir string, loader schema.ReferenceLoader) (*workspace.Project, *pcl.
 	contract.Assertf(loader != nil, "must provide a non-nil loader")
 	proj, template, diags, err := LoadTemplate(dir)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if template == nil && diags.HasErrors() {
-		return nil, nil, fmt.Errorf("failed to load the template: %s", diags.Error())
+		return nil, nil, nil, fmt.Errorf("failed to load the template: %s", diags.Error())
 	}
 	// remove extraneous keys from Pulumi.yaml project file
 	if proj.AdditionalKeys != nil {
@@ -49,22 +59,80 @@ func Eject(dir string, loader schema.ReferenceLoader) (*workspace.Project, *pcl.
 	if len(diags) != 0 {
 		err := diagWriter.WriteDiagnostics(diags)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
+		}
+	}
+
+	program, names, pdiags, err := ejectProgram(template, loader, opts)
+	if len(pdiags) != 0 {
+		err := diagWriter.WriteDiagnostics(pdiags)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load YAML program, %v", err)
+	}
+
+	return proj, program, names, nil
+}
+
+// EjectPlugin on a YAML plugin directory, which declares components in PulumiPlugin.yaml, returns a
+// Pulumi Project named after the plugin and a program in the intermediate PCL language. Each of the
+// plugin's components is converted to a PCL component in a directory named after it, which the
+// program instantiates. It also returns the identifier assigned to each of the components'
+// declarations.
+func EjectPlugin(
+	dir string, loader schema.ReferenceLoader, opts ImportOptions,
+) (*workspace.Project, *pcl.Program, []Name, error) {
+	contract.Assertf(loader != nil, "must provide a non-nil loader")
+	template, diags, err := pulumiyaml.LoadPluginTemplate(dir)
+	if template == nil || diags.HasErrors() {
+		if err == nil {
+			err = diags
+		}
+		return nil, nil, nil, fmt.Errorf("failed to load the plugin: %w", err)
+	}
+
+	proj := &workspace.Project{
+		Name:    tokens.PackageName(template.Name.Value),
+		Runtime: workspace.NewProjectRuntimeInfo("yaml", nil),
+	}
+	if template.Description != nil {
+		proj.Description = &template.Description.Value
+	}
+
+	diagWriter := template.NewDiagnosticWriter(os.Stderr, 0, true)
+	if len(diags) != 0 {
+		err := diagWriter.WriteDiagnostics(diags.HCL())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	components, names, cdiags, err := EjectComponents(template, loader, opts)
+	if len(cdiags) != 0 {
+		err := diagWriter.WriteDiagnostics(cdiags)
+		if err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load YAML plugin, %v", err)
+	}
 
-	program, pdiags, err := EjectProgram(template, loader)
+	program, pdiags, err := bindPluginProgram(dir, template, components, loader)
 	if len(pdiags) != 0 {
 		err := diagWriter.WriteDiagnostics(pdiags)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load YAML program, %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to load YAML plugin, %v", err)
 	}
 
-	return proj, program, nil
+	return proj, program, names, nil
 }
 
 func getProjectPath(dir string) (string, error) {
@@ -3,6 +3,7 @@
 package codegen
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -56,17 +57,21 @@ func (f *formatter) formatObjectCons(x *model.ObjectConsExpression) {
 		x.Tokens.OpenBrace.TrailingTrivia = f.newline()
 		f.indented(func() {
 			for i, item := range x.Items {
+				head := commentsIn(item.Key.GetLeadingTrivia())
+				line, foot := splitComments(item.Value.GetTrailingTrivia())
+
 				f.formatExpression(item.Key)
-				item.Key.SetLeadingTrivia(f.indent())
+				item.Key.SetLeadingTrivia(append(f.commentLines(head), f.indent()...))
 
 				x.Tokens.Items[i].Equals.LeadingTrivia = f.space()
 				x.Tokens.Items[i].Equals.TrailingTrivia = f.space()
 
 				f.formatExpression(item.Value)
+				trailingTrivia := append(f.endOfLine(line), f.commentLines(foot)...)
 				if x.Tokens.Items[i].Comma == nil {
-					item.Value.SetTrailingTrivia(f.newline())
+					item.Value.SetTrailingTrivia(trailingTrivia)
 				} else {
-					x.Tokens.Items[i].Comma.TrailingTrivia = f.newline()
+					x.Tokens.Items[i].Comma.TrailingTrivia = trailingTrivia
 				}
 			}
 		})
@@ -141,7 +146,13 @@ func (f *formatter) formatExpression(x model.Expression) model.Expression {
 		if x.KeyVariable != nil {
 			keyName = x.KeyVariable.Name
 		}
-		x.Tokens = syntax.NewForTokens(keyName, x.ValueVariable.Name, x.Key != nil, x.Group, x.Condition != nil)
+		tokens := syntax.NewForTokens(keyName, x.ValueVariable.Name, x.Key != nil, x.Group, x.Condition != nil)
+		if x.Key != nil {
+			// NewForTokens always brackets the expression, but one that produces an object is braced.
+			tokens.Open.Raw = hclsyntax.Token{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")}
+			tokens.Close.Raw = hclsyntax.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")}
+		}
+		x.Tokens = tokens
 
 		f.formatExpression(x.Collection).SetLeadingTrivia(f.space())
 		if x.Key != nil {
@@ -215,36 +226,28 @@ func (f *formatter) formatExpression(x model.Expression) model.Expression {
 //
 // Unless the block is the first in its containing body, a newline is inserted before the block.
 func (f *formatter) formatBlock(block *model.Block, first bool) {
-	tokens := block.Tokens
-
-	var comments []syntax.Comment
-	if tokens != nil {
-		for _, t := range tokens.Type.LeadingTrivia {
-			if comment, ok := t.(syntax.Comment); ok {
-				comments = append(comments, comment)
-			}
-		}
+	var head, line, foot []syntax.Comment
+	if tokens := block.Tokens; tokens != nil {
+		head = commentsIn(tokens.Type.LeadingTrivia)
+		line, _ = splitComments(tokens.OpenBrace.TrailingTrivia)
+		_, foot = splitComments(tokens.CloseBrace.TrailingTrivia)
 	}
 
 	block.Tokens = syntax.NewBlockTokens(block.Type, block.Labels...)
 
-	leadingTrivia := f.indent()
-	if len(comments) != 0 {
-		for _, c := range comments {
-			leadingTrivia = append(leadingTrivia, c)
-		}
-	}
+	leadingTrivia := append(f.commentLines(head), f.indent()...)
 	if !first {
 		leadingTrivia = append(f.newline(), leadingTrivia...)
 	}
 	block.Tokens.Type.LeadingTrivia = leadingTrivia
+	block.Tokens.OpenBrace.TrailingTrivia = f.endOfLine(line)
 
 	f.indented(func() {
 		f.formatBody(block.Body)
 	})
 
 	block.Tokens.CloseBrace.LeadingTrivia = f.indent()
-	block.Tokens.CloseBrace.TrailingTrivia = f.newline()
+	block.Tokens.CloseBrace.TrailingTrivia = append(f.newline(), f.commentLines(foot)...)
 }
 
 // formatAttribute formats a PCL attribute as
@@ -253,9 +256,17 @@ func (f *formatter) formatBlock(block *model.Block, first bool) {
 //
 // If the attribute follows a block, a newline is inserted before the attribute.
 func (f *formatter) formatAttribute(attr *model.Attribute, afterBlock bool) {
+	var head, line, foot []syntax.Comment
+	if attr.Tokens != nil {
+		head = commentsIn(attr.Tokens.Name.LeadingTrivia)
+	}
+	if attr.Value != nil {
+		line, foot = splitComments(attr.Value.GetTrailingTrivia())
+	}
+
 	attr.Tokens = syntax.NewAttributeTokens(attr.Name)
 
-	leadingTrivia := f.indent()
+	leadingTrivia := append(f.commentLines(head), f.indent()...)
 	if afterBlock {
 		leadingTrivia = append(f.newline(), leadingTrivia...)
 	}
@@ -265,8 +276,66 @@ func (f *formatter) formatAttribute(attr *model.Attribute, afterBlock bool) {
 
 	if attr.Value != nil {
 		attr.Value.SetLeadingTrivia(f.space())
-		attr.Value.SetTrailingTrivia(f.newline())
+		attr.Value.SetTrailingTrivia(append(f.endOfLine(line), f.commentLines(foot)...))
+	}
+}
+
+// commentsIn returns the comments in the given trivia.
+func commentsIn(trivia syntax.TriviaList) []syntax.Comment {
+	var comments []syntax.Comment
+	for _, t := range trivia {
+		if c, ok := t.(syntax.Comment); ok {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+// splitComments splits the trivia that follows an item into the comments on the item's line and
+// the comments on the lines after it.
+func splitComments(trivia syntax.TriviaList) (line, foot []syntax.Comment) {
+	onLine := true
+	for _, t := range trivia {
+		switch t := t.(type) {
+		case syntax.Comment:
+			if onLine {
+				line = append(line, t)
+			} else {
+				foot = append(foot, t)
+			}
+		case syntax.Whitespace:
+			if bytes.ContainsRune(t.Bytes(), '\n') {
+				onLine = false
+			}
+		}
+	}
+	return line, foot
+}
+
+// endOfLine ends a line with the given comments. The PCL syntax package only creates comments by
+// parsing them, so comments are written as raw trivia, which is parsed back into comments when the
+// program is bound.
+func (f *formatter) endOfLine(comments []syntax.Comment) syntax.TriviaList {
+	if len(comments) == 0 {
+		return f.newline()
+	}
+	var text []string
+	for _, c := range comments {
+		text = append(text, c.Lines...)
+	}
+	return syntax.TriviaList{syntax.NewWhitespace([]byte(" // " + strings.Join(text, " ") + "\n")...)}
+}
+
+// commentLines lays out comments on lines of their own at the current indent level.
+func (f *formatter) commentLines(comments []syntax.Comment) syntax.TriviaList {
+	var trivia syntax.TriviaList
+	for _, c := range comments {
+		for _, line := range c.Lines {
+			trivia = append(trivia, f.indent()...)
+			trivia = append(trivia, syntax.NewWhitespace([]byte(strings.TrimRight("// "+line, " ")+"\n")...))
+		}
 	}
+	return trivia
 }
 
 // formatBody formats a PCL body.
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -24,14 +26,21 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/fsutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
 	syn "github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
 )
 
-// Generate a serializable YAML template.
+// componentsPackage is the name of the plugin that the local components of a PCL program are
+// generated into. Its PulumiPlugin.yaml is written to a directory of the same name, beside the
+// program.
+const componentsPackage = "components"
+
+// Generate a serializable YAML template. If the program uses local components, they are generated
+// into components/PulumiPlugin.yaml.
 func GenerateProgram(program *pcl.Program) (map[string][]byte, hcl.Diagnostics, error) {
-	g := generator{}
+	g := generator{components: &componentDefinitions{dirs: map[string]string{}}}
 
 	for _, n := range program.Nodes {
 		g.genNode(n)
@@ -41,19 +50,38 @@ func GenerateProgram(program *pcl.Program) (map[string][]byte, hcl.Diagnostics,
 		return nil, g.diags, nil
 	}
 
-	w := &bytes.Buffer{}
+	files := map[string][]byte{}
+	main, err := encodeYAML(g.UnifyOutput())
+	if err != nil {
+		return nil, g.diags, err
+	}
+	files["Main.yaml"] = main
+
+	if len(g.components.definitions) > 0 {
+		plugin, err := encodeYAML(syn.Object(
+			syn.ObjectProperty(syn.String("name"), syn.String(componentsPackage)),
+			syn.ObjectProperty(syn.String("runtime"), syn.String("yaml")),
+			syn.ObjectProperty(syn.String("components"), syn.Object(g.components.definitions...)),
+		))
+		if err != nil {
+			return nil, g.diags, err
+		}
+		files[path.Join(componentsPackage, "PulumiPlugin.yaml")] = plugin
+	}
 
-	out := g.UnifyOutput()
+	return files, g.diags, nil
+}
+
+// encodeYAML encodes a YAML document.
+func encodeYAML(n syn.Node) ([]byte, error) {
+	w := &bytes.Buffer{}
 	encoder := yaml.NewEncoder(w)
 	encoder.SetIndent(2)
-	diags := encoding.EncodeYAML(encoder, out)
-
-	var err error
+	diags := encoding.EncodeYAML(encoder, n)
 	if diags.HasErrors() {
-		err = diags
+		return nil, diags
 	}
-
-	return map[string][]byte{"Main.yaml": w.Bytes()}, g.diags, err
+	return w.Bytes(), nil
 }
 
 func GenerateProject(directory string, project workspace.Project, program *pcl.Program, localDependencies map[string]string) error {
@@ -67,6 +95,12 @@ func GenerateProject(directory string, project workspace.Project, program *pcl.P
 
 	// Set the runtime to "yaml" then marshal to Pulumi.yaml
 	project.Runtime = workspace.NewProjectRuntimeInfo("yaml", nil)
+	// The program's components are generated into a plugin beside it, which the project depends on.
+	if _, ok := files[path.Join(componentsPackage, "PulumiPlugin.yaml")]; ok {
+		project.AddPackage(componentsPackage, workspace.PackageSpec{
+			Source: "./" + path.Join(project.Main, componentsPackage),
+		})
+	}
 	projectBytes, err := enc.YAML.Marshal(project)
 	if err != nil {
 		return err
@@ -88,7 +122,11 @@ func GenerateProject(directory string, project workspace.Project, program *pcl.P
 
 	for filename, data := range files {
 		outPath := path.Join(directory, filename)
-		err := os.WriteFile(outPath, data, 0o600)
+		err := os.MkdirAll(path.Dir(outPath), 0o700)
+		if err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+		err = os.WriteFile(outPath, data, 0o600)
 		if err != nil {
 			return fmt.Errorf("write output program: %w", err)
 		}
@@ -114,6 +152,21 @@ type generator struct {
 	variables []syn.ObjectPropertyDef
 	outputs   []syn.ObjectPropertyDef
 	pulumi    []syn.ObjectPropertyDef
+
+	// loops holds a scope for each for expression enclosing the expression being generated,
+	// innermost last. A scope maps the loop's variables to their path from `range`.
+	loops []map[model.Traversable]string
+
+	// components holds the definitions of the local components used by the program. It is shared
+	// with the generators of the components' own programs, which may use further components.
+	components *componentDefinitions
+}
+
+// componentDefinitions holds the components of a PulumiPlugin.yaml.
+type componentDefinitions struct {
+	// dirs maps the name of each component to the directory of the PCL program it was generated from.
+	dirs        map[string]string
+	definitions []syn.ObjectPropertyDef
 }
 
 func (g *generator) UnifyOutput() syn.Node {
@@ -175,6 +228,8 @@ func (g *generator) genNode(n pcl.Node) {
 		g.genOutputVariable(n)
 	case *pcl.PulumiBlock:
 		g.genPulumi(n)
+	case *pcl.Component:
+		g.genComponent(n)
 	default:
 		panic(fmt.Sprintf("Not implemented yet: %T", n))
 	}
@@ -324,6 +379,9 @@ func (g *generator) genResourceOpts(opts *pcl.ResourceOptions) *syn.ObjectNode {
 			g.expr(opts.PluginDownloadURL)))
 	}
 
+	if len(rOpts) == 0 {
+		return nil
+	}
 	return syn.Object(rOpts...)
 }
 
@@ -359,6 +417,9 @@ func (g *generator) genResource(n *pcl.Resource) {
 	if len(properties) > 0 {
 		entries = append(entries, syn.ObjectProperty(syn.String("properties"), syn.Object(properties...)))
 	}
+	if n.Options != nil && n.Options.Range != nil {
+		entries = append(entries, g.genRange(n.Name(), n.Options.Range))
+	}
 	if opts := g.genResourceOpts(n.Options); opts != nil {
 		entries = append(entries, syn.ObjectProperty(syn.String("options"), opts))
 	}
@@ -368,6 +429,102 @@ func (g *generator) genResource(n *pcl.Resource) {
 		syn.StringSyntax(trivia(n.Definition), n.Name()), r))
 }
 
+// genRange generates the entry that declares the resource with the given name once for each
+// element of a PCL range.
+func (g *generator) genRange(name string, rng model.Expression) syn.ObjectPropertyDef {
+	if dep := resourceDependency(rng); dep != "" {
+		YAMLError{
+			kind: "Unsupported Range",
+			detail: fmt.Sprintf("The range of %s depends on the outputs of %s, which are not known until it is created.\n"+
+				"Pulumi YAML registers one resource for each element of a range, so the range must be known"+
+				" when the program is evaluated.", name, dep),
+			rng: rng.SyntaxNode().Range(),
+		}.AppendTo(g)
+	}
+	// A PCL range is a condition when it is a boolean, a count when it is a number, and
+	// otherwise iterates over the elements of a list or map.
+	// The range of a component is not converted to its type when it is bound, so it may be a
+	// constant.
+	typ := model.ResolveOutputs(rng.Type())
+	switch {
+	case model.InputType(model.BoolType).ConversionFrom(typ) == model.SafeConversion:
+		return syn.ObjectProperty(syn.String("condition"), g.expr(rng))
+	case model.InputType(model.NumberType).ConversionFrom(typ) == model.SafeConversion:
+		return syn.ObjectProperty(syn.String("count"), g.expr(rng))
+	default:
+		return syn.ObjectProperty(syn.String("forEach"), g.expr(rng))
+	}
+}
+
+// genComponent generates a resource for an instance of a local component. The component itself is
+// generated into the PulumiPlugin.yaml of the components plugin the first time it is used.
+func (g *generator) genComponent(n *pcl.Component) {
+	name := path.Base(n.DirPath())
+	switch dir, ok := g.components.dirs[name]; {
+	case !ok:
+		g.components.dirs[name] = n.DirPath()
+		g.genComponentDefinition(name, n.Program)
+	case dir != n.DirPath():
+		YAMLError{
+			kind: "Duplicate Component",
+			detail: fmt.Sprintf("The components in %s and %s are both named %s, but the components of a"+
+				" Pulumi YAML plugin must have unique names.", dir, n.DirPath(), name),
+			rng: n.SyntaxNode().Range(),
+		}.AppendTo(g)
+		return
+	}
+
+	properties := make([]syn.ObjectPropertyDef, len(n.Inputs))
+	for i, input := range n.Inputs {
+		properties[i] = syn.ObjectProperty(syn.StringSyntax(trivia(input), input.Name), g.expr(input.Value))
+	}
+
+	entries := []syn.ObjectPropertyDef{
+		g.TypeProperty(componentsPackage + ":index:" + name),
+	}
+	if n.Name() != n.LogicalName() {
+		entries = append(entries, syn.ObjectProperty(syn.String("name"), syn.String(n.LogicalName())))
+	}
+	if len(properties) > 0 {
+		entries = append(entries, syn.ObjectProperty(syn.String("properties"), syn.Object(properties...)))
+	}
+	if n.Options != nil && n.Options.Range != nil {
+		entries = append(entries, g.genRange(n.Name(), n.Options.Range))
+	}
+	if opts := g.genResourceOpts(n.Options); opts != nil {
+		entries = append(entries, syn.ObjectProperty(syn.String("options"), opts))
+	}
+
+	g.resources = append(g.resources, syn.ObjectProperty(
+		syn.StringSyntax(trivia(n.Definition), n.Name()), syn.Object(entries...)))
+}
+
+// genComponentDefinition generates the definition of a component from its PCL program, whose config
+// variables are the component's inputs.
+func (g *generator) genComponentDefinition(name string, program *pcl.Program) {
+	c := generator{components: g.components}
+	for _, n := range program.Nodes {
+		c.genNode(n)
+	}
+	g.diags = g.diags.Extend(c.diags)
+
+	var entries []syn.ObjectPropertyDef
+	if len(c.config) > 0 {
+		entries = append(entries, syn.ObjectProperty(syn.String("inputs"), syn.Object(c.config...)))
+	}
+	if len(c.resources) > 0 {
+		entries = append(entries, syn.ObjectProperty(syn.String("resources"), syn.Object(c.resources...)))
+	}
+	if len(c.variables) > 0 {
+		entries = append(entries, syn.ObjectProperty(syn.String("variables"), syn.Object(c.variables...)))
+	}
+	if len(c.outputs) > 0 {
+		entries = append(entries, syn.ObjectProperty(syn.String("outputs"), syn.Object(c.outputs...)))
+	}
+	g.components.definitions = append(g.components.definitions,
+		syn.ObjectProperty(syn.String(name), syn.Object(entries...)))
+}
+
 func (g *generator) genOutputVariable(n *pcl.OutputVariable) {
 	k := syn.StringSyntax(trivia(n.Definition), n.LogicalName())
 	v := g.expr(n.Value)
@@ -706,6 +863,9 @@ func (g *generator) expr(e model.Expression) syn.Node {
 		}
 
 	case *model.UnaryOpExpression:
+		if e.Operation == hclsyntax.OpLogicalNot {
+			return wrapFn("not", g.expr(e.Operand))
+		}
 		if e.Operation == hclsyntax.OpNegate {
 			operand := e.Operand
 			switch operand := operand.(type) {
@@ -716,15 +876,20 @@ func (g *generator) expr(e model.Expression) syn.Node {
 					return syn.Number(-f)
 				}
 			}
+			return wrapFn("sub", syn.List(syn.Number(0), g.expr(operand)))
 		}
 
 		YAMLError{
 			kind:   "Unsupported unary operation",
-			detail: fmt.Sprintf("Invalid unary application encountered (only negation of numeric literals is supported at present): %v", e),
+			detail: fmt.Sprintf("Invalid unary application encountered: %v", e),
 			rng:    e.SyntaxNode().Range(),
 		}.AppendTo(g)
 		return syn.String("Unimplemented")
 
+	case *model.BinaryOpExpression:
+		return g.binaryOp(e)
+	case *model.ConditionalExpression:
+		return wrapFn("if", syn.List(g.expr(e.Condition), g.expr(e.TrueResult), g.expr(e.FalseResult)))
 	case *model.FunctionCallExpression:
 		return g.function(e)
 	case *model.RelativeTraversalExpression:
@@ -744,8 +909,14 @@ func (g *generator) expr(e model.Expression) syn.Node {
 			}
 			return g.function(f)
 		}
-		// This generally means a lookup scoped to a for loop. Since we don't do
-		// those, we don't process this type of RelativeTraversalExpressions.
+		// The items of a splat are traversed relative to the anonymous item.
+		if source, ok := e.Source.(*model.ScopeTraversalExpression); ok {
+			root := strings.TrimSuffix(strings.TrimPrefix(g.expr(source).String(), "${"), "}")
+			traversal := g.Traversal(e.Traversal, append([]model.Traversable{source.Type()}, e.Parts...)...)
+			traversal.root = root
+			return syn.String(fmt.Sprintf("${%s}", traversal))
+		}
+		// Otherwise, we don't process this type of RelativeTraversalExpressions.
 		YAMLError{
 			kind: "Unsupported Expression",
 			detail: "This use of a RelativeTraversalExpression is not supported in YAML.\n" +
@@ -756,8 +927,12 @@ func (g *generator) expr(e model.Expression) syn.Node {
 		return syn.String("Unimplemented Expression")
 
 	case *model.ScopeTraversalExpression:
-		rootName := e.RootName
-		traversal := g.Traversal(e.Traversal, e.Parts...).WithRoot(rootName, e.Tokens.Root.Range().Ptr())
+		traversal := g.Traversal(e.Traversal, e.Parts...)
+		if path, ok := g.loopVariable(e); ok {
+			traversal.root = path
+		} else {
+			traversal = traversal.WithRoot(e.RootName, e.Tokens.Root.Range().Ptr())
+		}
 		s := fmt.Sprintf("${%s}", traversal)
 		return syn.String(s)
 
@@ -802,23 +977,17 @@ func (g *generator) expr(e model.Expression) syn.Node {
 		return syn.Object(entries...)
 
 	case *model.SplatExpression:
-		YAMLError{
-			kind: "Splat",
-			detail: "A 'Splat' expression is equivalent in expressive power to a 'for loop', and is not representable in YAML.\n" +
-				"If the values of the function are known, you can manually unroll the loop," +
-				" otherwise it is necessary to switch to a more expressive language.",
-			rng: e.Syntax.Range(),
-		}.AppendTo(g)
-		return syn.String("Splat not implemented")
+		// `xs[*].name` is `[for x in xs : x.name]`.
+		in := g.expr(e.Source)
+		each := g.loop(map[model.Traversable]string{e.Item: "range.value"}, func() syn.Node {
+			return g.expr(e.Each)
+		})
+		return wrapFn("for", syn.Object(
+			syn.ObjectProperty(syn.String("in"), in),
+			syn.ObjectProperty(syn.String("value"), each),
+		))
 	case *model.ForExpression:
-		YAMLError{
-			kind: "For",
-			detail: "Pulumi YAML cannot represent for loops." +
-				"If the values of the for loop are known, you can manually unroll the loop," +
-				" otherwise it is necessary to switch to a more expressive language.",
-			rng: e.Syntax.Range(),
-		}.AppendTo(g)
-		return syn.String("For not implemented")
+		return g.forExpr(e)
 	default:
 		YAMLError{
 			kind:   fmt.Sprintf("%T", e),
@@ -829,6 +998,201 @@ func (g *generator) expr(e model.Expression) syn.Node {
 	}
 }
 
+// forExpr lowers a PCL for expression to fn::for. The loop's key and value variables are
+// `range.key` and `range.value` in the body, and the variable of a loop over `entries(x)` is
+// `range` itself, since fn::for iterates over the same pairs.
+func (g *generator) forExpr(e *model.ForExpression) syn.Node {
+	if e.Group {
+		YAMLError{
+			kind:   "Unsupported For",
+			detail: "fn::for cannot group the values of a for expression by key.",
+			rng:    e.Syntax.Range(),
+		}.AppendTo(g)
+		return syn.String("Unimplemented")
+	}
+
+	collection := e.Collection
+	scope := map[model.Traversable]string{e.ValueVariable: "range.value"}
+	if f, ok := collection.(*model.FunctionCallExpression); ok && f.Name == "entries" && e.KeyVariable == nil {
+		collection, scope[e.ValueVariable] = f.Args[0], pulumiyaml.RangeVarName
+	} else if e.KeyVariable != nil {
+		scope[e.KeyVariable] = "range.key"
+	}
+
+	entries := []syn.ObjectPropertyDef{syn.ObjectProperty(syn.String("in"), g.expr(collection))}
+	g.loop(scope, func() syn.Node {
+		if e.Key != nil {
+			entries = append(entries, syn.ObjectProperty(syn.String("key"), g.expr(e.Key)))
+		}
+		entries = append(entries, syn.ObjectProperty(syn.String("value"), g.expr(e.Value)))
+		if e.Condition != nil {
+			entries = append(entries, syn.ObjectProperty(syn.String("if"), g.expr(e.Condition)))
+		}
+		return nil
+	})
+	return wrapFn("for", syn.Object(entries...))
+}
+
+// loop generates the body of a for expression within the given scope.
+func (g *generator) loop(scope map[model.Traversable]string, body func() syn.Node) syn.Node {
+	g.loops = append(g.loops, scope)
+	defer func() { g.loops = g.loops[:len(g.loops)-1] }()
+	return body()
+}
+
+// loopVariable returns the path from `range` of the loop variable x refers to, if any. Within
+// fn::for, `range` only refers to the innermost loop, so the variables of enclosing loops and a
+// resource's own range cannot be referenced.
+func (g *generator) loopVariable(x *model.ScopeTraversalExpression) (string, bool) {
+	if len(x.Parts) == 0 || len(g.loops) == 0 {
+		return "", false
+	}
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		path, ok := g.loops[i][x.Parts[0]]
+		if !ok {
+			continue
+		}
+		if i != len(g.loops)-1 {
+			YAMLError{
+				kind: "Unsupported For",
+				detail: fmt.Sprintf("'%s' is a variable of an enclosing for expression, but within fn::for "+
+					"range only refers to the innermost loop.", x.RootName),
+				rng: x.Syntax.Range(),
+			}.AppendTo(g)
+		}
+		return path, true
+	}
+	if x.RootName == pulumiyaml.RangeVarName {
+		YAMLError{
+			kind:   "Unsupported For",
+			detail: "The range of a resource cannot be referenced within a for expression, where range refers to the loop.",
+			rng:    x.Syntax.Range(),
+		}.AppendTo(g)
+	}
+	return "", false
+}
+
+// resourceDependency returns the name of a resource that x refers to, if any.
+func resourceDependency(x model.Expression) string {
+	var dep string
+	_, diags := model.VisitExpression(x, model.IdentityVisitor, func(x model.Expression) (model.Expression, hcl.Diagnostics) {
+		if traversal, ok := x.(*model.ScopeTraversalExpression); ok && dep == "" && len(traversal.Parts) > 0 {
+			switch r := traversal.Parts[0].(type) {
+			case *pcl.Resource:
+				dep = r.Name()
+			case *pcl.Component:
+				dep = r.Name()
+			}
+		}
+		return x, nil
+	})
+	contract.Assertf(len(diags) == 0, "unexpected diagnostics: %v", diags)
+	return dep
+}
+
+// binaryOpBuiltins maps PCL binary operations to the builtin that implements them.
+var binaryOpBuiltins = map[*hclsyntax.Operation]string{
+	hclsyntax.OpLogicalAnd:         "and",
+	hclsyntax.OpLogicalOr:          "or",
+	hclsyntax.OpEqual:              "equals",
+	hclsyntax.OpNotEqual:           "notEquals",
+	hclsyntax.OpLessThan:           "lessThan",
+	hclsyntax.OpLessThanOrEqual:    "lessThanOrEqual",
+	hclsyntax.OpGreaterThan:        "greaterThan",
+	hclsyntax.OpGreaterThanOrEqual: "greaterThanOrEqual",
+	hclsyntax.OpAdd:                "add",
+	hclsyntax.OpSubtract:           "sub",
+	hclsyntax.OpMultiply:           "mul",
+	hclsyntax.OpDivide:             "div",
+	hclsyntax.OpModulo:             "mod",
+}
+
+// binaryOp lowers a PCL binary operation to a call to the matching builtin. Chains of the same
+// logical or arithmetic operation are flattened into a single call, since those builtins fold
+// their arguments from left to right.
+func (g *generator) binaryOp(e *model.BinaryOpExpression) syn.Node {
+	if match, ok := g.regexMatch(e); ok {
+		return match
+	}
+
+	name, ok := binaryOpBuiltins[e.Operation]
+	if !ok {
+		YAMLError{
+			kind:   "Unsupported binary operation",
+			detail: fmt.Sprintf("Pulumi YAML has no builtin for the binary operation in %v", e),
+			rng:    e.SyntaxNode().Range(),
+		}.AppendTo(g)
+		return syn.String("Unimplemented")
+	}
+
+	var folds, associative bool
+	switch e.Operation {
+	case hclsyntax.OpLogicalAnd, hclsyntax.OpLogicalOr, hclsyntax.OpAdd, hclsyntax.OpMultiply:
+		folds, associative = true, true
+	case hclsyntax.OpSubtract, hclsyntax.OpDivide, hclsyntax.OpModulo:
+		folds = true
+	}
+
+	// `(a - b) - c` is `fn::sub: [a, b, c]`, but `a - (b - c)` is not, so only associative
+	// operations are flattened on the right.
+	var operands func(x model.Expression, right bool) []syn.Node
+	operands = func(x model.Expression, right bool) []syn.Node {
+		if b, ok := x.(*model.BinaryOpExpression); ok && b.Operation == e.Operation && folds &&
+			(!right || associative) {
+			return append(operands(b.LeftOperand, false), operands(b.RightOperand, true)...)
+		}
+		return []syn.Node{g.expr(x)}
+	}
+	return wrapFn(name, syn.List(append(operands(e.LeftOperand, false), operands(e.RightOperand, true)...)...))
+}
+
+// regexMatch returns fn::regexMatch if e checks that the std function regexall finds a match, which is how the
+// importer converts fn::regexMatch.
+func (g *generator) regexMatch(e *model.BinaryOpExpression) (*syn.ObjectNode, bool) {
+	if e.Operation != hclsyntax.OpGreaterThan {
+		return nil, false
+	}
+	zero, ok := e.RightOperand.(*model.LiteralValueExpression)
+	if !ok || !zero.Value.Type().Equals(cty.Number) || !zero.Value.Equals(cty.Zero).True() {
+		return nil, false
+	}
+	length, ok := e.LeftOperand.(*model.FunctionCallExpression)
+	if !ok || length.Name != "length" || len(length.Args) != 1 {
+		return nil, false
+	}
+	result, ok := length.Args[0].(*model.RelativeTraversalExpression)
+	if !ok || len(result.Traversal) != 1 {
+		return nil, false
+	}
+	if attr, ok := result.Traversal[0].(hcl.TraverseAttr); !ok || attr.Name != "result" {
+		return nil, false
+	}
+	invoke, ok := result.Source.(*model.FunctionCallExpression)
+	if !ok || invoke.Name != pcl.Invoke || len(invoke.Args) != 2 {
+		return nil, false
+	}
+	if function, ok := stdFunction(pcl.LiteralValueString(invoke.Args[0])); !ok || function != "regexall" {
+		return nil, false
+	}
+	args, ok := invoke.Args[1].(*model.ObjectConsExpression)
+	if !ok || len(args.Items) != 2 {
+		return nil, false
+	}
+	var pattern, source model.Expression
+	for _, item := range args.Items {
+		switch pcl.LiteralValueString(item.Key) {
+		case "pattern":
+			pattern = item.Value
+		case "string":
+			source = item.Value
+		}
+	}
+	if pattern == nil || source == nil {
+		return nil, false
+	}
+	return wrapFn("regexMatch", syn.List(g.expr(pattern), g.expr(source))), true
+}
+
 // pclTypeToYAMLConfigType converts a PCL model.Type to a YAML config type string.
 // YAML uses angle-bracket syntax (e.g. "list<string>") while PCL uses parentheses
 // (e.g. "list(string)"). Output types are unwrapped since YAML represents secrets
@@ -921,6 +1285,18 @@ func (g *generator) function(f *model.FunctionCallExpression) syn.Node {
 		return wrapFn("join", syn.List(args...))
 	case "split":
 		return wrapFn("split", syn.List(g.expr(f.Args[0]), g.expr(f.Args[1])))
+	case "lookup":
+		args := []syn.Node{g.expr(f.Args[0]), g.expr(f.Args[1]), syn.Null()}
+		if len(f.Args) > 2 {
+			args[2] = g.expr(f.Args[2])
+		}
+		return wrapFn("lookup", syn.List(args...))
+	case "try":
+		args := make([]syn.Node, len(f.Args))
+		for i, arg := range f.Args {
+			args[i] = g.expr(arg)
+		}
+		return wrapFn(f.Name, syn.List(args...))
 	case "toBase64":
 		return wrapFn("toBase64", g.expr(f.Args[0]))
 	case "fromBase64":
@@ -945,6 +1321,17 @@ func (g *generator) function(f *model.FunctionCallExpression) syn.Node {
 		return wrapFn("unsecret", g.expr(f.Args[0]))
 	case "sha1":
 		return wrapFn("sha1", g.expr(f.Args[0]))
+	case "mimeType":
+		return wrapFn("mimeType", g.expr(f.Args[0]))
+	case "entries":
+		// entries(x) is the list of the pairs fn::for iterates over.
+		return wrapFn("for", syn.Object(
+			syn.ObjectProperty(syn.String("in"), g.expr(f.Args[0])),
+			syn.ObjectProperty(syn.String("value"), syn.Object(
+				syn.ObjectProperty(syn.String("key"), syn.String("${range.key}")),
+				syn.ObjectProperty(syn.String("value"), syn.String("${range.value}")),
+			)),
+		))
 	case "length":
 		return wrapFn("length", g.expr(f.Args[0]))
 	case "singleOrNone":
@@ -953,6 +1340,16 @@ func (g *generator) function(f *model.FunctionCallExpression) syn.Node {
 		return wrapFn("pulumiResourceName", g.expr(f.Args[0]))
 	case "pulumiResourceType":
 		return wrapFn("pulumiResourceType", g.expr(f.Args[0]))
+	case "min", "max":
+		args := make([]syn.Node, len(f.Args))
+		for i, arg := range f.Args {
+			args[i] = g.expr(arg)
+		}
+		if len(args) == 1 {
+			// fn::min and fn::max take at least two values, and the extremum of one is itself.
+			return args[0]
+		}
+		return wrapFn(f.Name, syn.List(args...))
 	case "cwd":
 		return syn.String("${pulumi.cwd}")
 	case "getOutput":
@@ -1005,7 +1402,13 @@ type Invoke struct {
 func (g *generator) MustInvoke(f *model.FunctionCallExpression, ret string) *syn.ObjectNode {
 	contract.Assertf(f.Name == pcl.Invoke, "MustInvoke called on non-invoke function: %v", f.Name)
 	contract.Assertf(len(f.Args) > 0, "Invoke called with no arguments: %v", f.Name)
-	name := collapseToken(g.expr(f.Args[0]).(*syn.StringNode).Value())
+	token := g.expr(f.Args[0]).(*syn.StringNode).Value()
+	if ret == "result" && len(f.Args) == 2 {
+		if builtin, ok := g.stdBuiltin(token, f.Args[1]); ok {
+			return builtin
+		}
+	}
+	name := collapseToken(token)
 
 	var arguments syn.Node
 	if len(f.Args) > 1 {
@@ -1053,6 +1456,151 @@ func (g *generator) MustInvoke(f *model.FunctionCallExpression, ret string) *syn
 	return wrapFn("invoke", syn.Object(properties...))
 }
 
+// stdBuiltins maps the functions of the std package that compute the same result as a builtin to the builtin and the
+// names of the function's arguments, in the order the builtin takes them.
+var stdBuiltins = map[string]struct {
+	builtin string
+	params  []string
+}{
+	"upper":      {"upper", []string{"input"}},
+	"lower":      {"lower", []string{"input"}},
+	"trimspace":  {"trim", []string{"input"}},
+	"merge":      {"merge", []string{"input"}},
+	"keys":       {"keys", []string{"input"}},
+	"values":     {"values", []string{"input"}},
+	"flatten":    {"flatten", []string{"input"}},
+	"concat":     {"concat", []string{"input"}},
+	"distinct":   {"distinct", []string{"input"}},
+	"jsondecode": {"fromJSON", []string{"input"}},
+	"contains":   {"contains", []string{"input", "element"}},
+	"substr":     {"substring", []string{"input", "offset", "length"}},
+	"format":     {"format", []string{"input", "args"}},
+	"replace":    {"replace", []string{"text", "search", "replace"}},
+	"regexall":   {"regexFind", []string{"pattern", "string"}},
+}
+
+// stdBuiltin returns the builtin that computes the result of invoking the std function token with args, if there is
+// one. The importer converts the builtins that PCL lacks to such invokes, so they are converted back here.
+func (g *generator) stdBuiltin(token string, args model.Expression) (*syn.ObjectNode, bool) {
+	function, ok := stdFunction(token)
+	if !ok {
+		return nil, false
+	}
+	fn, ok := stdBuiltins[function]
+	if !ok {
+		return nil, false
+	}
+	obj, ok := args.(*model.ObjectConsExpression)
+	if !ok || len(obj.Items) != len(fn.params) {
+		return nil, false
+	}
+	values := make([]model.Expression, len(fn.params))
+	for _, item := range obj.Items {
+		i := slices.Index(fn.params, pcl.LiteralValueString(item.Key))
+		if i < 0 {
+			return nil, false
+		}
+		values[i] = item.Value
+	}
+
+	switch function {
+	case "format":
+		// fn::format supports fewer verbs than std format, so only a literal format string can be checked.
+		format, ok := literalString(values[0])
+		if !ok || !isBuiltinFormat(format) {
+			return nil, false
+		}
+		// std format takes its values as a list, and fn::format takes them after the format string.
+		tuple, ok := values[1].(*model.TupleConsExpression)
+		if !ok {
+			return nil, false
+		}
+		values = append(values[:1], tuple.Expressions...)
+	case "substr":
+		// std substr counts a negative offset from the end of the string, which fn::substring does not.
+		offset, ok := values[1].(*model.LiteralValueExpression)
+		if !ok || !offset.Value.Type().Equals(cty.Number) || offset.Value.IsNull() {
+			return nil, false
+		}
+		if n, _ := offset.Value.AsBigFloat().Float64(); n < 0 {
+			return nil, false
+		}
+	case "replace":
+		// std replace treats a search string wrapped in slashes as a regular expression, so a search string that
+		// is not a literal may be one when the program runs.
+		search, ok := literalString(values[1])
+		if !ok {
+			return nil, false
+		}
+		if len(search) >= 2 && strings.HasPrefix(search, "/") && strings.HasSuffix(search, "/") {
+			pattern := strings.ReplaceAll(search[1:len(search)-1], "${", "$${")
+			return wrapFn("regexReplace", syn.List(syn.String(pattern), g.expr(values[0]), g.expr(values[2]))), true
+		}
+	case "regexall":
+		// regexall returns the capture groups of each match rather than the whole match that fn::regexFind returns.
+		pattern, ok := literalString(values[0])
+		if !ok {
+			return nil, false
+		}
+		if re, err := regexp.Compile(pattern); err != nil || re.NumSubexp() > 0 {
+			return nil, false
+		}
+	}
+
+	nodes := make([]syn.Node, len(values))
+	for i, v := range values {
+		nodes[i] = g.expr(v)
+	}
+	if len(nodes) == 1 {
+		return wrapFn(fn.builtin, nodes[0]), true
+	}
+	return wrapFn(fn.builtin, syn.List(nodes...)), true
+}
+
+// isBuiltinFormat returns true if format only uses the verbs that fn::format supports: %s, %q, %v, %d,
+// %f, %e, %g and %%, with flags, width and precision but without explicit argument indexes.
+func isBuiltinFormat(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j == len(format) || strings.IndexByte("sqvdfeg%", format[j]) < 0 {
+			return false
+		}
+		i = j
+	}
+	return true
+}
+
+// stdFunction returns the name of the std function that token refers to, if it does. The token may be written
+// std:index:upper or, once bound, std::upper.
+func stdFunction(token string) (string, bool) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 || parts[0] != "std" || (parts[1] != "index" && parts[1] != "") {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// literalString returns the value of x if it is a literal string.
+func literalString(x model.Expression) (string, bool) {
+	switch x := x.(type) {
+	case *model.LiteralValueExpression:
+		return pcl.LiteralValueString(x), x.Value.Type().Equals(cty.String)
+	case *model.TemplateExpression:
+		if len(x.Parts) == 1 {
+			return literalString(x.Parts[0])
+		}
+		return "", len(x.Parts) == 0
+	default:
+		return "", false
+	}
+}
+
 func (g *generator) TypeProperty(s string) syn.ObjectPropertyDef {
 	return syn.ObjectProperty(syn.String("type"), syn.String(s))
 }
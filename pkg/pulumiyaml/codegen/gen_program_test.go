@@ -189,14 +189,12 @@ func TestGenerateProgram(t *testing.T) {
 				// https://github.com/pulumi/pulumi-yaml/issues/229
 			case "azure-sa":
 				// Reason: has dependencies between config variables
-			case "aws-eks", "aws-s3-folder", "simple-splat":
-				// Reason: missing splat
-				//
-				// Note: aws-s3-folder errors with
-				// 14,27-52: the asset parameter must be a string literal; the asset parameter must be a string literal
-				// But the actual error is that it is using a Splat operator.
+			case "aws-eks", "aws-s3-folder":
+				// Reason: aws-eks refers to the instances of a ranged resource as a list, and
+				// aws-s3-folder ranges over readDir, neither of which Pulumi YAML has.
 			case "components":
-				// https://github.com/pulumi/pulumi-yaml/issues/476
+				// Reason: exampleComponent indexes a list with the range of a resource, which
+				// Pulumi YAML cannot express.
 			case "unknown-resource":
 				// https://github.com/pulumi/pulumi-yaml/issues/478
 			case "optional-complex-config":
@@ -210,12 +208,11 @@ func TestGenerateProgram(t *testing.T) {
 				// Reason: A python only test.
 			case "csharp-invoke-options":
 				// Reason: C# only test.
-			case "simple-range", "entries-function",
-				"iterating-optional-range-expressions",
-				"invoke-inside-conditional-range":
-				// Pulumi YAML does not support ranges
-			case "dynamic-entries", "csharp-typed-for-expressions":
-				// Pulumi YAML does not support for loops.
+			case "iterating-optional-range-expressions":
+				// Reason: ranges over resource outputs, which Pulumi YAML must know when the program
+				// is evaluated.
+			case "invoke-inside-conditional-range":
+				// Reason: invokes the std provider, which the test plugin context does not load.
 			case "read-file-func", "python-regress-10914", "unknown-invoke":
 				tt.SkipCompile = codegen.NewStringSet("yaml")
 				l = append(l, tt)
@@ -275,6 +272,18 @@ func TestGenerateProgram(t *testing.T) {
 				Description:   "Negative literals in Pulumi Programs",
 				PluginContext: newPluginContext(),
 			},
+			test.ProgramTest{
+				Directory:     "for-expressions",
+				Description:   "For expressions, splats and ranges in Pulumi Programs",
+				PluginContext: newPluginContext(),
+			},
+			test.ProgramTest{
+				Directory:   "local-components",
+				Description: "Local components are generated into a plugin",
+				// The check cannot load the plugin the components are generated into.
+				SkipCompile:   codegen.NewStringSet("yaml"),
+				PluginContext: newPluginContext(),
+			},
 		),
 	})
 }
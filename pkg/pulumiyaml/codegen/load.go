@@ -6,13 +6,15 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"maps"
 	"reflect"
+	"regexp"
 	"slices"
-	"sort"
 	"strings"
 
 	"github.com/blang/semver"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/pulumi/pulumi/pkg/v3/codegen"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/model"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
@@ -45,6 +47,29 @@ type importer struct {
 	// passes unknown symbols through as scope traversals without diagnosing them — the
 	// snippet's eventual PCL binder is responsible for resolving (or rejecting) them.
 	snippet bool
+
+	// ranged is set while importing a resource declared with count or forEach, within which
+	// `range` refers to PCL's range variable.
+	ranged bool
+
+	// loops holds the variable of each fn::for enclosing the expression being imported, innermost
+	// last. Within a loop, `range` refers to its variable, which holds the current key and value.
+	loops []*model.Variable
+
+	// PCL has no user-defined functions, so calls to the template's functions are inlined. While
+	// a function's body is imported, arguments holds the expressions its parameters are bound to
+	// and inlining holds the functions being imported, so that recursion is rejected.
+	functions ast.FunctionsMapDecl
+	arguments map[string]argument
+	inlining  map[string]bool
+
+	// mappings holds the template's mappings, which fn::findInMap reads. PCL has no equivalent, so lookups are
+	// imported as indexes into the mapping's literal value.
+	mappings ast.MappingsMapDecl
+
+	// names records the identifier assigned to each declaration of the template, in the order they were
+	// assigned.
+	names []Name
 }
 
 type packageInfo struct {
@@ -63,6 +88,12 @@ var null = &model.Variable{
 func (imp *importer) importRef(node ast.Expr, name string, environment map[string]model.Expression, isAccess bool, hint schema.Type) (model.Expression, syntax.Diagnostics) {
 	// `pulumi` is not a real variable, so it doesn't make sense to look it up.
 	contract.Assertf(name != pulumiyaml.PulumiVarName, "%[1]T: %[1]v", node)
+	if arg, ok := imp.arguments[name]; ok {
+		return imp.importArgument(arg), nil
+	}
+	if name == pulumiyaml.RangeVarName && len(imp.loops) > 0 {
+		return model.VariableReference(imp.loops[len(imp.loops)-1]), nil
+	}
 	if v, ok := imp.configuration[name]; ok {
 		return model.VariableReference(v), nil
 	}
@@ -94,7 +125,7 @@ func (imp *importer) importRef(node ast.Expr, name string, environment map[strin
 		Traversal: hcl.Traversal{hcl.TraverseRoot{Name: camel(makeLegalIdentifier(name))}},
 		Parts:     []model.Traversable{model.DynamicType},
 	}
-	if imp.snippet {
+	if imp.snippet || imp.ranged && name == pulumiyaml.RangeVarName {
 		return traversal, nil
 	}
 	return traversal, syntax.Diagnostics{ast.ExprError(node, fmt.Sprintf("unknown config, variable, or resource '%v'", name), "")}
@@ -264,6 +295,380 @@ func (imp *importer) importSplit(node *ast.SplitExpr) (model.Expression, syntax.
 	return call, diags
 }
 
+// comparisonOperations maps each comparison builtin to its PCL binary operation.
+var comparisonOperations = map[ast.ComparisonOperator]*hclsyntax.Operation{
+	ast.Equals:             hclsyntax.OpEqual,
+	ast.NotEquals:          hclsyntax.OpNotEqual,
+	ast.LessThan:           hclsyntax.OpLessThan,
+	ast.LessThanOrEqual:    hclsyntax.OpLessThanOrEqual,
+	ast.GreaterThan:        hclsyntax.OpGreaterThan,
+	ast.GreaterThanOrEqual: hclsyntax.OpGreaterThanOrEqual,
+}
+
+// arithmeticOperations maps each arithmetic builtin other than fn::min and fn::max to its PCL
+// binary operation.
+var arithmeticOperations = map[ast.ArithmeticOperator]*hclsyntax.Operation{
+	ast.Add:      hclsyntax.OpAdd,
+	ast.Subtract: hclsyntax.OpSubtract,
+	ast.Multiply: hclsyntax.OpMultiply,
+	ast.Divide:   hclsyntax.OpDivide,
+	ast.Modulo:   hclsyntax.OpModulo,
+}
+
+// importBinaryOp imports the operands of a builtin as a left-associative chain of PCL binary
+// operations, so that `[a, b, c]` becomes `(a op b) op c`.
+func (imp *importer) importBinaryOp(op *hclsyntax.Operation, operands []ast.Expr, hint schema.Type) (model.Expression, syntax.Diagnostics) {
+	var diags syntax.Diagnostics
+	var result model.Expression
+	for _, operand := range operands {
+		x, xdiags := imp.importExpr(operand, hint)
+		diags.Extend(xdiags...)
+		if result == nil {
+			result = x
+			continue
+		}
+		result = &model.BinaryOpExpression{
+			LeftOperand:  result,
+			Operation:    op,
+			RightOperand: x,
+		}
+	}
+	return result, diags
+}
+
+// importFunctionArgs imports a builtin as a call to the named PCL function, passing each of the
+// builtin's arguments in order.
+func (imp *importer) importFunctionArgs(name string, args []ast.Expr, hint schema.Type) (model.Expression, syntax.Diagnostics) {
+	var diags syntax.Diagnostics
+	exprs := make([]model.Expression, len(args))
+	for i, arg := range args {
+		x, xdiags := imp.importExpr(arg, hint)
+		diags.Extend(xdiags...)
+		exprs[i] = x
+	}
+	return &model.FunctionCallExpression{
+		Name: name,
+		Args: exprs,
+	}, diags
+}
+
+// stdInvoke returns a call to the function of the std package named function, which is given args by
+// name. The call evaluates to the function's result.
+func stdInvoke(function string, args ...model.ObjectConsItem) model.Expression {
+	return relativeTraversal(&model.FunctionCallExpression{
+		Name: pcl.Invoke,
+		Args: []model.Expression{
+			quotedLit("std:index:" + function),
+			&model.ObjectConsExpression{Items: args},
+		},
+	}, "result")
+}
+
+// importStdInvoke imports a builtin as a call to the function of the std package that computes the
+// same result. The builtin's arguments are passed to the function as the arguments named params.
+func (imp *importer) importStdInvoke(
+	function string, params []string, args []ast.Expr, hint schema.Type,
+) (model.Expression, syntax.Diagnostics) {
+	var diags syntax.Diagnostics
+	items := make([]model.ObjectConsItem, len(args))
+	for i, arg := range args {
+		x, xdiags := imp.importExpr(arg, hint)
+		diags.Extend(xdiags...)
+		items[i] = model.ObjectConsItem{Key: plainLit(params[i]), Value: x}
+	}
+	return stdInvoke(function, items...), diags
+}
+
+// importReplace imports `fn::replace` as an invoke of std `replace`. `fn::replace` replaces its search string
+// literally, but std treats a search string wrapped in slashes, such as `/tmp/`, as a regular expression. Such a
+// search string is imported as a regular expression that matches it literally, wrapped in slashes, and `$` in the
+// replacement is escaped so that it is not taken to refer to a capture group. A search string that is not a literal
+// may be wrapped in slashes when the program runs, so it is imported as it is, with a warning.
+func (imp *importer) importReplace(node *ast.ReplaceExpr) (model.Expression, syntax.Diagnostics) {
+	lit, ok := node.Search.(*ast.StringExpr)
+	if !ok {
+		expr, diags := imp.importStdInvoke("replace", []string{"text", "search", "replace"},
+			[]ast.Expr{node.Source, node.Search, node.Replacement}, schema.StringType)
+		diags.Extend(exprWarning(node.Search, "fn::replace is converted to std replace",
+			"std replace treats a search string wrapped in slashes as a regular expression; check that the "+
+				"search string is never wrapped in slashes"))
+		return expr, diags
+	}
+	if len(lit.Value) < 2 || !strings.HasPrefix(lit.Value, "/") || !strings.HasSuffix(lit.Value, "/") {
+		return imp.importStdInvoke("replace", []string{"text", "search", "replace"},
+			[]ast.Expr{node.Source, node.Search, node.Replacement}, schema.StringType)
+	}
+
+	var diags syntax.Diagnostics
+	source, sdiags := imp.importExpr(node.Source, schema.StringType)
+	diags.Extend(sdiags...)
+	var replacement model.Expression
+	if rlit, ok := node.Replacement.(*ast.StringExpr); ok {
+		replacement = quotedLit(strings.ReplaceAll(rlit.Value, "$", "$$"))
+	} else {
+		var rdiags syntax.Diagnostics
+		replacement, rdiags = imp.importExpr(node.Replacement, schema.StringType)
+		diags.Extend(rdiags...)
+		diags.Extend(exprWarning(node.Replacement, "fn::replace is converted to std replace",
+			"the search string is converted to a regular expression, so $ in the replacement refers to a capture "+
+				"group; check that the replacement never contains $"))
+	}
+	return stdInvoke("replace",
+		model.ObjectConsItem{Key: plainLit("text"), Value: source},
+		model.ObjectConsItem{Key: plainLit("search"), Value: quotedLit("/" + regexp.QuoteMeta(lit.Value) + "/")},
+		model.ObjectConsItem{Key: plainLit("replace"), Value: replacement}), diags
+}
+
+// importRegex imports a regex builtin as invokes of the std functions that apply a regular expression:
+//
+// - `fn::regexMatch` is imported as a check that `regexall` finds at least one match
+// - `fn::regexReplace` is imported as a call to `replace` with the pattern wrapped in slashes, which std
+// treats as a regular expression whose replacement may refer to capture groups
+// - `fn::regexFind` is imported as a call to `regexall`
+func (imp *importer) importRegex(node *ast.RegexExpr) (model.Expression, syntax.Diagnostics) {
+	var diags syntax.Diagnostics
+	pattern, pdiags := imp.importExpr(node.Pattern, schema.StringType)
+	diags.Extend(pdiags...)
+	source, sdiags := imp.importExpr(node.Source, schema.StringType)
+	diags.Extend(sdiags...)
+
+	// A literal pattern is checked here, as the template may not have been analysed before it is imported.
+	lit, isLit := node.Pattern.(*ast.StringExpr)
+	var re *regexp.Regexp
+	if isLit {
+		var err error
+		if re, err = regexp.Compile(lit.Value); err != nil {
+			diags.Extend(ast.ExprError(node.Pattern, fmt.Sprintf("invalid regular expression: %v", err), ""))
+			return nil, diags
+		}
+	}
+
+	switch node.Operator {
+	case ast.RegexMatch:
+		matches := stdInvoke("regexall",
+			model.ObjectConsItem{Key: plainLit("pattern"), Value: pattern},
+			model.ObjectConsItem{Key: plainLit("string"), Value: source})
+		return &model.BinaryOpExpression{
+			Operation: hclsyntax.OpGreaterThan,
+			LeftOperand: &model.FunctionCallExpression{
+				Name: "length",
+				Args: []model.Expression{matches},
+			},
+			RightOperand: &model.LiteralValueExpression{Value: cty.NumberIntVal(0)},
+		}, diags
+	case ast.RegexReplace:
+		replacement, rdiags := imp.importExpr(node.Replacement, schema.StringType)
+		diags.Extend(rdiags...)
+		search := &model.TemplateExpression{Parts: []model.Expression{plainLit("/"), pattern, plainLit("/")}}
+		if isLit {
+			search = quotedLit("/" + lit.Value + "/")
+		}
+		return stdInvoke("replace",
+			model.ObjectConsItem{Key: plainLit("text"), Value: source},
+			model.ObjectConsItem{Key: plainLit("search"), Value: search},
+			model.ObjectConsItem{Key: plainLit("replace"), Value: replacement}), diags
+	case ast.RegexFind:
+		if !isLit || re.NumSubexp() > 0 {
+			diags.Extend(exprWarning(node.Pattern, "fn::regexFind is converted to std regexall",
+				"regexall returns the capture groups of each match instead of the whole match when the pattern "+
+					"has capture groups; check that the converted program finds the same matches"))
+		}
+		return stdInvoke("regexall",
+			model.ObjectConsItem{Key: plainLit("pattern"), Value: pattern},
+			model.ObjectConsItem{Key: plainLit("string"), Value: source}), diags
+	default:
+		contract.Failf("unexpected regex operator %q", node.Operator)
+		return nil, nil
+	}
+}
+
+// importNotImplemented imports a builtin that has no equivalent in PCL as a call to `notImplemented`, and warns
+// that the call must be replaced by hand.
+func importNotImplemented(node ast.BuiltinExpr) (model.Expression, syntax.Diagnostics) {
+	name := node.Name().Value
+	call := &model.FunctionCallExpression{
+		Name: "notImplemented",
+		Args: []model.Expression{quotedLit(name)},
+	}
+	return call, syntax.Diagnostics{exprWarning(node, name+" has no equivalent in PCL",
+		"it is converted to a call to notImplemented, which must be replaced by hand")}
+}
+
+// exprWarning creates a warning-level diagnostic associated with the range of the given expression.
+func exprWarning(expr ast.Expr, summary, detail string) *syntax.Diagnostic {
+	diag := ast.ExprError(expr, summary, detail)
+	diag.Severity = hcl.DiagWarning
+	return diag
+}
+
+// importFor imports fn::for as a PCL for expression over the entries of its collection, so that
+// the loop variable has the same key and value properties as `range`.
+func (imp *importer) importFor(node *ast.ForExpr) (model.Expression, syntax.Diagnostics) {
+	var diags syntax.Diagnostics
+	in, idiags := imp.importExpr(node.In, nil)
+	diags.Extend(idiags...)
+
+	loop := &model.Variable{Name: pulumiyaml.RangeVarName, VariableType: model.DynamicType}
+	imp.loops = append(imp.loops, loop)
+	defer func() { imp.loops = imp.loops[:len(imp.loops)-1] }()
+
+	x := &model.ForExpression{
+		ValueVariable: loop,
+		Collection:    &model.FunctionCallExpression{Name: "entries", Args: []model.Expression{in}},
+	}
+	if node.Key != nil {
+		key, kdiags := imp.importExpr(node.Key, schema.StringType)
+		diags.Extend(kdiags...)
+		x.Key = key
+	}
+	value, vdiags := imp.importExpr(node.Value, nil)
+	diags.Extend(vdiags...)
+	x.Value = value
+	if node.If != nil {
+		cond, cdiags := imp.importExpr(node.If, schema.BoolType)
+		diags.Extend(cdiags...)
+		x.Condition = cond
+	}
+	return x, diags
+}
+
+// importCall inlines a call to one of the template's functions: the body is imported with each
+// parameter bound to the imported argument or default.
+func (imp *importer) importCall(node *ast.CallExpr) (model.Expression, syntax.Diagnostics) {
+	name := node.Function.Value
+	fn, ok := imp.functions.Get(name)
+	if !ok && node.IsShorthand() {
+		obj, diag := node.ReservedObject()
+		x, diags := imp.importExpr(obj, nil)
+		return x, append(syntax.Diagnostics{diag}, diags...)
+	}
+	if !ok {
+		return nil, syntax.Diagnostics{ast.ExprError(node.Function, fmt.Sprintf("function %q is not declared", name), "")}
+	}
+	if diag := node.ArgsError(); diag != nil {
+		return nil, syntax.Diagnostics{diag}
+	}
+	if imp.inlining[name] {
+		return nil, syntax.Diagnostics{ast.ExprError(node, fmt.Sprintf("function %q cannot call itself", name), "")}
+	}
+
+	var diags syntax.Diagnostics
+	args := map[string]argument{}
+	if node.CallArgs != nil {
+		for _, kvp := range node.CallArgs.Entries {
+			k, ok := kvp.Key.(*ast.StringExpr)
+			if !ok {
+				diags.Extend(ast.ExprError(kvp.Key, "function argument names must be string literals", ""))
+				continue
+			}
+			// The argument is imported here for its diagnostics, and again wherever the body refers to it.
+			_, adiags := imp.importExpr(kvp.Value, nil)
+			diags.Extend(adiags...)
+			args[k.Value] = imp.newArgument(kvp.Value)
+		}
+	}
+
+	saved := imp.arguments
+	defer func() {
+		imp.arguments = saved
+		delete(imp.inlining, name)
+	}()
+	if imp.inlining == nil {
+		imp.inlining = map[string]bool{}
+	}
+	imp.arguments, imp.inlining[name] = args, true
+	for _, param := range fn.Parameters.Entries {
+		if _, ok := args[param.Key.Value]; ok {
+			continue
+		}
+		if param.Value == nil || param.Value.Default == nil {
+			diags.Extend(ast.ExprError(node, fmt.Sprintf("missing argument %q to function %q", param.Key.Value, name), ""))
+			continue
+		}
+		_, ddiags := imp.importExpr(param.Value.Default, nil)
+		diags.Extend(ddiags...)
+		args[param.Key.Value] = imp.newArgument(param.Value.Default)
+	}
+
+	body, bdiags := imp.importExpr(fn.Body, nil)
+	diags.Extend(bdiags...)
+	return body, diags
+}
+
+// An argument is the expression that a parameter of an inlined function is bound to, along with the scope of the
+// call. It is imported afresh wherever the function's body refers to the parameter, so that no node of the PCL tree
+// appears in more than one place.
+type argument struct {
+	expr      ast.Expr
+	arguments map[string]argument
+	loops     []*model.Variable
+	inlining  map[string]bool
+}
+
+// newArgument binds a parameter to x, which is evaluated in the current scope.
+func (imp *importer) newArgument(x ast.Expr) argument {
+	return argument{
+		expr:      x,
+		arguments: imp.arguments,
+		loops:     slices.Clone(imp.loops),
+		inlining:  maps.Clone(imp.inlining),
+	}
+}
+
+// importArgument imports arg in the scope of the call that bound it. Its diagnostics were reported by the call.
+func (imp *importer) importArgument(arg argument) model.Expression {
+	arguments, loops, inlining := imp.arguments, imp.loops, imp.inlining
+	defer func() { imp.arguments, imp.loops, imp.inlining = arguments, loops, inlining }()
+	imp.arguments, imp.loops, imp.inlining = arg.arguments, arg.loops, arg.inlining
+
+	x, _ := imp.importExpr(arg.expr, nil)
+	return x
+}
+
+// importFindInMap imports a lookup in one of the template's mappings. If both keys are literals, the lookup is
+// imported as the value it finds; otherwise it is imported as an index into the mapping.
+func (imp *importer) importFindInMap(node *ast.FindInMapExpr) (model.Expression, syntax.Diagnostics) {
+	mapping, ok := imp.mappings.Get(node.MapName.Value)
+	if !ok {
+		return nil, syntax.Diagnostics{ast.ExprError(node.MapName,
+			fmt.Sprintf("mapping %q is not declared", node.MapName.Value), "")}
+	}
+
+	if top, ok := node.TopLevelKey.(*ast.StringExpr); ok {
+		if second, ok := node.SecondLevelKey.(*ast.StringExpr); ok {
+			if inner, ok := objectEntry(mapping, top.Value).(*ast.ObjectExpr); ok {
+				if v := objectEntry(inner, second.Value); v != nil {
+					return imp.importExpr(v, nil)
+				}
+			}
+			return nil, syntax.Diagnostics{ast.ExprError(node, fmt.Sprintf("mapping %q has no key %q under %q",
+				node.MapName.Value, second.Value, top.Value), "")}
+		}
+	}
+
+	var diags syntax.Diagnostics
+	collection, cdiags := imp.importExpr(mapping, nil)
+	diags.Extend(cdiags...)
+	top, tdiags := imp.importExpr(node.TopLevelKey, nil)
+	diags.Extend(tdiags...)
+	second, sdiags := imp.importExpr(node.SecondLevelKey, nil)
+	diags.Extend(sdiags...)
+	return &model.IndexExpression{
+		Collection: &model.IndexExpression{Collection: collection, Key: top},
+		Key:        second,
+	}, diags
+}
+
+// objectEntry returns the value of the entry of obj with the given literal key, or nil if there is none.
+func objectEntry(obj *ast.ObjectExpr, key string) ast.Expr {
+	for _, kvp := range obj.Entries {
+		if k, ok := kvp.Key.(*ast.StringExpr); ok && k.Value == key {
+			return kvp.Value
+		}
+	}
+	return nil
+}
+
 // importFunctionCall imports a call to an AWS intrinsic function. The way the function is imported depends on the
 // function:
 //
@@ -272,6 +677,21 @@ func (imp *importer) importSplit(node *ast.SplitExpr) (model.Expression, syntax.
 // - `fn::join` is imported as either a template expression or a call to `join`
 // - `fn::split` is imported as a call to `split`
 // - `fn::stackReference` is imported as a reference to the named stack
+// - `fn::if` is imported as a conditional expression
+// - `fn::not`, `fn::and`, `fn::or` and the comparison builtins are imported as PCL operators
+// - `fn::min` and `fn::max` are imported as calls to `min` and `max`
+// - the other arithmetic builtins are imported as PCL operators
+// - the string builtins are imported as invokes of std functions, e.g. `fn::upper` as `invoke("std:index:upper", ...)`
+// - the regex builtins are imported as invokes of std functions; see importRegex
+// - the collection builtins are imported as invokes of the std functions of the same name, except for `fn::zip`,
+// which std has no equivalent of and is imported as a call to `notImplemented`
+// - `fn::fromJSON` is imported as an invoke of std `jsondecode`; `fn::fromYAML` and `fn::toYAML` have no
+// equivalent and are imported as calls to `notImplemented`
+// - `fn::lookup` and `fn::try` are imported as calls to `lookup` and `try`
+// - `fn::mimeType` has no equivalent and is imported as a call to `notImplemented`
+// - `fn::for` is imported as a for expression; see importFor
+// - `fn::call` is inlined; see importCall
+// - `fn::findInMap` is imported as the value it finds, or as an index into the mapping; see importFindInMap
 func (imp *importer) importBuiltin(node ast.BuiltinExpr) (model.Expression, syntax.Diagnostics) {
 	switch node := node.(type) {
 	case *ast.StringAssetExpr:
@@ -478,12 +898,20 @@ func (imp *importer) importBuiltin(node ast.BuiltinExpr) (model.Expression, synt
 			Name: "filebase64sha256",
 			Args: []model.Expression{path},
 		}, pdiags
+	case *ast.CallExpr:
+		return imp.importCall(node)
 	case *ast.ToJSONExpr:
 		path, pdiags := imp.importExpr(node.Args(), nil)
 		return &model.FunctionCallExpression{
 			Name: "toJSON",
 			Args: []model.Expression{path},
 		}, pdiags
+	case *ast.FromJSONExpr:
+		return imp.importStdInvoke("jsondecode", []string{"input"}, []ast.Expr{node.Value}, schema.StringType)
+	case *ast.FromYAMLExpr:
+		return importNotImplemented(node)
+	case *ast.ToYAMLExpr:
+		return importNotImplemented(node)
 	case *ast.ToBase64Expr:
 		path, pdiags := imp.importExpr(node.Args(), nil)
 		return &model.FunctionCallExpression{
@@ -502,6 +930,8 @@ func (imp *importer) importBuiltin(node ast.BuiltinExpr) (model.Expression, synt
 			Name: "sha1",
 			Args: []model.Expression{val},
 		}, vdiags
+	case *ast.MimeTypeExpr:
+		return importNotImplemented(node)
 	case *ast.LengthExpr:
 		val, vdiags := imp.importExpr(node.Args(), nil)
 		return &model.FunctionCallExpression{
@@ -514,6 +944,30 @@ func (imp *importer) importBuiltin(node ast.BuiltinExpr) (model.Expression, synt
 			Name: "singleOrNone",
 			Args: []model.Expression{val},
 		}, vdiags
+	case *ast.MergeExpr:
+		return imp.importStdInvoke("merge", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.KeysExpr:
+		return imp.importStdInvoke("keys", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.ValuesExpr:
+		return imp.importStdInvoke("values", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.FlattenExpr:
+		return imp.importStdInvoke("flatten", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.ConcatExpr:
+		return imp.importStdInvoke("concat", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.DistinctExpr:
+		return imp.importStdInvoke("distinct", []string{"input"}, []ast.Expr{node.Value}, nil)
+	case *ast.ContainsExpr:
+		return imp.importStdInvoke("contains", []string{"input", "element"}, []ast.Expr{node.Values, node.Value}, nil)
+	case *ast.ZipExpr:
+		return importNotImplemented(node)
+	case *ast.LookupExpr:
+		return imp.importFunctionArgs("lookup", []ast.Expr{node.Map, node.Key, node.Default}, nil)
+	case *ast.TryExpr:
+		return imp.importFunctionArgs("try", node.Values, nil)
+	case *ast.FindInMapExpr:
+		return imp.importFindInMap(node)
+	case *ast.ForExpr:
+		return imp.importFor(node)
 	case *ast.PulumiResourceNameExpr:
 		res, rdiags := imp.importExpr(node.Resource, nil)
 		return &model.FunctionCallExpression{
@@ -526,6 +980,80 @@ func (imp *importer) importBuiltin(node ast.BuiltinExpr) (model.Expression, synt
 			Name: "pulumiResourceType",
 			Args: []model.Expression{res},
 		}, rdiags
+	case *ast.ReplaceExpr:
+		return imp.importReplace(node)
+	case *ast.UpperExpr:
+		return imp.importStdInvoke("upper", []string{"input"}, []ast.Expr{node.Value}, schema.StringType)
+	case *ast.LowerExpr:
+		return imp.importStdInvoke("lower", []string{"input"}, []ast.Expr{node.Value}, schema.StringType)
+	case *ast.TrimExpr:
+		return imp.importStdInvoke("trimspace", []string{"input"}, []ast.Expr{node.Value}, schema.StringType)
+	case *ast.SubstringExpr:
+		var diags syntax.Diagnostics
+		source, sdiags := imp.importExpr(node.Source, schema.StringType)
+		diags.Extend(sdiags...)
+		start, stdiags := imp.importExpr(node.Start, schema.IntType)
+		diags.Extend(stdiags...)
+		length, ldiags := imp.importExpr(node.Length, schema.IntType)
+		diags.Extend(ldiags...)
+		return stdInvoke("substr",
+			model.ObjectConsItem{Key: plainLit("input"), Value: source},
+			model.ObjectConsItem{Key: plainLit("offset"), Value: start},
+			model.ObjectConsItem{Key: plainLit("length"), Value: length},
+		), diags
+	case *ast.FormatExpr:
+		var diags syntax.Diagnostics
+		format, fdiags := imp.importExpr(node.Format, schema.StringType)
+		diags.Extend(fdiags...)
+		values := &model.TupleConsExpression{}
+		for _, v := range node.Values {
+			x, xdiags := imp.importExpr(v, nil)
+			diags.Extend(xdiags...)
+			values.Expressions = append(values.Expressions, x)
+		}
+		return stdInvoke("format",
+			model.ObjectConsItem{Key: plainLit("input"), Value: format},
+			model.ObjectConsItem{Key: plainLit("args"), Value: values},
+		), diags
+	case *ast.RegexExpr:
+		return imp.importRegex(node)
+	case *ast.IfExpr:
+		var diags syntax.Diagnostics
+
+		cond, cdiags := imp.importExpr(node.Condition, schema.BoolType)
+		diags.Extend(cdiags...)
+
+		then, tdiags := imp.importExpr(node.Then, nil)
+		diags.Extend(tdiags...)
+
+		els, ediags := imp.importExpr(node.Else, nil)
+		diags.Extend(ediags...)
+
+		return &model.ConditionalExpression{
+			Condition:   cond,
+			TrueResult:  then,
+			FalseResult: els,
+		}, diags
+	case *ast.NotExpr:
+		val, vdiags := imp.importExpr(node.Value, schema.BoolType)
+		return &model.UnaryOpExpression{
+			Operation: hclsyntax.OpLogicalNot,
+			Operand:   val,
+		}, vdiags
+	case *ast.LogicalExpr:
+		op := hclsyntax.OpLogicalAnd
+		if node.Operator == ast.LogicalOr {
+			op = hclsyntax.OpLogicalOr
+		}
+		return imp.importBinaryOp(op, node.Values, schema.BoolType)
+	case *ast.ComparisonExpr:
+		return imp.importBinaryOp(comparisonOperations[node.Operator], []ast.Expr{node.Left, node.Right}, nil)
+	case *ast.ArithmeticExpr:
+		if op, ok := arithmeticOperations[node.Operator]; ok {
+			return imp.importBinaryOp(op, node.Values, schema.NumberType)
+		}
+
+		return imp.importFunctionArgs(string(node.Operator), node.Values, schema.NumberType)
 	default:
 		contract.Failf("unexpected builtin type %T", node)
 		return nil, nil
@@ -596,7 +1124,8 @@ func (imp *importer) importExpr(node ast.Expr, hint schema.Type) (model.Expressi
 			v, vdiags := imp.importExpr(kvp.Value, hint)
 			diags.Extend(vdiags...)
 
-			items = append(items, model.ObjectConsItem{Key: k, Value: v})
+			item := model.ObjectConsItem{Key: k, Value: v}
+			items = append(items, entryComments(kvp.Key.Syntax(), kvp.Value.Syntax()).item(item))
 		}
 		return &model.ObjectConsExpression{
 			Items: items,
@@ -708,7 +1237,7 @@ func (imp *importer) importConfig(kvp ast.ConfigMapEntry) (model.BodyItem, synta
 			Items: bodyItems,
 		},
 	}
-	return configDef, nil
+	return entryComments(kvp.Key.Syntax(), config.Syntax()).block(configDef), nil
 }
 
 func (imp *importer) getResourceRefList(optionField ast.Expr, name string, field string) ([]model.Expression, syntax.Diagnostics) {
@@ -806,10 +1335,10 @@ func (imp *importer) importVariable(kvp ast.VariablesMapEntry, latestPkgInfo map
 			}
 		}
 	}
-	return &model.Attribute{
+	return entryComments(kvp.Key.Syntax(), value.Syntax()).attribute(&model.Attribute{
 		Name:  imp.variables[name].Name,
 		Value: v,
-	}, diags
+	}), diags
 }
 
 // gets the latest package version specified on a resource
@@ -879,6 +1408,9 @@ func (imp *importer) importResource(kvp ast.ResourcesMapEntry, latestPkgInfo map
 	resourceVar, ok := imp.resources[name]
 	contract.Assertf(ok, "resource %q not found", name)
 
+	imp.ranged = resource.Count != nil || resource.ForEach != nil
+	defer func() { imp.ranged = false }()
+
 	var diags syntax.Diagnostics
 
 	version, err := pulumiyaml.ParseVersion(resource.Options.Version)
@@ -921,10 +1453,10 @@ func (imp *importer) importResource(kvp ast.ResourcesMapEntry, latestPkgInfo map
 		for _, kvp := range pm.Entries {
 			v, vdiags := imp.importExpr(kvp.Value, hints[kvp.Key.Value])
 			diags.Extend(vdiags...)
-			items = append(items, &model.Attribute{
+			items = append(items, entryComments(kvp.Key.Syntax(), kvp.Value.Syntax()).attribute(&model.Attribute{
 				Name:  kvp.Key.Value,
 				Value: v,
-			})
+			}))
 		}
 	} else if resource.Properties.Expr != nil {
 		// Else write out a literal object that refers to each known property in the resource indexed off the referred to symbol
@@ -954,6 +1486,33 @@ func (imp *importer) importResource(kvp ast.ResourcesMapEntry, latestPkgInfo map
 		Type: "options",
 		Body: &model.Body{},
 	}
+	switch {
+	case resource.Condition != nil && imp.ranged:
+		diags.Extend(ast.ExprError(resource.Condition,
+			fmt.Sprintf("resource '%v' cannot have both a condition and count or forEach in PCL", name), ""))
+	case resource.Condition != nil:
+		// PCL creates a resource whose range is a boolean only when the range is true.
+		condExpr, cdiags := imp.importExpr(resource.Condition, schema.BoolType)
+		diags.Extend(cdiags...)
+		resourceOptions.Body.Items = append(resourceOptions.Body.Items, &model.Attribute{
+			Name:  "range",
+			Value: condExpr,
+		})
+	case resource.Count != nil:
+		countExpr, cdiags := imp.importExpr(resource.Count, schema.IntType)
+		diags.Extend(cdiags...)
+		resourceOptions.Body.Items = append(resourceOptions.Body.Items, &model.Attribute{
+			Name:  "range",
+			Value: countExpr,
+		})
+	case resource.ForEach != nil:
+		forEachExpr, fdiags := imp.importExpr(resource.ForEach, schema.AnyType)
+		diags.Extend(fdiags...)
+		resourceOptions.Body.Items = append(resourceOptions.Body.Items, &model.Attribute{
+			Name:  "range",
+			Value: forEachExpr,
+		})
+	}
 	if resource.Options.Aliases != nil {
 		aliasHint := &schema.ArrayType{ElementType: &schema.ObjectType{
 			Properties: []*schema.Property{
@@ -1179,7 +1738,13 @@ func (imp *importer) importResource(kvp ast.ResourcesMapEntry, latestPkgInfo map
 	}
 
 	if len(resourceOptions.Body.Items) > 0 {
-		items = append(items, resourceOptions)
+		optionsKey, optionsValue := yamlEntry(resource.Syntax(), "options")
+		for _, item := range resourceOptions.Body.Items {
+			if attr, ok := item.(*model.Attribute); ok {
+				entryComments(yamlEntry(optionsValue, attr.Name)).attribute(attr)
+			}
+		}
+		items = append(items, entryComments(optionsKey, optionsValue).block(resourceOptions))
 	}
 
 	r := &model.Block{
@@ -1188,13 +1753,7 @@ func (imp *importer) importResource(kvp ast.ResourcesMapEntry, latestPkgInfo map
 		Body:   &model.Body{Items: items},
 	}
 
-	// TODO: comments
-	//	if comment := attr.Key.GetComment(); comment != nil {
-	//		r.Tokens = hclsyntax.NewBlockTokens(r.Type, r.Labels...)
-	//		r.Tokens.Type.LeadingTrivia = hclsyntax.TriviaList{hclsyntax.NewComment(strings.Split(strings.TrimSpace(comment.Value), "\n"))}
-	//	}
-
-	return r, diags
+	return entryComments(kvp.Key.Syntax(), resource.Syntax()).block(r), diags
 }
 
 // importOutput imports a CloudFormation output as a PCL output.
@@ -1206,7 +1765,7 @@ func (imp *importer) importOutput(kvp ast.PropertyMapEntry) (model.BodyItem, syn
 
 	x, diags := imp.importExpr(kvp.Value, nil)
 
-	return &model.Block{
+	return entryComments(kvp.Key.Syntax(), kvp.Value.Syntax()).block(&model.Block{
 		Type:   "output",
 		Labels: []string{outputVar.Name},
 		Body: &model.Body{
@@ -1221,12 +1780,15 @@ func (imp *importer) importOutput(kvp ast.PropertyMapEntry) (model.BodyItem, syn
 				},
 			},
 		},
-	}, diags
+	}), diags
 }
 
 // assignNames assigns names to the variables used to represent template configuration, outputs, and resources.
 // Care is taken to keep configuration and output names as close to their original names as possible.
-func (imp *importer) assignNames() {
+//
+// Names are assigned to each kind of declaration in the order the template declares them, so that a name
+// that collides with an earlier one, or with one of the given keywords, is always the one given a suffix.
+func (imp *importer) assignNames(keywords codegen.StringSet, config, outputs, variables, resources []string) {
 	// PCL has only one namspace with respect to binding, so we can't use any of
 	// these as names.
 	assigned := codegen.NewStringSet(
@@ -1242,6 +1804,9 @@ func (imp *importer) assignNames() {
 		"join",
 		"length",
 		"lookup",
+		"max",
+		"min",
+		"notImplemented",
 		"organization",
 		"project",
 		"range",
@@ -1254,8 +1819,12 @@ func (imp *importer) assignNames() {
 		"stack",
 		"toBase64",
 		"toJSON",
+		"try",
 		"unsecret",
 	)
+	for k := range keywords {
+		assigned.Add(k)
+	}
 
 	assign := func(name, suffix string) *model.Variable {
 		assignName := func(name, suffix string) string {
@@ -1280,24 +1849,21 @@ func (imp *importer) assignNames() {
 		}
 	}
 
-	// TODO: do this in source order
-	assignNames := func(m map[string]*model.Variable, suffix string) {
-		names := make([]string, 0, len(m))
-		for n := range m {
-			names = append(names, n)
-		}
-		sort.Strings(names)
-
+	assignNames := func(kind string, m map[string]*model.Variable, names []string, suffix string) {
 		for _, n := range names {
+			if m[n] != nil {
+				continue
+			}
 			m[n] = assign(n, suffix)
+			imp.names = append(imp.names, Name{Kind: kind, LogicalName: n, Identifier: m[n].Name})
 		}
 	}
 
-	assignNames(imp.configuration, "")
-	assignNames(imp.outputs, "")
-	assignNames(imp.variables, "Var")
-	assignNames(imp.stackReferences, "Stack")
-	assignNames(imp.resources, "Resource")
+	assignNames("config", imp.configuration, config, "")
+	assignNames("output", imp.outputs, outputs, "")
+	assignNames("variable", imp.variables, variables, "Var")
+	assignNames("stackReference", imp.stackReferences, imp.referencedStacks, "Stack")
+	assignNames("resource", imp.resources, resources, "Resource")
 }
 
 func (imp *importer) findStackReferences(node ast.Expr) {
@@ -1320,34 +1886,43 @@ func (imp *importer) findStackReferences(node ast.Expr) {
 	}
 }
 
-func (imp *importer) importTemplate(file *ast.TemplateDecl) (*model.Body, syntax.Diagnostics) {
+func (imp *importer) importTemplate(file ast.Template, keywords codegen.StringSet) (*model.Body, syntax.Diagnostics) {
 	var diags syntax.Diagnostics
+	imp.functions = file.GetFunctions()
+	imp.mappings = file.GetMappings()
 	// Declare config variables, resources, and outputs.
 
-	for _, kvp := range append(file.Configuration.Entries, file.Config.Entries...) {
+	var configNames, resourceNames, variableNames, outputNames []string
+	for _, kvp := range file.GetConfig().Entries {
 		imp.configuration[kvp.Key.Value] = nil
+		configNames = append(configNames, kvp.Key.Value)
 	}
-	for _, kvp := range file.Resources.Entries {
+	for _, kvp := range file.GetResources().Entries {
 		if kvp.Value.Properties.PropertyMap != nil {
 			for _, kvp := range kvp.Value.Properties.PropertyMap.Entries {
 				imp.findStackReferences(kvp.Value)
 			}
 		}
 		imp.resources[kvp.Key.Value] = nil
+		resourceNames = append(resourceNames, kvp.Key.Value)
 	}
-	for _, kvp := range file.Variables.Entries {
+	// Conditions are imported as variables.
+	variables := slices.Concat(file.GetVariables().Entries, file.GetConditions().Entries)
+	for _, kvp := range variables {
 		imp.variables[kvp.Key.Value] = nil
+		variableNames = append(variableNames, kvp.Key.Value)
 	}
-	for _, kvp := range file.Outputs.Entries {
+	for _, kvp := range file.GetOutputs().Entries {
 		imp.findStackReferences(kvp.Value)
 		imp.outputs[kvp.Key.Value] = nil
+		outputNames = append(outputNames, kvp.Key.Value)
 	}
-	imp.assignNames()
+	imp.assignNames(keywords, configNames, outputNames, variableNames, resourceNames)
 
 	var items []model.BodyItem
 
 	// Import config.
-	for _, kvp := range append(file.Configuration.Entries, file.Config.Entries...) {
+	for _, kvp := range file.GetConfig().Entries {
 		config, cdiags := imp.importConfig(kvp)
 		diags.Extend(cdiags...)
 
@@ -1358,16 +1933,16 @@ func (imp *importer) importTemplate(file *ast.TemplateDecl) (*model.Body, syntax
 
 	// get latest package info
 	latestPkgInfo := make(map[string]*packageInfo)
-	for _, kvp := range file.Resources.Entries {
+	for _, kvp := range file.GetResources().Entries {
 		rdiags := imp.getLatestPkgInfoResource(kvp, latestPkgInfo)
 		diags.Extend(rdiags...)
 	}
-	for _, kvp := range file.Variables.Entries {
+	for _, kvp := range variables {
 		imp.getLatestPkgInfoVariable(kvp, latestPkgInfo)
 	}
 
 	// Import variables
-	for _, kvp := range file.Variables.Entries {
+	for _, kvp := range variables {
 		output, vdiags := imp.importVariable(kvp, latestPkgInfo)
 		diags.Extend(vdiags...)
 
@@ -1391,7 +1966,7 @@ func (imp *importer) importTemplate(file *ast.TemplateDecl) (*model.Body, syntax
 	}
 
 	// Import resources.
-	for _, kvp := range file.Resources.Entries {
+	for _, kvp := range file.GetResources().Entries {
 		resource, rdiags := imp.importResource(kvp, latestPkgInfo)
 		diags.Extend(rdiags...)
 
@@ -1401,7 +1976,7 @@ func (imp *importer) importTemplate(file *ast.TemplateDecl) (*model.Body, syntax
 	}
 
 	// Import outputs.
-	for _, kvp := range file.Outputs.Entries {
+	for _, kvp := range file.GetOutputs().Entries {
 		output, odiags := imp.importOutput(kvp)
 		diags.Extend(odiags...)
 
@@ -1411,8 +1986,8 @@ func (imp *importer) importTemplate(file *ast.TemplateDecl) (*model.Body, syntax
 	}
 
 	// Import the pulumi block if requiredVersion is set.
-	if file.Pulumi.RequiredVersion != nil {
-		versionExpr, vdiags := imp.importExpr(file.Pulumi.RequiredVersion, nil)
+	if file.GetPulumi().RequiredVersion != nil {
+		versionExpr, vdiags := imp.importExpr(file.GetPulumi().RequiredVersion, nil)
 		diags.Extend(vdiags...)
 		if versionExpr != nil {
 			items = append(items, &model.Block{
@@ -1495,9 +2070,26 @@ func (imp *importer) importPackageDescriptors() []model.BodyItem {
 
 // ImportTemplate converts a YAML template to a PCL definition.
 func ImportTemplate(file *ast.TemplateDecl, loader pulumiyaml.PackageLoader) (*model.Body, syntax.Diagnostics) {
-	pacakgeDescriptors, err := packages.ToPackageDescriptors(file.Sdks)
+	body, _, diags := ImportTemplateWithOptions(file, loader, ImportOptions{})
+	return body, diags
+}
+
+// ImportTemplateWithOptions converts a YAML template to a PCL definition. It also returns the identifier
+// assigned to each of the template's configuration values, outputs, variables, stack references and
+// resources, in that order.
+//
+// The template may also be a component of a plugin, which is imported as the program of a PCL
+// component: its inputs are imported as config, and its outputs as outputs.
+func ImportTemplateWithOptions(
+	file ast.Template, loader pulumiyaml.PackageLoader, opts ImportOptions,
+) (*model.Body, []Name, syntax.Diagnostics) {
+	keywords, err := languageKeywords(opts.Languages)
+	if err != nil {
+		return nil, nil, syntax.Diagnostics{syntax.Error(nil, err.Error(), "")}
+	}
+	pacakgeDescriptors, err := packages.ToPackageDescriptors(file.GetSdks())
 	if err != nil {
-		return nil, syntax.Diagnostics{syntax.Error(nil, fmt.Sprintf("unable to parse package descriptors: %v", err), "")}
+		return nil, nil, syntax.Diagnostics{syntax.Error(nil, fmt.Sprintf("unable to parse package descriptors: %v", err), "")}
 	}
 
 	imp := importer{
@@ -1510,5 +2102,6 @@ func ImportTemplate(file *ast.TemplateDecl, loader pulumiyaml.PackageLoader) (*m
 
 		packageDescriptors: pacakgeDescriptors,
 	}
-	return imp.importTemplate(file)
+	body, diags := imp.importTemplate(file, keywords)
+	return body, imp.names, diags
 }
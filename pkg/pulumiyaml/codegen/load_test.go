@@ -7,10 +7,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
 )
 
 func TestImportTemplate(t *testing.T) {
@@ -22,7 +25,8 @@ func TestImportTemplate(t *testing.T) {
 		// A PCL program
 		expected string
 
-		diagErrors []string
+		diagErrors   []string
+		diagWarnings []string
 	}{
 		{
 			name: "complex resource options",
@@ -172,6 +176,286 @@ resource provider "pulumi:providers:aws" {
 }
 `,
 		},
+		{
+			name: "arithmetic builtins",
+			input: `
+variables:
+  size:
+    fn::add: [1, 2, 3]
+  half:
+    fn::div: ["${size}", 2]
+  biggest:
+    fn::max: [1, "${size}"]`,
+			expected: `size = 1 + 2 + 3
+half = size / 2
+biggest = max(1, size)
+`,
+		},
+		{
+			name: "functions are inlined",
+			input: `
+functions:
+  shout:
+    parameters:
+      value:
+        type: string
+    body:
+      fn::upper: ${value}
+variables:
+  greeting:
+    fn::shout:
+      value: hello`,
+			expected: `greeting = invoke("std:index:upper", {
+	input = "hello"
+}).result
+`,
+		},
+		{
+			name: "comments",
+			input: `
+config:
+  # The deployment environment.
+  env:
+    type: string
+variables:
+  # A greeting,
+  # over two lines.
+  greeting: hello # inline
+resources:
+  # The bucket that serves the site.
+  bar: # the site
+    type: test:mod:typ
+    properties:
+      # Run from the project directory.
+      foo: ${pulumi.cwd} # not the home directory
+      # The end of the properties.
+  baz:
+    type: test:mod:typ
+    properties:
+      foo:
+        # The first key.
+        a: 1 # one
+        b: 2
+    # Keep the bucket.
+    options: # always
+      protect: true # until the site moves
+outputs:
+  # The greeting.
+  message: ${greeting}`,
+			expected: `// The deployment environment.
+config env string {
+	__logicalName = "env"
+}
+
+// A greeting,
+// over two lines.
+greeting = "hello" // inline
+
+// The bucket that serves the site.
+resource bar "test:mod:typ" { // the site
+	__logicalName = "bar"
+	// Run from the project directory.
+	foo = cwd() // not the home directory
+	// The end of the properties.
+}
+
+resource baz "test:mod:typ" {
+	__logicalName = "baz"
+	foo = {
+		// The first key.
+		"a" = 1, // one
+		"b" = 2
+	}
+
+	// Keep the bucket.
+	options { // always
+		protect = true // until the site moves
+	}
+}
+
+// The greeting.
+output message {
+	__logicalName = "message"
+	value = greeting
+}
+`,
+		},
+		{
+			name: "for",
+			input: `
+variables:
+  ports: [ 80, 443 ]
+  rules:
+    fn::for:
+      in: ${ports}
+      key: port-${range.key}
+      value: ${range.value}
+      if:
+        fn::greaterThan: [ "${range.value}", 100 ]
+  types:
+    fn::mimeType: index.html`,
+			expected: `ports = [
+	80,
+	443
+]
+rules = {for range in entries(ports): "port-${range.key}" => range.value if range.value > 100}
+types = notImplemented("fn::mimeType")
+`,
+			diagWarnings: []string{"for.yaml:12,5-29: fn::mimeType has no equivalent in PCL; " +
+				"it is converted to a call to notImplemented, which must be replaced by hand"},
+		},
+		{
+			name: "findInMap with literal keys is inlined",
+			input: `
+mappings:
+  regionMap:
+    us-east-1:
+      ami: ami-123
+conditions:
+  isEast:
+    fn::equals: [ us-east-1, us-east-1 ]
+variables:
+  ami:
+    fn::findInMap: [ regionMap, us-east-1, ami ]`,
+			expected: `ami = "ami-123"
+isEast = "us-east-1" == "us-east-1"
+`,
+		},
+		{
+			name: "string builtins",
+			input: `
+variables:
+  name:
+    fn::lower:
+      fn::replace: ["My_Service", "_", "-"]
+  short:
+    fn::substring: ["${name}", 0, 5]
+  label:
+    fn::format: ["%s-%d", "${short}", 1]`,
+			expected: `name = invoke("std:index:lower", {
+	input = invoke("std:index:replace", {
+		text = "My_Service",
+		search = "_",
+		replace = "-"
+	}).result
+}).result
+short = invoke("std:index:substr", {
+	input = name,
+	offset = 0,
+	length = 5
+}).result
+label = invoke("std:index:format", {
+	input = "%s-%d",
+	args = [
+		short,
+		1
+	]
+}).result
+`,
+		},
+		{
+			name: "replace with a slash-delimited search",
+			input: `
+variables:
+  path: /tmp/cache/$HOME
+  moved:
+    fn::replace: [ "${path}", /tmp/, /var/tmp/$1 ]`,
+			expected: `path = "/tmp/cache/$HOME"
+moved = invoke("std:index:replace", {
+	text = path,
+	search = "//tmp//",
+	replace = "/var/tmp/$$1"
+}).result
+`,
+		},
+		{
+			name: "regex builtins",
+			input: `
+variables:
+  arn: arn:aws:s3:::my-bucket
+  isBucket:
+    fn::regexMatch: [ "^arn:aws:s3:::[a-z0-9.-]+$", "${arn}" ]
+  bucket:
+    fn::regexReplace: [ "^arn:aws:s3:::(?P<name>.*)$", "${arn}", "$${name}" ]
+  parts:
+    fn::regexFind: [ "[a-z0-9]+", "${bucket}" ]
+  groups:
+    fn::regexFind: [ "([a-z]+)-", "${bucket}" ]`,
+			expected: `arn = "arn:aws:s3:::my-bucket"
+isBucket = length(invoke("std:index:regexall", {
+	pattern = "^arn:aws:s3:::[a-z0-9.-]+$",
+	string = arn
+}).result) > 0
+bucket = invoke("std:index:replace", {
+	text = arn,
+	search = "/^arn:aws:s3:::(?P<name>.*)$/",
+	replace = "$${name}"
+}).result
+parts = invoke("std:index:regexall", {
+	pattern = "[a-z0-9]+",
+	string = bucket
+}).result
+groups = invoke("std:index:regexall", {
+	pattern = "([a-z]+)-",
+	string = bucket
+}).result
+`,
+			diagWarnings: []string{"regex builtins.yaml:11,22-31: fn::regexFind is converted to std regexall; " +
+				"regexall returns the capture groups of each match instead of the whole match when the pattern " +
+				"has capture groups; check that the converted program finds the same matches"},
+		},
+		{
+			name: "collection builtins",
+			input: `
+variables:
+  tags:
+    fn::merge:
+      - env: dev
+      - team: web
+  names:
+    fn::keys: ${tags}
+  hasEnv:
+    fn::contains: [ "${names}", "env" ]
+  pairs:
+    fn::zip: [ "${names}", [ 1, 2 ] ]`,
+			expected: `tags = invoke("std:index:merge", {
+	input = [
+		{
+			"env" = "dev"
+		},
+		{
+			"team" = "web"
+		}
+	]
+}).result
+names = invoke("std:index:keys", {
+	input = tags
+}).result
+hasEnv = invoke("std:index:contains", {
+	input = names,
+	element = "env"
+}).result
+pairs = notImplemented("fn::zip")
+`,
+			diagWarnings: []string{"collection builtins.yaml:12,5-34: fn::zip has no equivalent in PCL; " +
+				"it is converted to a call to notImplemented, which must be replaced by hand"},
+		},
+		{
+			name: "serialization builtins",
+			input: `
+variables:
+  settings:
+    fn::fromJSON: '{"replicas": 3}'
+  manifest:
+    fn::toYAML: ${settings}`,
+			expected: `settings = invoke("std:index:jsondecode", {
+	input = "{\"replicas\": 3}"
+}).result
+manifest = notImplemented("fn::toYAML")
+`,
+			diagWarnings: []string{"serialization builtins.yaml:6,5-28: fn::toYAML has no equivalent in PCL; " +
+				"it is converted to a call to notImplemented, which must be replaced by hand"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -185,7 +469,11 @@ resource provider "pulumi:providers:aws" {
 			if tt.diagErrors == nil {
 				require.False(t, diags.HasErrors(), diags)
 				assert.Equal(t, tt.expected, fmt.Sprintf("%v", result))
-				assert.Empty(t, diags)
+				var diagWarnings []string
+				for _, diag := range diags {
+					diagWarnings = append(diagWarnings, diag.Error())
+				}
+				assert.Equal(t, tt.diagWarnings, diagWarnings)
 			} else {
 				require.True(t, diags.HasErrors())
 				var diagErrors []string
@@ -197,3 +485,195 @@ resource provider "pulumi:providers:aws" {
 		})
 	}
 }
+
+func TestImportInvalidRegex(t *testing.T) {
+	t.Parallel()
+
+	// The parser rejects invalid literal patterns, but the importer may be given a template that it never saw.
+	decl, diags, err := pulumiyaml.LoadYAML("regex.yaml", strings.NewReader(`
+variables:
+  parts: []`))
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags)
+	decl.Variables.Entries[0].Value = ast.Regex(ast.RegexFind, ast.String("([a-z]+"), ast.String("my-bucket"))
+
+	_, diags = ImportTemplate(decl, testPackageLoader{t})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "invalid regular expression: error parsing regexp: missing closing ): `([a-z]+`",
+		diags[0].Summary)
+}
+
+func TestImportCallCopiesArguments(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+functions:
+  twice:
+    parameters:
+      value:
+        type: string
+    body:
+      fn::join: ["-", ["${value}", "${value}"]]
+variables:
+  name: web
+  label:
+    fn::twice:
+      value: ${name}
+`
+	decl, diags, err := pulumiyaml.LoadYAML("twice.yaml", strings.NewReader(text))
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags)
+
+	body, diags := ImportTemplate(decl, testPackageLoader{t})
+	require.False(t, diags.HasErrors(), diags)
+	assert.Equal(t, `name = "web"
+label = join("-", [
+	name,
+	name
+])
+`, fmt.Sprintf("%v", body))
+
+	// Each use of the parameter is a distinct node of the PCL tree.
+	seen := map[model.Expression]bool{}
+	for _, item := range body.Items {
+		model.VisitExpressions(item, nil, func(x model.Expression) (model.Expression, hcl.Diagnostics) {
+			assert.False(t, seen[x], "%v appears more than once", x)
+			seen[x] = true
+			return x, nil
+		})
+	}
+}
+
+func TestImportTemplateNames(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+resources:
+  my_bucket:
+    type: test:mod:typ
+  my-bucket:
+    type: test:mod:typ
+  default:
+    type: test:mod:typ
+variables:
+  myBucket: ${my-bucket.foo}
+outputs:
+  type: ${default.foo}
+`
+	decl, diags, err := pulumiyaml.LoadYAML("names.yaml", strings.NewReader(text))
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags)
+
+	t.Run("no languages", func(t *testing.T) {
+		t.Parallel()
+
+		_, names, diags := ImportTemplateWithOptions(decl, testPackageLoader{t}, ImportOptions{})
+		require.False(t, diags.HasErrors(), diags)
+		assert.Equal(t, []Name{
+			{Kind: "output", LogicalName: "type", Identifier: "type"},
+			{Kind: "variable", LogicalName: "myBucket", Identifier: "myBucket"},
+			{Kind: "resource", LogicalName: "my_bucket", Identifier: "myBucketResource"},
+			{Kind: "resource", LogicalName: "my-bucket", Identifier: "myBucketResource0"},
+			{Kind: "resource", LogicalName: "default", Identifier: "default"},
+		}, names)
+	})
+
+	t.Run("go", func(t *testing.T) {
+		t.Parallel()
+
+		_, names, diags := ImportTemplateWithOptions(decl, testPackageLoader{t}, ImportOptions{
+			Languages: []string{"go"},
+		})
+		require.False(t, diags.HasErrors(), diags)
+		assert.Equal(t, []Name{
+			{Kind: "output", LogicalName: "type", Identifier: "type0"},
+			{Kind: "variable", LogicalName: "myBucket", Identifier: "myBucket"},
+			{Kind: "resource", LogicalName: "my_bucket", Identifier: "myBucketResource"},
+			{Kind: "resource", LogicalName: "my-bucket", Identifier: "myBucketResource0"},
+			{Kind: "resource", LogicalName: "default", Identifier: "defaultResource"},
+		}, names)
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		t.Parallel()
+
+		_, names, diags := ImportTemplateWithOptions(decl, testPackageLoader{t}, ImportOptions{
+			Languages: []string{"typescript", "csharp"},
+		})
+		require.False(t, diags.HasErrors(), diags)
+		assert.Equal(t, []Name{
+			{Kind: "output", LogicalName: "type", Identifier: "type"},
+			{Kind: "variable", LogicalName: "myBucket", Identifier: "myBucket"},
+			{Kind: "resource", LogicalName: "my_bucket", Identifier: "myBucketResource"},
+			{Kind: "resource", LogicalName: "my-bucket", Identifier: "myBucketResource0"},
+			{Kind: "resource", LogicalName: "default", Identifier: "defaultResource"},
+		}, names)
+	})
+
+	t.Run("unknown language", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, diags := ImportTemplateWithOptions(decl, testPackageLoader{t}, ImportOptions{
+			Languages: []string{"cobol"},
+		})
+		require.Len(t, diags, 1)
+		assert.Equal(t,
+			`unknown language "cobol"; expected one of dotnet, go, java, nodejs, python`,
+			diags[0].Summary)
+	})
+}
+
+func TestImportComponent(t *testing.T) {
+	t.Parallel()
+
+	const text = `
+name: plugin
+runtime: yaml
+components:
+  site:
+    inputs:
+      name:
+        type: string
+      replicas:
+        type: integer
+        default: 1
+    variables:
+      label: ${name}-site
+    resources:
+      bar:
+        type: test:mod:typ
+        properties:
+          foo: ${label}
+    outputs:
+      label: ${label}
+`
+	decl, diags, err := pulumiyaml.LoadYAML("PulumiPlugin.yaml", strings.NewReader(text))
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags)
+	require.Len(t, decl.Components.Entries, 1)
+
+	body, _, diags := ImportTemplateWithOptions(decl.Components.Entries[0].Value, testPackageLoader{t}, ImportOptions{})
+	require.False(t, diags.HasErrors(), diags)
+	assert.Equal(t, `config name string {
+	__logicalName = "name"
+}
+
+config replicas int {
+	__logicalName = "replicas"
+	default = 1
+}
+
+labelVar = "${name}-site"
+
+resource bar "test:mod:typ" {
+	__logicalName = "bar"
+	foo = labelVar
+}
+
+output label {
+	__logicalName = "label"
+	value = labelVar
+}
+`, fmt.Sprintf("%v", body))
+}
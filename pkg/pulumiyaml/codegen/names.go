@@ -0,0 +1,104 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+)
+
+// ImportOptions controls how a template is imported to PCL.
+type ImportOptions struct {
+	// Languages are the languages the PCL program will be generated in. Identifiers that are
+	// keywords in any of them are not assigned. If Languages is empty, no keywords are avoided:
+	// each language's generator escapes its own.
+	Languages []string
+}
+
+// A Name records the PCL identifier assigned to a configuration value, variable, stack reference,
+// resource or output of a template.
+type Name struct {
+	// Kind is one of "config", "output", "variable", "stackReference" or "resource".
+	Kind string `json:"kind"`
+	// LogicalName is the name the template declares the item by.
+	LogicalName string `json:"logicalName"`
+	// Identifier is the name of the PCL variable the item is imported as.
+	Identifier string `json:"identifier"`
+	// Component is the name of the plugin component that declares the item, if any. Each component
+	// is imported as a separate PCL component, so identifiers are only unique within it.
+	Component string `json:"component,omitempty"`
+}
+
+// keywords holds the reserved words of each language PCL programs are generated in. Names are
+// assigned in camel case, so keywords that start with an upper case letter are omitted.
+var keywords = map[string][]string{
+	"dotnet": {
+		"abstract", "as", "base", "bool", "break", "byte", "case", "catch", "char", "checked", "class",
+		"const", "continue", "decimal", "default", "delegate", "do", "double", "else", "enum", "event",
+		"explicit", "extern", "false", "finally", "fixed", "float", "for", "foreach", "goto", "if",
+		"implicit", "in", "int", "interface", "internal", "is", "lock", "long", "namespace", "new", "null",
+		"object", "operator", "out", "override", "params", "private", "protected", "public", "readonly",
+		"ref", "return", "sbyte", "sealed", "short", "sizeof", "stackalloc", "static", "string", "struct",
+		"switch", "this", "throw", "true", "try", "typeof", "uint", "ulong", "unchecked", "unsafe",
+		"ushort", "using", "virtual", "void", "volatile", "while",
+	},
+	"go": {
+		"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for",
+		"func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+		"struct", "switch", "type", "var",
+	},
+	"java": {
+		"abstract", "assert", "boolean", "break", "byte", "case", "catch", "char", "class", "const",
+		"continue", "default", "do", "double", "else", "enum", "extends", "false", "final", "finally",
+		"float", "for", "goto", "if", "implements", "import", "instanceof", "int", "interface", "long",
+		"native", "new", "null", "package", "private", "protected", "public", "record", "return", "short",
+		"static", "strictfp", "super", "switch", "synchronized", "this", "throw", "throws", "transient",
+		"true", "try", "var", "void", "volatile", "while", "yield",
+	},
+	"nodejs": {
+		"await", "break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete",
+		"do", "else", "enum", "export", "extends", "false", "finally", "for", "function", "if",
+		"implements", "import", "in", "instanceof", "interface", "let", "new", "null", "package",
+		"private", "protected", "public", "return", "static", "super", "switch", "this", "throw", "true",
+		"try", "typeof", "var", "void", "while", "with", "yield",
+	},
+	"python": {
+		"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif",
+		"else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+		"nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+	},
+}
+
+// languageAliases maps the other names a language may be given, such as those accepted by
+// `pulumi convert --language`, to its key in keywords.
+var languageAliases = map[string]string{
+	"csharp":     "dotnet",
+	"javascript": "nodejs",
+	"typescript": "nodejs",
+}
+
+// languageKeywords returns the keywords of the given languages.
+func languageKeywords(languages []string) (codegen.StringSet, error) {
+	set := codegen.NewStringSet()
+	for _, l := range languages {
+		if alias, ok := languageAliases[l]; ok {
+			l = alias
+		}
+		words, ok := keywords[l]
+		if !ok {
+			known := make([]string, 0, len(keywords))
+			for k := range keywords {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown language %q; expected one of %s", l, strings.Join(known, ", "))
+		}
+		for _, w := range words {
+			set.Add(w)
+		}
+	}
+	return set, nil
+}
@@ -4,6 +4,7 @@ package codegen
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -12,6 +13,10 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/model"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/syntax"
 	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+
+	syn "github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
 )
 
 // camel replaces the first contiguous string of upper case runes in the given string with its lower-case equivalent.
@@ -97,3 +102,95 @@ func (b BlockSyntax) LineComment() string {
 func (b BlockSyntax) FootComment() string {
 	return ""
 }
+
+// comments holds the YAML comments attached to an entry of a mapping as PCL comment trivia. The
+// comments carry only their lines; formatBody lays them out around the item they are attached to.
+type comments struct {
+	head, line, foot syntax.TriviaList
+}
+
+// entryComments returns the comments attached to the mapping entry with the given key and value.
+// YAML attaches head and foot comments to an entry's key, and its line comment to the key or, if
+// the value is a scalar, to the value.
+func entryComments(key, value syn.Node) comments {
+	var c comments
+	if k := yamlNode(key); k != nil {
+		c.head, c.line, c.foot = comment(k.HeadComment), comment(k.LineComment), comment(k.FootComment)
+	}
+	if v := yamlNode(value); v != nil && v.Kind == yaml.ScalarNode && c.line == nil {
+		c.line = comment(v.LineComment)
+	}
+	return c
+}
+
+// yamlNode returns the YAML node that n was decoded from, if any.
+func yamlNode(n syn.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if s, ok := n.Syntax().(encoding.YAMLSyntax); ok {
+		return s.Node
+	}
+	return nil
+}
+
+// comment converts the text of a YAML comment to a PCL comment.
+func comment(text string) syntax.TriviaList {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimPrefix(l, "#"), " ")
+	}
+	return syntax.TriviaList{syntax.Comment{Lines: lines}}
+}
+
+// block attaches the comments to b: head comments precede the block, the line comment follows its
+// opening brace, and foot comments follow its closing brace.
+func (c comments) block(b *model.Block) *model.Block {
+	if c.head == nil && c.line == nil && c.foot == nil {
+		return b
+	}
+	b.Tokens = syntax.NewBlockTokens(b.Type, b.Labels...)
+	b.Tokens.Type.LeadingTrivia = c.head
+	b.Tokens.OpenBrace.TrailingTrivia = slices.Concat(c.line, syntax.TriviaList{syntax.NewWhitespace('\n')})
+	b.Tokens.CloseBrace.TrailingTrivia = slices.Concat(syntax.TriviaList{syntax.NewWhitespace('\n')}, c.foot)
+	return b
+}
+
+// item attaches the comments to an item of an object: head comments precede its key, and the line
+// comment and foot comments follow its value, separated by a newline.
+func (c comments) item(item model.ObjectConsItem) model.ObjectConsItem {
+	if c.head == nil && c.line == nil && c.foot == nil || item.Key == nil || item.Value == nil {
+		return item
+	}
+	item.Key.SetLeadingTrivia(c.head)
+	item.Value.SetTrailingTrivia(slices.Concat(c.line, syntax.TriviaList{syntax.NewWhitespace('\n')}, c.foot))
+	return item
+}
+
+// yamlEntry returns the key and value of the entry of the YAML object node whose key is key,
+// ignoring case. Nil is returned if node is not an object or has no such entry.
+func yamlEntry(node syn.Node, key string) (syn.Node, syn.Node) {
+	if obj, ok := node.(*syn.ObjectNode); ok {
+		for i := 0; i < obj.Len(); i++ {
+			if kvp := obj.Index(i); strings.EqualFold(kvp.Key.Value(), key) {
+				return kvp.Key, kvp.Value
+			}
+		}
+	}
+	return nil, nil
+}
+
+// attribute attaches the comments to a: head comments precede the attribute, and the line comment
+// and foot comments follow its value, separated by a newline.
+func (c comments) attribute(a *model.Attribute) *model.Attribute {
+	if c.head == nil && c.line == nil && c.foot == nil {
+		return a
+	}
+	a.Tokens = syntax.NewAttributeTokens(a.Name)
+	a.Tokens.Name.LeadingTrivia = c.head
+	a.Value.SetTrailingTrivia(slices.Concat(c.line, syntax.TriviaList{syntax.NewWhitespace('\n')}, c.foot))
+	return a
+}
@@ -0,0 +1,702 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+// Package edit makes typed changes to Pulumi YAML templates, such as adding a resource, setting one of its properties
+// or renaming it. Each change rewrites only the mapping entries of the template that it touches, which are encoded
+// from syntax nodes with encoding.MarshalYAML. The rest of the source, including its comments and blank lines, is kept
+// byte for byte.
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// A Template is a Pulumi YAML template that is being edited.
+type Template struct {
+	filename string
+	source   []byte
+	root     *syntax.ObjectNode
+}
+
+// A field is a key of a mapping that is part of the template schema, such as `resources` or `properties`. Unlike
+// other keys, fields are matched without regard to case.
+type field string
+
+// Parse parses the source of a template for editing.
+func Parse(filename string, source []byte) (*Template, syntax.Diagnostics) {
+	t := &Template{filename: filename}
+	diags := t.reset(source)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return t, diags
+}
+
+// Bytes returns the source of the edited template.
+func (t *Template) Bytes() []byte {
+	return t.source
+}
+
+// AddResource adds a resource with the given logical name, type and properties to the template. properties may be
+// nil.
+func (t *Template) AddResource(name, typ string, properties *syntax.ObjectNode) error {
+	if _, ok := t.resource(name); ok {
+		return fmt.Errorf("resource %q already exists", name)
+	}
+
+	entries := []syntax.ObjectPropertyDef{syntax.ObjectProperty(syntax.String("type"), syntax.String(typ))}
+	if properties != nil && properties.Len() != 0 {
+		entries = append(entries, syntax.ObjectProperty(syntax.String("properties"), properties))
+	}
+	return t.set([]interface{}{field("resources"), name}, syntax.Object(entries...))
+}
+
+// RemoveResource removes the resource with the given logical name, and the comments before it, from the template.
+// References to the resource are left as they are.
+func (t *Template) RemoveResource(name string) error {
+	if _, ok := t.resource(name); !ok {
+		return fmt.Errorf("resource %q does not exist", name)
+	}
+	return t.remove([]interface{}{field("resources"), name})
+}
+
+// SetProperty sets the property of a resource at the given path, e.g. `tags.owner` or `rules[0].port`, creating the
+// mappings along the path that do not exist.
+func (t *Template) SetProperty(name, path string, value syntax.Node) error {
+	steps, err := t.propertyPath(name, path)
+	if err != nil {
+		return err
+	}
+	return t.set(steps, value)
+}
+
+// RemoveProperty removes the property of a resource at the given path.
+func (t *Template) RemoveProperty(name, path string) error {
+	steps, err := t.propertyPath(name, path)
+	if err != nil {
+		return err
+	}
+	return t.remove(steps)
+}
+
+// SetResourceOption sets a resource option, such as `protect` or `version`, of a resource.
+func (t *Template) SetResourceOption(name, option string, value syntax.Node) error {
+	if _, ok := t.resource(name); !ok {
+		return fmt.Errorf("resource %q does not exist", name)
+	}
+	canonical, ok := resourceOption(option)
+	if !ok {
+		return fmt.Errorf("unknown resource option %q", option)
+	}
+	return t.set([]interface{}{field("resources"), name, field("options"), field(canonical)}, value)
+}
+
+// RenameResource changes the logical name of a resource, and rewrites the references to it, such as `${from.id}`,
+// throughout the template. The components of the template are not rewritten, as they have their own scope. The new
+// name must not already be declared by a config value, variable or resource, as they share one namespace.
+func (t *Template) RenameResource(from, to string) error {
+	kvp, ok := t.resource(from)
+	if !ok {
+		return fmt.Errorf("resource %q does not exist", from)
+	}
+	for _, section := range []struct{ name, kind string }{
+		{"config", "config"},
+		{"variables", "variable"},
+		{"resources", "resource"},
+	} {
+		if _, ok := t.declaration(section.name, to); ok {
+			return fmt.Errorf("%s %q already exists", section.kind, to)
+		}
+	}
+
+	// The key and the references are renamed together, so that the template is left as it was if either fails.
+	var splices []splice
+	lines := lines(t.source)
+	for i := 0; i < t.root.Len(); i++ {
+		kvp := t.root.Index(i)
+		var s []splice
+		var err error
+		switch {
+		case strings.EqualFold(kvp.Key.Value(), "components"):
+			continue
+		case strings.EqualFold(kvp.Key.Value(), "functions"):
+			s, err = renameFunctionReferences(lines, kvp, from, to)
+		default:
+			s, err = renameReferences(lines, kvp, from, to)
+		}
+		if err != nil {
+			return err
+		}
+		splices = append(splices, s...)
+	}
+	rename, err := renameKey(lines, kvp, to, splices)
+	if err != nil {
+		return err
+	}
+	splices = append(slices.DeleteFunc(splices, func(s splice) bool {
+		return s.start < rename.end && rename.start < s.end
+	}), rename)
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start < splices[j].start })
+	return t.check(t.reset(apply(t.source, splices...)))
+}
+
+// renameKey returns the splice that renames the key of the block mapping entry kvp. A plain key is rewritten in place,
+// unless one of the splices that rename the references to it rewrites the same line. Otherwise the entry is rewritten,
+// with the references in its value renamed too.
+func renameKey(lines [][]byte, kvp syntax.ObjectPropertyDef, to string, references []splice) (splice, error) {
+	key := keyNode(kvp)
+	if key == nil {
+		return splice{}, fmt.Errorf("%q has no source", kvp.Key.Value())
+	}
+
+	line, column := lines[key.Line-1], key.Column-1
+	from := []byte(kvp.Key.Value())
+	inPlace := !slices.ContainsFunc(references, func(s splice) bool { return s.start < key.Line && key.Line-1 < s.end })
+	if inPlace && key.Style == 0 && isPlain(to) && bytes.HasPrefix(line[column:], from) {
+		renamed := append(append(append([]byte{}, line[:column]...), to...), line[column+len(from):]...)
+		return splice{key.Line - 1, key.Line, renamed}, nil
+	}
+
+	value, _ := rewriteReferences(kvp.Value, kvp.Key.Value(), to)
+	renamed := syntax.ObjectPropertySyntax(kvp.Syntax, syntax.StringSyntax(kvp.Key.Syntax(), to), value)
+	start, end := entrySpan(lines, key)
+	text, err := render(renamed, column)
+	if err != nil {
+		return splice{}, err
+	}
+	return splice{start, end, text}, nil
+}
+
+// reset replaces the source of the template and parses it again.
+func (t *Template) reset(source []byte) syntax.Diagnostics {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(source, &doc); err != nil {
+		return syntax.Diagnostics{syntax.Error(nil, err.Error(), "")}
+	}
+
+	root := syntax.Object()
+	if len(doc.Content) != 0 {
+		node, diags := encoding.UnmarshalYAML(t.filename, doc.Content[0], pulumiyaml.TagDecoder)
+		if diags.HasErrors() {
+			return diags
+		}
+		obj, ok := node.(*syntax.ObjectNode)
+		if !ok {
+			return append(diags, syntax.Error(nil, fmt.Sprintf("Top level of '%s' must be an object", t.filename), ""))
+		}
+		root = obj
+	}
+	t.source, t.root = source, root
+	return nil
+}
+
+// check returns the errors in diags, if any, as an error.
+func (t *Template) check(diags syntax.Diagnostics) error {
+	if diags.HasErrors() {
+		return fmt.Errorf("editing '%s': %w", t.filename, diags)
+	}
+	return nil
+}
+
+// resource returns the entry of the resource with the given logical name.
+func (t *Template) resource(name string) (syntax.ObjectPropertyDef, bool) {
+	return t.declaration("resources", name)
+}
+
+// declaration returns the entry that declares name in the given top-level section of the template, such as
+// `variables`.
+func (t *Template) declaration(section, name string) (syntax.ObjectPropertyDef, bool) {
+	decls, ok := lookup(t.root, field(section))
+	if !ok {
+		return syntax.ObjectPropertyDef{}, false
+	}
+	obj, ok := decls.Value.(*syntax.ObjectNode)
+	if !ok {
+		return syntax.ObjectPropertyDef{}, false
+	}
+	return lookup(obj, name)
+}
+
+// propertyPath returns the path to the property of a resource at the given property path.
+func (t *Template) propertyPath(name, path string) ([]interface{}, error) {
+	if _, ok := t.resource(name); !ok {
+		return nil, fmt.Errorf("resource %q does not exist", name)
+	}
+	parsed, err := resource.ParsePropertyPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return append([]interface{}{field("resources"), name, field("properties")}, parsed...), nil
+}
+
+// set sets the value at path, creating the mappings along it that do not exist. The deepest block mapping entry
+// along the path is rewritten, or, if the path leaves a block mapping at a key that does not exist, a new entry is
+// added to that mapping.
+func (t *Template) set(path []interface{}, value syntax.Node) error {
+	obj, owner := t.root, (*syntax.ObjectPropertyDef)(nil)
+	for i, step := range path {
+		if owner != nil {
+			next, ok := owner.Value.(*syntax.ObjectNode)
+			if !ok || !isBlock(next) {
+				v, err := setPath(owner.Value, path[i:], value)
+				if err != nil {
+					return err
+				}
+				return t.replace(*owner, syntax.ObjectPropertySyntax(owner.Syntax, owner.Key, v))
+			}
+			obj = next
+		}
+
+		key, ok := keyOf(step)
+		if !ok {
+			return fmt.Errorf("cannot index a mapping with %v", step)
+		}
+		kvp, ok := lookup(obj, step)
+		if !ok {
+			v, err := setPath(nil, path[i+1:], value)
+			if err != nil {
+				return err
+			}
+			return t.insert(obj, owner, syntax.ObjectProperty(syntax.String(key), v))
+		}
+		owner = &kvp
+	}
+	return t.replace(*owner, syntax.ObjectPropertySyntax(owner.Syntax, owner.Key, keepSyntax(owner.Value, value)))
+}
+
+// keepSyntax returns value with the syntax of old if both are scalars of the same type, so that a scalar keeps its
+// quoting and comments when it is replaced.
+func keepSyntax(old, value syntax.Node) syntax.Node {
+	if value.Syntax() != syntax.NoSyntax {
+		return value
+	}
+	switch value := value.(type) {
+	case *syntax.BooleanNode:
+		if _, ok := old.(*syntax.BooleanNode); ok {
+			return syntax.BooleanSyntax(old.Syntax(), value.Value())
+		}
+	case *syntax.NumberNode:
+		if _, ok := old.(*syntax.NumberNode); ok {
+			return syntax.NumberSyntax(old.Syntax(), value.Value())
+		}
+	case *syntax.StringNode:
+		if _, ok := old.(*syntax.StringNode); ok {
+			return syntax.StringSyntax(old.Syntax(), value.Value())
+		}
+	}
+	return value
+}
+
+// remove removes the value at path.
+func (t *Template) remove(path []interface{}) error {
+	obj, owner := t.root, (*syntax.ObjectPropertyDef)(nil)
+	for i, step := range path {
+		if owner != nil {
+			next, ok := owner.Value.(*syntax.ObjectNode)
+			if !ok || !isBlock(next) {
+				v, err := removePath(owner.Value, path[i:])
+				if err != nil {
+					return err
+				}
+				return t.replace(*owner, syntax.ObjectPropertySyntax(owner.Syntax, owner.Key, v))
+			}
+			obj = next
+		}
+
+		kvp, ok := lookup(obj, step)
+		if !ok {
+			return fmt.Errorf("%v does not exist", step)
+		}
+		if i == len(path)-1 {
+			if obj.Len() == 1 && owner != nil {
+				// Keep the key of the emptied mapping, which may have comments of its own.
+				return t.replace(*owner, syntax.ObjectPropertySyntax(owner.Syntax, owner.Key, syntax.Object()))
+			}
+			return t.delete(kvp, keyNode(obj.Index(0)) == keyNode(kvp))
+		}
+		owner = &kvp
+	}
+	return nil
+}
+
+// replace rewrites the block mapping entry old as kvp.
+func (t *Template) replace(old, kvp syntax.ObjectPropertyDef) error {
+	key := keyNode(old)
+	if key == nil {
+		return fmt.Errorf("%q has no source", old.Key.Value())
+	}
+	start, end := entrySpan(lines(t.source), key)
+	text, err := render(kvp, key.Column-1)
+	if err != nil {
+		return err
+	}
+	return t.check(t.reset(apply(t.source, splice{start, end, text})))
+}
+
+// insert adds kvp to the end of obj, whose entry is owner. If obj is not a block mapping, its entry is rewritten.
+func (t *Template) insert(obj *syntax.ObjectNode, owner *syntax.ObjectPropertyDef, kvp syntax.ObjectPropertyDef) error {
+	entries := make([]syntax.ObjectPropertyDef, 0, obj.Len()+1)
+	for i := 0; i < obj.Len(); i++ {
+		entries = append(entries, obj.Index(i))
+	}
+	entries = append(entries, kvp)
+
+	if !isBlock(obj) {
+		if owner != nil {
+			value := syntax.ObjectSyntax(obj.Syntax(), entries...)
+			return t.replace(*owner, syntax.ObjectPropertySyntax(owner.Syntax, owner.Key, value))
+		}
+		if obj.Len() != 0 {
+			return fmt.Errorf("cannot add %q to a flow-style mapping", kvp.Key.Value())
+		}
+	}
+
+	lines, indent, at := lines(t.source), 0, len(lines(t.source))
+	if obj.Len() != 0 {
+		indent = keyNode(obj.Index(0)).Column - 1
+		_, at = entrySpan(lines, keyNode(obj.Index(obj.Len()-1)))
+	}
+	text, err := render(kvp, indent)
+	if err != nil {
+		return err
+	}
+	// Set new top-level sections apart, as `pulumi-yaml fmt` does.
+	if indent == 0 && at > 0 && !isBlank(lines[at-1]) {
+		text = append([]byte("\n"), text...)
+	}
+	return t.check(t.reset(apply(t.source, splice{at, at, text})))
+}
+
+// delete removes the block mapping entry kvp and the comments before it. first is true if kvp is the first entry of
+// its mapping.
+func (t *Template) delete(kvp syntax.ObjectPropertyDef, first bool) error {
+	lines, key := lines(t.source), keyNode(kvp)
+	start, end := entrySpan(lines, key)
+	start = commentSpan(lines, start, key.Column-1)
+	// Don't leave a blank line at the start of the mapping, or two blank lines where the entry was.
+	if (first || start == 0 || isBlank(lines[start-1])) && end < len(lines) && isBlank(lines[end]) {
+		end++
+	}
+	return t.check(t.reset(apply(t.source, splice{start, end, nil})))
+}
+
+// renameReferences returns the splices that rewrite the references to the resource from in the block mapping entry
+// kvp.
+func renameReferences(lines [][]byte, kvp syntax.ObjectPropertyDef, from, to string) ([]splice, error) {
+	if obj, ok := kvp.Value.(*syntax.ObjectNode); ok && isBlock(obj) {
+		var splices []splice
+		for i := 0; i < obj.Len(); i++ {
+			s, err := renameReferences(lines, obj.Index(i), from, to)
+			if err != nil {
+				return nil, err
+			}
+			splices = append(splices, s...)
+		}
+		return splices, nil
+	}
+
+	value, changed := rewriteReferences(kvp.Value, from, to)
+	if !changed {
+		return nil, nil
+	}
+	return replaceValue(lines, kvp, value)
+}
+
+// renameFunctionReferences returns the splices that rewrite the references to the resource from in the `functions`
+// section kvp. The references in a function that has a parameter named from refer to the parameter, so it is skipped.
+func renameFunctionReferences(lines [][]byte, kvp syntax.ObjectPropertyDef, from, to string) ([]splice, error) {
+	obj, ok := kvp.Value.(*syntax.ObjectNode)
+	if !ok {
+		return nil, nil
+	}
+	if isBlock(obj) {
+		var splices []splice
+		for i := 0; i < obj.Len(); i++ {
+			if shadows(obj.Index(i), from) {
+				continue
+			}
+			s, err := renameReferences(lines, obj.Index(i), from, to)
+			if err != nil {
+				return nil, err
+			}
+			splices = append(splices, s...)
+		}
+		return splices, nil
+	}
+
+	changed := false
+	entries := make([]syntax.ObjectPropertyDef, obj.Len())
+	for i := range entries {
+		entries[i] = obj.Index(i)
+		if shadows(entries[i], from) {
+			continue
+		}
+		if v, c := rewriteReferences(entries[i].Value, from, to); c {
+			entries[i], changed = syntax.ObjectPropertySyntax(entries[i].Syntax, entries[i].Key, v), true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return replaceValue(lines, kvp, syntax.ObjectSyntax(obj.Syntax(), entries...))
+}
+
+// shadows returns true if the function declared by kvp has a parameter with the given name.
+func shadows(kvp syntax.ObjectPropertyDef, name string) bool {
+	function, ok := kvp.Value.(*syntax.ObjectNode)
+	if !ok {
+		return false
+	}
+	params, ok := lookup(function, field("parameters"))
+	if !ok {
+		return false
+	}
+	obj, ok := params.Value.(*syntax.ObjectNode)
+	if !ok {
+		return false
+	}
+	_, ok = lookup(obj, name)
+	return ok
+}
+
+// replaceValue returns the splice that rewrites the entry kvp with the given value.
+func replaceValue(lines [][]byte, kvp syntax.ObjectPropertyDef, value syntax.Node) ([]splice, error) {
+	key := keyNode(kvp)
+	start, end := entrySpan(lines, key)
+	text, err := render(syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, value), key.Column-1)
+	if err != nil {
+		return nil, err
+	}
+	return []splice{{start, end, text}}, nil
+}
+
+// rewriteReferences rewrites the references to the resource from in node as references to the resource to.
+func rewriteReferences(node syntax.Node, from, to string) (syntax.Node, bool) {
+	switch node := node.(type) {
+	case *syntax.ListNode:
+		changed := false
+		elements := make([]syntax.Node, node.Len())
+		for i := range elements {
+			e, c := rewriteReferences(node.Index(i), from, to)
+			elements[i], changed = e, changed || c
+		}
+		if !changed {
+			return node, false
+		}
+		return syntax.ListSyntax(node.Syntax(), elements...), true
+	case *syntax.ObjectNode:
+		changed := false
+		entries := make([]syntax.ObjectPropertyDef, node.Len())
+		for i := range entries {
+			kvp := node.Index(i)
+			v, c := rewriteReferences(kvp.Value, from, to)
+			entries[i], changed = syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, v), changed || c
+		}
+		if !changed {
+			return node, false
+		}
+		return syntax.ObjectSyntax(node.Syntax(), entries...), true
+	case *syntax.StringNode:
+		expr, diags := ast.InterpolateSyntax(node)
+		if diags.HasErrors() {
+			return node, false
+		}
+		changed := false
+		for _, part := range expr.Parts {
+			if part.Value == nil || len(part.Value.Accessors) == 0 || part.Value.RootName() != from {
+				continue
+			}
+			if _, ok := part.Value.Accessors[0].(*ast.PropertyName); ok && !strings.ContainsAny(to, ".[]}") {
+				part.Value.Accessors[0] = &ast.PropertyName{Name: to}
+			} else {
+				part.Value.Accessors[0] = &ast.PropertySubscript{Index: to}
+			}
+			changed = true
+		}
+		if !changed {
+			return node, false
+		}
+		return syntax.StringSyntax(node.Syntax(), expr.String()), true
+	default:
+		return node, false
+	}
+}
+
+// lookup returns the entry of obj with the given key, which is a string or a field.
+func lookup(obj *syntax.ObjectNode, step interface{}) (syntax.ObjectPropertyDef, bool) {
+	for i := 0; i < obj.Len(); i++ {
+		if kvp := obj.Index(i); matches(kvp.Key.Value(), step) {
+			return kvp, true
+		}
+	}
+	return syntax.ObjectPropertyDef{}, false
+}
+
+// matches returns true if step, which is a string or a field, names key.
+func matches(key string, step interface{}) bool {
+	switch step := step.(type) {
+	case string:
+		return key == step
+	case field:
+		return strings.EqualFold(key, string(step))
+	default:
+		return false
+	}
+}
+
+// keyOf returns the key named by a step of a path, if the step is a key rather than an index.
+func keyOf(step interface{}) (string, bool) {
+	switch step := step.(type) {
+	case string:
+		return step, true
+	case field:
+		return string(step), true
+	default:
+		return "", false
+	}
+}
+
+// setPath returns a copy of node with the value at path set to value. A nil node is treated as an empty mapping.
+func setPath(node syntax.Node, path []interface{}, value syntax.Node) (syntax.Node, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if _, ok := node.(*syntax.NullNode); ok {
+		node = nil
+	}
+
+	if key, ok := keyOf(path[0]); ok {
+		obj, ok := node.(*syntax.ObjectNode)
+		if node == nil {
+			obj = syntax.Object()
+		} else if !ok {
+			return nil, fmt.Errorf("cannot set %q: the value is not a mapping", key)
+		}
+		entries := make([]syntax.ObjectPropertyDef, 0, obj.Len()+1)
+		found := false
+		for i := 0; i < obj.Len(); i++ {
+			kvp := obj.Index(i)
+			if matches(kvp.Key.Value(), path[0]) && !found {
+				v, err := setPath(kvp.Value, path[1:], value)
+				if err != nil {
+					return nil, err
+				}
+				kvp, found = syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, v), true
+			}
+			entries = append(entries, kvp)
+		}
+		if !found {
+			v, err := setPath(nil, path[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, syntax.ObjectProperty(syntax.String(key), v))
+		}
+		return syntax.ObjectSyntax(obj.Syntax(), entries...), nil
+	}
+
+	index := path[0].(int)
+	list, ok := node.(*syntax.ListNode)
+	if node == nil {
+		list = syntax.List()
+	} else if !ok {
+		return nil, fmt.Errorf("cannot set [%d]: the value is not a list", index)
+	}
+	if index < 0 || index > list.Len() {
+		return nil, fmt.Errorf("cannot set [%d]: the list has %d elements", index, list.Len())
+	}
+	elements := make([]syntax.Node, list.Len(), list.Len()+1)
+	for i := range elements {
+		elements[i] = list.Index(i)
+	}
+	if index == list.Len() {
+		elements = append(elements, nil)
+	}
+	v, err := setPath(elements[index], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	elements[index] = v
+	return syntax.ListSyntax(list.Syntax(), elements...), nil
+}
+
+// removePath returns a copy of node without the value at path.
+func removePath(node syntax.Node, path []interface{}) (syntax.Node, error) {
+	if key, ok := keyOf(path[0]); ok {
+		obj, ok := node.(*syntax.ObjectNode)
+		if !ok {
+			return nil, fmt.Errorf("%q does not exist", key)
+		}
+		entries := make([]syntax.ObjectPropertyDef, 0, obj.Len())
+		found := false
+		for i := 0; i < obj.Len(); i++ {
+			kvp := obj.Index(i)
+			if matches(kvp.Key.Value(), path[0]) && !found {
+				found = true
+				if len(path) == 1 {
+					continue
+				}
+				v, err := removePath(kvp.Value, path[1:])
+				if err != nil {
+					return nil, err
+				}
+				kvp = syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, v)
+			}
+			entries = append(entries, kvp)
+		}
+		if !found {
+			return nil, fmt.Errorf("%q does not exist", key)
+		}
+		return syntax.ObjectSyntax(obj.Syntax(), entries...), nil
+	}
+
+	index := path[0].(int)
+	list, ok := node.(*syntax.ListNode)
+	if !ok || index < 0 || index >= list.Len() {
+		return nil, fmt.Errorf("[%d] does not exist", index)
+	}
+	elements := make([]syntax.Node, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		e := list.Index(i)
+		if i == index {
+			if len(path) == 1 {
+				continue
+			}
+			v, err := removePath(e, path[1:])
+			if err != nil {
+				return nil, err
+			}
+			e = v
+		}
+		elements = append(elements, e)
+	}
+	return syntax.ListSyntax(list.Syntax(), elements...), nil
+}
+
+// resourceOption returns the canonical name of a resource option, e.g. `dependsOn` for `DependsOn`.
+func resourceOption(name string) (string, bool) {
+	typ := reflect.TypeOf(ast.ResourceOptionsDecl{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous || !strings.EqualFold(f.Name, name) {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(f.Name)
+		return string(unicode.ToLower(r)) + f.Name[size:], true
+	}
+	return "", false
+}
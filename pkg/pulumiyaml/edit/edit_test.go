@@ -0,0 +1,523 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+)
+
+const template = `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`
+
+func TestEdit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		edit     func(t *Template) error
+		expected string
+	}{
+		{
+			name: "add resource",
+			edit: func(t *Template) error {
+				return t.AddResource("logs", "aws:s3:Bucket", syntax.Object(
+					syntax.ObjectProperty(syntax.String("acl"), syntax.String("log-delivery-write")),
+				))
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+  logs:
+    type: aws:s3:Bucket
+    properties:
+      acl: log-delivery-write
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "remove resource",
+			edit: func(t *Template) error {
+				return t.RemoveResource("bucket")
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "set property",
+			edit: func(t *Template) error {
+				return t.SetProperty("bucket", "website.indexDocument", syntax.String("home.html"))
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: home.html # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "add property",
+			edit: func(t *Template) error {
+				return t.SetProperty("bucket", "website.errorDocument", syntax.String("404.html"))
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+        errorDocument: 404.html
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "add tag",
+			edit: func(t *Template) error {
+				return t.SetProperty("bucket", "tags.owner", syntax.String("web"))
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: {env: dev, owner: web}
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "remove property",
+			edit: func(t *Template) error {
+				return t.RemoveProperty("index", "source")
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "set resource option",
+			edit: func(t *Template) error {
+				return t.SetResourceOption("bucket", "Protect", syntax.Boolean(true))
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+    options:
+      protect: true
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "replace resource option",
+			edit: func(t *Template) error {
+				return t.SetResourceOption("index", "dependsOn", syntax.List())
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn: []
+
+outputs:
+  # Where the site is served from.
+  url: http://${bucket.websiteEndpoint}
+`,
+		},
+		{
+			name: "rename resource",
+			edit: func(t *Template) error {
+				return t.RenameResource("bucket", "site-bucket")
+			},
+			expected: `# A static website.
+name: site
+runtime: yaml
+
+resources:
+  # The bucket that serves the site.
+  site-bucket:
+    type: aws:s3:Bucket
+    properties:
+      website:
+        indexDocument: index.html   # the landing page
+
+      tags: { env: dev }
+
+  # The site's content.
+  index:
+    type: aws:s3:BucketObject
+    properties:
+      bucket: ${site-bucket}
+      source:
+        fn::fileAsset: ./www/index.html
+    options:
+      dependsOn:
+        - ${site-bucket}
+
+outputs:
+  # Where the site is served from.
+  url: http://${site-bucket.websiteEndpoint}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, diags := Parse("Pulumi.yaml", []byte(template))
+			require.False(t, diags.HasErrors(), diags)
+			require.NoError(t, tt.edit(tmpl))
+			assert.Equal(t, tt.expected, string(tmpl.Bytes()))
+		})
+	}
+}
+
+func TestEditEmpty(t *testing.T) {
+	t.Parallel()
+
+	tmpl, diags := Parse("Pulumi.yaml", []byte("name: test\nruntime: yaml\n"))
+	require.False(t, diags.HasErrors(), diags)
+	require.NoError(t, tmpl.AddResource("bucket", "aws:s3:Bucket", nil))
+	require.NoError(t, tmpl.SetProperty("bucket", "tags.env", syntax.String("dev")))
+	require.NoError(t, tmpl.SetResourceOption("bucket", "version", syntax.String("6.0.0")))
+	assert.Equal(t, `name: test
+runtime: yaml
+
+resources:
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      tags:
+        env: dev
+    options:
+      version: 6.0.0
+`, string(tmpl.Bytes()))
+}
+
+func TestEditErrors(t *testing.T) {
+	t.Parallel()
+
+	tmpl, diags := Parse("Pulumi.yaml", []byte(template))
+	require.False(t, diags.HasErrors(), diags)
+
+	assert.EqualError(t, tmpl.AddResource("bucket", "aws:s3:Bucket", nil), `resource "bucket" already exists`)
+	assert.EqualError(t, tmpl.RemoveResource("logs"), `resource "logs" does not exist`)
+	assert.EqualError(t, tmpl.RenameResource("bucket", "index"), `resource "index" already exists`)
+	assert.EqualError(t, tmpl.SetResourceOption("bucket", "retain", syntax.Boolean(true)),
+		`unknown resource option "retain"`)
+	assert.EqualError(t, tmpl.SetProperty("index", "source[0]", syntax.String("x")),
+		"cannot index a mapping with 0")
+	assert.Equal(t, template, string(tmpl.Bytes()))
+}
+
+func TestRenameResource(t *testing.T) {
+	t.Parallel()
+
+	tmpl, diags := Parse("Pulumi.yaml", []byte(`resources:
+  "bucket":
+    type: aws:s3:Bucket
+variables:
+  # Both forms of reference are rewritten.
+  arns: [ "${bucket.arn}", !Ref bucket ]
+  label:
+    fn::join: ["-", ["$${bucket}", "${bucket.id}"]]
+components:
+  site:
+    resources:
+      bucket:
+        type: aws:s3:Bucket
+    outputs:
+      id: ${bucket.id}
+`))
+	require.False(t, diags.HasErrors(), diags)
+	require.NoError(t, tmpl.RenameResource("bucket", "logs"))
+	assert.Equal(t, `resources:
+  "logs":
+    type: aws:s3:Bucket
+variables:
+  # Both forms of reference are rewritten.
+  arns: ["${logs.arn}", '${logs}']
+  label:
+    fn::join: ["-", ["$${bucket}", "${logs.id}"]]
+components:
+  site:
+    resources:
+      bucket:
+        type: aws:s3:Bucket
+    outputs:
+      id: ${bucket.id}
+`, string(tmpl.Bytes()))
+
+	// A resource whose entry refers to itself is rewritten whole, with its key and references renamed together.
+	tmpl, diags = Parse("Pulumi.yaml", []byte(`resources:
+  bucket: { type: aws:s3:Bucket, properties: { tags: { self: "${bucket.urn}" } } }
+`))
+	require.False(t, diags.HasErrors(), diags)
+	require.NoError(t, tmpl.RenameResource("bucket", "logs"))
+	assert.Equal(t, `resources:
+  logs: {type: 'aws:s3:Bucket', properties: {tags: {self: "${logs.urn}"}}}
+`, string(tmpl.Bytes()))
+
+	// References in a function that declares a parameter of the same name refer to the parameter.
+	tmpl, diags = Parse("Pulumi.yaml", []byte(`resources:
+  bucket:
+    type: aws:s3:Bucket
+functions:
+  tagOf:
+    parameters:
+      bucket:
+        default: ${bucket}
+    body: ${bucket.tags}
+  arnOf:
+    body: ${bucket.arn}
+  inline: { parameters: { bucket: {} }, body: "${bucket.id}" }
+`))
+	require.False(t, diags.HasErrors(), diags)
+	require.NoError(t, tmpl.RenameResource("bucket", "logs"))
+	assert.Equal(t, `resources:
+  logs:
+    type: aws:s3:Bucket
+functions:
+  tagOf:
+    parameters:
+      bucket:
+        default: ${bucket}
+    body: ${bucket.tags}
+  arnOf:
+    body: ${logs.arn}
+  inline: { parameters: { bucket: {} }, body: "${bucket.id}" }
+`, string(tmpl.Bytes()))
+
+	tmpl, diags = Parse("Pulumi.yaml", []byte(`resources:
+  bucket:
+    type: aws:s3:Bucket
+functions: { id: { parameters: { bucket: {} }, body: "${bucket.id}" }, arn: { body: "${bucket.arn}" } }
+`))
+	require.False(t, diags.HasErrors(), diags)
+	require.NoError(t, tmpl.RenameResource("bucket", "logs"))
+	assert.Equal(t, `resources:
+  logs:
+    type: aws:s3:Bucket
+functions: {id: {parameters: {bucket: {}}, body: "${bucket.id}"}, arn: {body: "${logs.arn}"}}
+`, string(tmpl.Bytes()))
+
+	const declared = `config:
+  region:
+    type: string
+variables:
+  prefix: logs
+resources:
+  bucket:
+    type: aws:s3:Bucket
+  index:
+    type: aws:s3:BucketObject
+`
+	tmpl, diags = Parse("Pulumi.yaml", []byte(declared))
+	require.False(t, diags.HasErrors(), diags)
+	assert.EqualError(t, tmpl.RenameResource("bucket", "region"), `config "region" already exists`)
+	assert.EqualError(t, tmpl.RenameResource("bucket", "prefix"), `variable "prefix" already exists`)
+	assert.EqualError(t, tmpl.RenameResource("bucket", "index"), `resource "index" already exists`)
+	assert.Equal(t, declared, string(tmpl.Bytes()))
+}
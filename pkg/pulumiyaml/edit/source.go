@@ -0,0 +1,149 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package edit
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+)
+
+// A splice replaces the lines [start, end) of the source of a template with text.
+type splice struct {
+	start, end int
+	text       []byte
+}
+
+// lines splits source into lines, each with its line terminator.
+func lines(source []byte) [][]byte {
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// apply applies splices to source. The splices must not overlap.
+func apply(source []byte, splices ...splice) []byte {
+	lines := lines(source)
+
+	var b bytes.Buffer
+	line := 0
+	for _, s := range splices {
+		for ; line < s.start; line++ {
+			b.Write(lines[line])
+		}
+		if len(s.text) != 0 && b.Len() != 0 && b.Bytes()[b.Len()-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		b.Write(s.text)
+		line = s.end
+	}
+	for ; line < len(lines); line++ {
+		b.Write(lines[line])
+	}
+	return b.Bytes()
+}
+
+// keyNode returns the YAML node of the key of a mapping entry.
+func keyNode(kvp syntax.ObjectPropertyDef) *yaml.Node {
+	if s, ok := kvp.Syntax.(encoding.YAMLSyntax); ok {
+		return s.Node
+	}
+	return nil
+}
+
+// isBlock returns true if obj is a non-empty, block-style mapping, whose entries each start on their own line.
+func isBlock(obj *syntax.ObjectNode) bool {
+	s, ok := obj.Syntax().(encoding.YAMLSyntax)
+	return ok && s.Node != nil && s.Kind == yaml.MappingNode && s.Style&yaml.FlowStyle == 0 && obj.Len() != 0
+}
+
+// isPlain returns true if s is written as a plain scalar, without quotes.
+func isPlain(s string) bool {
+	text, err := yaml.Marshal(s)
+	return err == nil && string(text) == s+"\n"
+}
+
+// isBlank returns true if line holds only whitespace.
+func isBlank(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// indentation returns the number of spaces that line is indented by.
+func indentation(line []byte) int {
+	return len(line) - len(bytes.TrimLeft(line, " "))
+}
+
+// entrySpan returns the lines [start, end) of the block mapping entry whose key is key: the line of the key, and
+// the lines of its value that follow it. The comments before the key are not part of the span, and neither are the
+// blank lines after the value.
+func entrySpan(lines [][]byte, key *yaml.Node) (int, int) {
+	start, column := key.Line-1, key.Column-1
+
+	end := start + 1
+	for ; end < len(lines); end++ {
+		line := lines[end]
+		if isBlank(line) {
+			continue
+		}
+		indent := indentation(line)
+		// A block sequence may be indented as far as its key.
+		if indent < column || indent == column && !bytes.HasPrefix(line[indent:], []byte("- ")) {
+			break
+		}
+	}
+	for end > start+1 && isBlank(lines[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+// commentSpan returns the first line of the comments that directly precede the line start and are indented by
+// column.
+func commentSpan(lines [][]byte, start, column int) int {
+	for start > 0 {
+		line := lines[start-1]
+		if isBlank(line) || indentation(line) != column || line[column] != '#' {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// render renders a mapping entry as block-style YAML, indented by indent spaces. The comments before the key of the
+// entry are not rendered, as they lie outside of the entry's span.
+func render(kvp syntax.ObjectPropertyDef, indent int) ([]byte, error) {
+	node, diags := encoding.MarshalYAML(syntax.Object(kvp))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	node.Content[0].HeadComment = ""
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	if indent == 0 {
+		return b.Bytes(), nil
+	}
+
+	prefix := bytes.Repeat([]byte(" "), indent)
+	var indented bytes.Buffer
+	for _, line := range lines(b.Bytes()) {
+		if !isBlank(line) {
+			indented.Write(prefix)
+		}
+		indented.Write(line)
+	}
+	return indented.Bytes(), nil
+}
@@ -3,6 +3,8 @@
 package pulumiyaml
 
 import (
+	"slices"
+
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
 )
@@ -10,33 +12,32 @@ import (
 // GetResourceDependencies gets the full set of implicit and explicit dependencies for a Resource.
 func GetResourceDependencies(r *ast.ResourceDecl) []*ast.StringExpr {
 	var deps []*ast.StringExpr
+	for _, x := range resourceExprs(r) {
+		getExpressionDependencies(&deps, x)
+	}
+	if r.Count != nil || r.ForEach != nil {
+		// Within a ranged resource `range` refers to the current element, not to another node.
+		deps = slices.DeleteFunc(deps, isRangeReference)
+	}
+	return deps
+}
 
+// resourceExprs returns the expressions of a resource that may refer to other nodes.
+func resourceExprs(r *ast.ResourceDecl) []ast.Expr {
+	exprs := []ast.Expr{r.Condition, r.Count, r.ForEach}
 	if r.Properties.PropertyMap != nil {
 		for _, kvp := range r.Properties.PropertyMap.Entries {
-			getExpressionDependencies(&deps, kvp.Value)
+			exprs = append(exprs, kvp.Value)
 		}
-	} else if r.Properties.Expr != nil {
-		getExpressionDependencies(&deps, r.Properties.Expr)
-	}
-	if r.Options.DependsOn != nil {
-		getExpressionDependencies(&deps, r.Options.DependsOn)
-	}
-	if r.Options.Parent != nil {
-		getExpressionDependencies(&deps, r.Options.Parent)
+	} else {
+		exprs = append(exprs, r.Properties.Expr)
 	}
-	if r.Options.Provider != nil {
-		getExpressionDependencies(&deps, r.Options.Provider)
-	}
-	if r.Options.Providers != nil {
-		getExpressionDependencies(&deps, r.Options.Providers)
-	}
-	if r.Get.Id != nil {
-		getExpressionDependencies(&deps, r.Get.Id)
-	}
-	if r.Options.Aliases != nil {
-		getExpressionDependencies(&deps, r.Options.Aliases)
-	}
-	return deps
+	return append(exprs, r.Options.DependsOn, r.Options.Parent, r.Options.Provider, r.Options.Providers, r.Get.Id,
+		r.Options.Aliases)
+}
+
+func isRangeReference(dep *ast.StringExpr) bool {
+	return dep.Value == RangeVarName
 }
 
 // GetVariableDependencies gets the full set of implicit and explicit dependencies for a Variable.
@@ -46,6 +47,26 @@ func GetVariableDependencies(e ast.VariablesMapEntry) []*ast.StringExpr {
 	return deps
 }
 
+// GetFunctionDependencies gets the dependencies of a function's body and parameter defaults. Its
+// parameters are not dependencies, as they shadow any node with the same name.
+func GetFunctionDependencies(f *ast.FunctionDecl) []*ast.StringExpr {
+	if f == nil {
+		return nil
+	}
+	var deps []*ast.StringExpr
+	for _, param := range f.Parameters.Entries {
+		if param.Value != nil && param.Value.Default != nil {
+			getExpressionDependencies(&deps, param.Value.Default)
+		}
+	}
+	getExpressionDependencies(&deps, f.Body)
+	return slices.DeleteFunc(deps, func(dep *ast.StringExpr) bool {
+		return slices.ContainsFunc(f.Parameters.Entries, func(param ast.ConfigMapEntry) bool {
+			return param.Key.Value == dep.Value
+		})
+	})
+}
+
 // getResourceDependencies gets the resource dependencies of an expression.
 func getExpressionDependencies(deps *[]*ast.StringExpr, x ast.Expr) {
 	switch x := x.(type) {
@@ -81,7 +102,96 @@ func getExpressionDependencies(deps *[]*ast.StringExpr, x ast.Expr) {
 		if x.CallOpts.DependsOn != nil {
 			getExpressionDependencies(deps, x.CallOpts.DependsOn)
 		}
+	case *ast.ForExpr:
+		getExpressionDependencies(deps, x.In)
+		// Within the other arguments `range` refers to the current element, not to another node.
+		var body []*ast.StringExpr
+		for _, e := range []ast.Expr{x.Key, x.Value, x.If} {
+			getExpressionDependencies(&body, e)
+		}
+		*deps = append(*deps, slices.DeleteFunc(body, isRangeReference)...)
+	case *ast.CallExpr:
+		if x.Function != nil {
+			*deps = append(*deps, functionKeyExpr(x.Function))
+		}
+		// The argument of a shorthand call may not be an object if it calls no declared function.
+		getExpressionDependencies(deps, x.Args())
 	case ast.BuiltinExpr:
 		getExpressionDependencies(deps, x.Args())
 	}
 }
+
+// findRangeReference returns a reference to `range` in t that refers to the current element of a ranged resource or
+// of fn::for rather than to a node named range, if there is one.
+func findRangeReference(t ast.Template) *ast.StringExpr {
+	for _, kvp := range t.GetResources().Entries {
+		ranged := kvp.Value.Count != nil || kvp.Value.ForEach != nil
+		for _, x := range resourceExprs(kvp.Value) {
+			if ref := findExprRangeReference(x, ranged); ref != nil {
+				return ref
+			}
+		}
+	}
+	var exprs []ast.Expr
+	for _, kvp := range t.GetVariables().Entries {
+		exprs = append(exprs, kvp.Value)
+	}
+	for _, kvp := range t.GetConditions().Entries {
+		exprs = append(exprs, kvp.Value)
+	}
+	for _, kvp := range t.GetOutputs().Entries {
+		exprs = append(exprs, kvp.Value)
+	}
+	for _, kvp := range t.GetFunctions().Entries {
+		if kvp.Value != nil {
+			exprs = append(exprs, kvp.Value.Body)
+		}
+	}
+	for _, x := range exprs {
+		if ref := findExprRangeReference(x, false); ref != nil {
+			return ref
+		}
+	}
+	return nil
+}
+
+// findExprRangeReference returns a reference to `range` in x that refers to the current element of an enclosing
+// fn::for, or of the resource that declares x if ranged is true.
+func findExprRangeReference(x ast.Expr, ranged bool) *ast.StringExpr {
+	if ranged {
+		var deps []*ast.StringExpr
+		getExpressionDependencies(&deps, x)
+		if i := slices.IndexFunc(deps, isRangeReference); i >= 0 {
+			return deps[i]
+		}
+	}
+
+	var children []ast.Expr
+	switch x := x.(type) {
+	case *ast.ListExpr:
+		children = x.Elements
+	case *ast.ObjectExpr:
+		for _, kvp := range x.Entries {
+			children = append(children, kvp.Key, kvp.Value)
+		}
+	case *ast.InvokeExpr:
+		children = []ast.Expr{x.Args(), x.CallOpts.Parent, x.CallOpts.Provider, x.CallOpts.DependsOn}
+	case *ast.ForExpr:
+		if ref := findExprRangeReference(x.In, ranged); ref != nil {
+			return ref
+		}
+		for _, e := range []ast.Expr{x.Key, x.Value, x.If} {
+			if ref := findExprRangeReference(e, true); ref != nil {
+				return ref
+			}
+		}
+	case ast.BuiltinExpr:
+		children = []ast.Expr{x.Args()}
+	}
+	for _, e := range children {
+		if ref := findExprRangeReference(e, ranged); ref != nil {
+			return ref
+		}
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+// Package format canonicalizes the layout of Pulumi YAML templates. A template is decoded into syntax nodes,
+// normalized, and encoded again with encoding.MarshalYAML, which keeps the comments, quoting and flow style of each
+// node. The normalizations are:
+//
+//   - the top-level sections of the template, and the fields of each resource, are written in a canonical order,
+//   - section, field and builtin names are written in their canonical case, e.g. `fn::toJSON` for `Fn::ToJson`,
+//   - the properties of each resource are sorted by name,
+//   - nested nodes are indented by two spaces, and top-level sections are set apart by blank lines.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+)
+
+// settings stands for the top-level keys that are not template sections, such as the settings of a project, in
+// sectionOrder.
+const settings = ""
+
+// sectionOrder is the canonical order of the top-level sections of a template. Keys that are not listed are
+// settings, which keep their original order.
+var sectionOrder = []string{
+	"name", "namespace", "runtime", "description", "version", settings, "pulumi", "imports", "config",
+	"configuration", "mappings", "functions", "conditions", "variables", "resources", "outputs", "types", "components",
+}
+
+// componentOrder is the canonical order of the fields of a component.
+var componentOrder = []string{"description", "pulumi", "inputs", "variables", "resources", "outputs", "methods"}
+
+// resourceOrder is the canonical order of the fields of a resource.
+var resourceOrder = []string{
+	"type", "name", "defaultProvider", "condition", "count", "forEach", "properties", "options", "get",
+}
+
+// Format formats the Pulumi YAML template in source. Formatting a formatted template leaves it unchanged.
+func Format(filename string, source []byte) ([]byte, syntax.Diagnostics) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(source, &doc); err != nil {
+		return nil, syntax.Diagnostics{syntax.Error(nil, err.Error(), "")}
+	}
+	if doc.Kind == 0 {
+		return source, nil
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 {
+		return nil, syntax.Diagnostics{syntax.Error(nil, fmt.Sprintf("'%s' must hold a single document", filename), "")}
+	}
+
+	node, diags := encoding.UnmarshalYAML(filename, doc.Content[0], pulumiyaml.TagDecoder)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	template, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		return nil, append(diags, syntax.Error(nil, fmt.Sprintf("Top level of '%s' must be an object", filename), ""))
+	}
+
+	content, mdiags := encoding.MarshalYAML(formatTemplate(template))
+	diags.Extend(mdiags...)
+	if mdiags.HasErrors() {
+		return nil, diags
+	}
+	doc.Content = []*yaml.Node{content}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, append(diags, syntax.Error(nil, err.Error(), ""))
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, append(diags, syntax.Error(nil, err.Error(), ""))
+	}
+	return separateSections(b.Bytes()), diags
+}
+
+// formatTemplate orders the sections of a template and formats each of them.
+func formatTemplate(template *syntax.ObjectNode) *syntax.ObjectNode {
+	entries := objectEntries(template)
+	for i, kvp := range entries {
+		key := canonicalKey(kvp.Key, sectionOrder)
+		value := formatExpr(kvp.Value)
+		switch strings.ToLower(key.Value()) {
+		case "resources":
+			value = mapEntries(value, formatResource)
+		case "components":
+			value = mapEntries(value, formatComponent)
+		}
+		entries[i] = syntax.ObjectPropertySyntax(kvp.Syntax, key, value)
+	}
+	return syntax.ObjectSyntax(template.Syntax(), pinHeader(entries, sortByOrder(slices.Clone(entries), sectionOrder))...)
+}
+
+// pinHeader keeps the comments at the top of a template there when its sections are reordered: yaml.v3 attaches the
+// comments before the first section, such as a header describing the file, to the key of that section. entries are
+// the sections of the template in their original order, and sorted in their canonical order.
+func pinHeader(entries, sorted []syntax.ObjectPropertyDef) []syntax.ObjectPropertyDef {
+	if len(entries) == 0 || sorted[0].Key == entries[0].Key {
+		return sorted
+	}
+	first := entries[0].Key
+	header := comments(first).HeadComment
+	if header == "" {
+		return sorted
+	}
+	for i, kvp := range sorted {
+		switch kvp.Key {
+		case first:
+			sorted[i].Key = withComments(kvp.Key, func(n *yaml.Node) { n.HeadComment = "" })
+		case sorted[0].Key:
+			sorted[i].Key = withComments(kvp.Key, func(n *yaml.Node) { n.HeadComment = joinComments(header, n.HeadComment) })
+		}
+	}
+	return sorted
+}
+
+// formatComponent orders the fields of a component and formats its resources and methods.
+func formatComponent(component syntax.Node) syntax.Node {
+	obj, ok := component.(*syntax.ObjectNode)
+	if !ok {
+		return component
+	}
+	entries := objectEntries(obj)
+	for i, kvp := range entries {
+		key := canonicalKey(kvp.Key, componentOrder)
+		value := kvp.Value
+		switch key.Value() {
+		case "resources":
+			value = mapEntries(value, formatResource)
+		case "methods":
+			// A method has the same fields as a component, less its resources.
+			value = mapEntries(value, formatComponent)
+		}
+		entries[i] = syntax.ObjectPropertySyntax(kvp.Syntax, key, value)
+	}
+	return syntax.ObjectSyntax(obj.Syntax(), sortByOrder(entries, componentOrder)...)
+}
+
+// formatResource orders the fields of a resource and sorts its properties.
+func formatResource(resource syntax.Node) syntax.Node {
+	obj, ok := resource.(*syntax.ObjectNode)
+	if !ok {
+		return resource
+	}
+	entries := objectEntries(obj)
+	for i, kvp := range entries {
+		key := canonicalKey(kvp.Key, resourceOrder)
+		value := kvp.Value
+		if properties, ok := value.(*syntax.ObjectNode); ok && key.Value() == "properties" && !isBuiltin(properties) {
+			sorted := sortEntries(objectEntries(properties), func(a, b syntax.ObjectPropertyDef) int {
+				return strings.Compare(a.Key.Value(), b.Key.Value())
+			})
+			value = syntax.ObjectSyntax(properties.Syntax(), sorted...)
+		}
+		entries[i] = syntax.ObjectPropertySyntax(kvp.Syntax, key, value)
+	}
+	return syntax.ObjectSyntax(obj.Syntax(), sortByOrder(entries, resourceOrder)...)
+}
+
+// formatExpr writes the names of the builtins in an expression in their canonical case.
+func formatExpr(node syntax.Node) syntax.Node {
+	switch node := node.(type) {
+	case *syntax.ListNode:
+		elements := make([]syntax.Node, node.Len())
+		for i := range elements {
+			elements[i] = formatExpr(node.Index(i))
+		}
+		return syntax.ListSyntax(node.Syntax(), elements...)
+	case *syntax.ObjectNode:
+		entries := objectEntries(node)
+		for i, kvp := range entries {
+			key := kvp.Key
+			if name, ok := ast.BuiltinName(key.Value()); ok && name != key.Value() {
+				key = syntax.StringSyntax(key.Syntax(), name)
+			}
+			entries[i] = syntax.ObjectPropertySyntax(kvp.Syntax, key, formatExpr(kvp.Value))
+		}
+		return syntax.ObjectSyntax(node.Syntax(), entries...)
+	default:
+		return node
+	}
+}
+
+// isBuiltin returns true if obj is a call to a builtin, whose arguments are not sorted.
+func isBuiltin(obj *syntax.ObjectNode) bool {
+	return obj.Len() == 1 && strings.HasPrefix(strings.ToLower(obj.Index(0).Key.Value()), "fn::")
+}
+
+// mapEntries applies f to the value of each entry of a mapping.
+func mapEntries(node syntax.Node, f func(syntax.Node) syntax.Node) syntax.Node {
+	obj, ok := node.(*syntax.ObjectNode)
+	if !ok {
+		return node
+	}
+	entries := objectEntries(obj)
+	for i, kvp := range entries {
+		entries[i] = syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, f(kvp.Value))
+	}
+	return syntax.ObjectSyntax(obj.Syntax(), entries...)
+}
+
+// objectEntries returns a copy of the entries of a mapping.
+func objectEntries(obj *syntax.ObjectNode) []syntax.ObjectPropertyDef {
+	entries := make([]syntax.ObjectPropertyDef, obj.Len())
+	for i := range entries {
+		entries[i] = obj.Index(i)
+	}
+	return entries
+}
+
+// canonicalKey returns key in the case it is spelled in order, if it is listed there.
+func canonicalKey(key *syntax.StringNode, order []string) *syntax.StringNode {
+	for _, name := range order {
+		if name != key.Value() && strings.EqualFold(name, key.Value()) {
+			return syntax.StringSyntax(key.Syntax(), name)
+		}
+	}
+	return key
+}
+
+// sortByOrder sorts entries into the given order. Entries that are not listed are placed with settings, if it is
+// listed, or else after the listed entries, in their original order.
+func sortByOrder(entries []syntax.ObjectPropertyDef, order []string) []syntax.ObjectPropertyDef {
+	rank := func(kvp syntax.ObjectPropertyDef) int {
+		for i, name := range order {
+			if name != settings && strings.EqualFold(name, kvp.Key.Value()) {
+				return i
+			}
+		}
+		if i := slices.Index(order, settings); i != -1 {
+			return i
+		}
+		return len(order)
+	}
+	return sortEntries(entries, func(a, b syntax.ObjectPropertyDef) int {
+		return rank(a) - rank(b)
+	})
+}
+
+// sortEntries stably sorts the entries of a mapping with cmp. yaml.v3 attaches the comments after the last entry of a
+// mapping, which close the mapping as a whole, to the key of that entry; they are moved to the entry that is last once
+// sorted.
+func sortEntries(
+	entries []syntax.ObjectPropertyDef, cmp func(a, b syntax.ObjectPropertyDef) int,
+) []syntax.ObjectPropertyDef {
+	if len(entries) == 0 {
+		return entries
+	}
+	last := entries[len(entries)-1].Key
+	slices.SortStableFunc(entries, cmp)
+
+	footer := comments(last).FootComment
+	if footer == "" || entries[len(entries)-1].Key == last {
+		return entries
+	}
+	for i, kvp := range entries {
+		if kvp.Key == last {
+			entries[i].Key = withComments(kvp.Key, func(n *yaml.Node) { n.FootComment = "" })
+		}
+	}
+	end := &entries[len(entries)-1]
+	end.Key = withComments(end.Key, func(n *yaml.Node) { n.FootComment = joinComments(n.FootComment, footer) })
+	return entries
+}
+
+// comments returns the YAML node that holds the comments of a key, which is empty if the key was not decoded from
+// YAML.
+func comments(key *syntax.StringNode) yaml.Node {
+	if s, ok := key.Syntax().(encoding.YAMLSyntax); ok && s.Node != nil {
+		return *s.Node
+	}
+	return yaml.Node{}
+}
+
+// withComments returns a copy of a key whose comments are updated by f. Keys that were not decoded from YAML have no
+// comments, and are returned as they are.
+func withComments(key *syntax.StringNode, f func(n *yaml.Node)) *syntax.StringNode {
+	s, ok := key.Syntax().(encoding.YAMLSyntax)
+	if !ok || s.Node == nil {
+		return key
+	}
+	n := *s.Node
+	f(&n)
+	s.Node = &n
+	return syntax.StringSyntax(s, key.Value())
+}
+
+// joinComments joins two blocks of comments, either of which may be empty.
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// separateSections inserts a blank line before each top-level entry of a YAML mapping that spans several lines, and
+// after each one, so that the sections of a template stand apart while short settings such as `name` and `runtime`
+// stay together. The comments before an entry are kept with it.
+func separateSections(text []byte) []byte {
+	lines := bytes.SplitAfter(text, []byte("\n"))
+
+	var b bytes.Buffer
+	for i, line := range lines {
+		if i > 0 && isTopLevel(line) && !isComment(lines[i-1]) && !isBlank(lines[i-1]) {
+			k := i
+			for k < len(lines) && isComment(lines[k]) {
+				k++
+			}
+			block := k+1 < len(lines) && isIndented(lines[k+1])
+			if block || isIndented(lines[i-1]) {
+				b.WriteByte('\n')
+			}
+		}
+		b.Write(line)
+	}
+	return b.Bytes()
+}
+
+// isTopLevel returns true if line starts a top-level entry, or a comment before one.
+func isTopLevel(line []byte) bool {
+	return !isBlank(line) && !isIndented(line)
+}
+
+// isIndented returns true if line belongs to a nested node.
+func isIndented(line []byte) bool {
+	return len(line) != 0 && (line[0] == ' ' || line[0] == '-')
+}
+
+// isComment returns true if line is an unindented comment.
+func isComment(line []byte) bool {
+	return len(line) != 0 && line[0] == '#'
+}
+
+// isBlank returns true if line holds only whitespace.
+func isBlank(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
@@ -0,0 +1,289 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name: "formatted",
+			input: `name: test
+runtime: yaml
+
+resources:
+  bucket:
+    type: aws:s3:Bucket
+`,
+			expected: `name: test
+runtime: yaml
+
+resources:
+  bucket:
+    type: aws:s3:Bucket
+`,
+		},
+		{
+			name: "sections",
+			input: `outputs:
+  name: ${bucket.id}
+resources:
+  bucket:
+    type: aws:s3:Bucket
+config:
+  env: dev
+runtime: yaml
+name: test
+`,
+			expected: `name: test
+runtime: yaml
+
+config:
+  env: dev
+
+resources:
+  bucket:
+    type: aws:s3:Bucket
+
+outputs:
+  name: ${bucket.id}
+`,
+		},
+		{
+			name: "project settings keep their order",
+			input: `backend:
+  url: file://~
+main: src/
+name: test
+runtime: yaml
+`,
+			expected: `name: test
+runtime: yaml
+
+backend:
+  url: file://~
+
+main: src/
+`,
+		},
+		{
+			name: "resources",
+			input: `resources:
+  bucket:
+    Options:
+      protect: true
+    Properties:
+      website:
+        indexDocument: index.html
+      acl: private
+      tags: {b: 2, a: 1}
+    Type: aws:s3:Bucket
+`,
+			expected: `resources:
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      acl: private
+      tags: {b: 2, a: 1}
+      website:
+        indexDocument: index.html
+    options:
+      protect: true
+`,
+		},
+		{
+			name: "builtins",
+			input: `variables:
+  policy:
+    Fn::ToJSON:
+      Version: "2012-10-17"
+  joined:
+    FN::join: [",", [a, b]]
+  literal:
+    fn::secret: !Base64 hello
+  difference:
+    Fn::Sub: [3, 1]
+`,
+			expected: `variables:
+  policy:
+    fn::toJSON:
+      Version: "2012-10-17"
+  joined:
+    fn::join: [",", [a, b]]
+  literal:
+    fn::secret: !Base64 hello
+  difference:
+    fn::sub: [3, 1]
+`,
+		},
+		{
+			name: "comments",
+			input: `# A static website.
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    properties:
+      # Served from the root.
+      website: index.html # not a directory
+      acl: private
+    type: aws:s3:Bucket # the bucket type
+name: site # the project
+`,
+			expected: `# A static website.
+
+name: site # the project
+
+resources:
+  # The bucket that serves the site.
+  bucket:
+    type: aws:s3:Bucket # the bucket type
+    properties:
+      acl: private
+      # Served from the root.
+      website: index.html # not a directory
+`,
+		},
+		{
+			name: "indentation",
+			input: `resources:
+    bucket:
+        type: aws:s3:Bucket
+        options:
+            dependsOn:
+            - ${other}
+`,
+			expected: `resources:
+  bucket:
+    type: aws:s3:Bucket
+    options:
+      dependsOn:
+        - ${other}
+`,
+		},
+		{
+			name: "components",
+			input: `name: plugin
+runtime: yaml
+components:
+  site:
+    resources:
+      bucket:
+        properties:
+          website: index.html
+          acl: private
+        type: aws:s3:Bucket
+    methods:
+      getUrl:
+        outputs:
+          url: ${self.url}
+        description: The URL of the site.
+    Inputs:
+      name:
+        type: string
+`,
+			expected: `name: plugin
+runtime: yaml
+
+components:
+  site:
+    inputs:
+      name:
+        type: string
+    resources:
+      bucket:
+        type: aws:s3:Bucket
+        properties:
+          acl: private
+          website: index.html
+    methods:
+      getUrl:
+        description: The URL of the site.
+        outputs:
+          url: ${self.url}
+`,
+		},
+		{
+			name: "foot comments stay with their mapping",
+			input: `name: test
+runtime: yaml
+resources:
+  r:
+    type: test:mod:Typ
+    properties:
+      z: 1
+      a: [1, 2]
+    # foot of r
+outputs:
+  o: 1
+`,
+			expected: `name: test
+runtime: yaml
+
+resources:
+  r:
+    type: test:mod:Typ
+    properties:
+      a: [1, 2]
+      z: 1
+      # foot of r
+
+outputs:
+  o: 1
+`,
+		},
+		{
+			name: "header comments stay at the top",
+			input: `# A test project.
+# It has no resources.
+runtime: yaml
+name: test
+`,
+			expected: `# A test project.
+# It has no resources.
+name: test
+runtime: yaml
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, diags := Format(tt.name+".yaml", []byte(tt.input))
+			require.False(t, diags.HasErrors(), diags)
+			assert.Equal(t, tt.expected, string(actual))
+
+			again, diags := Format(tt.name+".yaml", actual)
+			require.False(t, diags.HasErrors(), diags)
+			assert.Equal(t, string(actual), string(again), "formatting is not idempotent")
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	t.Parallel()
+
+	_, diags := Format("list.yaml", []byte("- a\n- b\n"))
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "Top level of 'list.yaml' must be an object", diags[0].Summary)
+
+	_, diags = Format("invalid.yaml", []byte("a: [b\n"))
+	assert.True(t, diags.HasErrors())
+}
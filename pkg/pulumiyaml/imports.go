@@ -0,0 +1,339 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+)
+
+// mergeImports loads the templates imported by template, which was read from filename within directory, and merges
+// their variables, resources and outputs into it under each import's namespace. Imported templates may themselves
+// import other templates. Import paths are relative to the importing file, and must not be absolute or lead outside of
+// directory. The returned set holds the absolute paths of every file that was imported.
+//
+// References to imported entities within template are left as written; see qualifyImportedReferences.
+func mergeImports(template *ast.TemplateDecl, directory, filename string) (map[string]bool, syntax.Diagnostics) {
+	imported := map[string]bool{}
+	root, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, syntax.Diagnostics{syntax.Error(nil, err.Error(), "")}
+	}
+	l := importLoader{root: root, imported: imported, derivedNames: map[*ast.ResourceDecl]bool{}}
+	return imported, l.mergeImports(template, []string{filepath.Join(root, filename)})
+}
+
+type importLoader struct {
+	// root is the directory of the main template. Imported files are named relative to it in diagnostics.
+	root     string
+	imported map[string]bool
+	// derivedNames holds the imported resources whose registered name was derived from their namespaced name, rather
+	// than set explicitly with `name`.
+	derivedNames map[*ast.ResourceDecl]bool
+}
+
+// mergeImports merges the imports of template, which was read from the last file in chain.
+func (l importLoader) mergeImports(template *ast.TemplateDecl, chain []string) syntax.Diagnostics {
+	var diags syntax.Diagnostics
+	dir := filepath.Dir(chain[len(chain)-1])
+	for _, imp := range template.Imports.Entries {
+		if imp == nil || imp.Path == nil {
+			continue
+		}
+
+		namespace := imp.GetNamespace()
+		if namespace == "" || strings.ContainsAny(namespace, ".:[]${}") {
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("%q is not a valid import namespace", namespace),
+				"Set 'namespace' to a name without dots, colons or brackets."))
+			continue
+		}
+
+		if filepath.IsAbs(filepath.FromSlash(imp.Path.Value)) {
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("import %s must be a relative path", imp.Path.Value),
+				"Imports are read from the project directory."))
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(imp.Path.Value))
+		if !l.contains(path) {
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("import %s is outside of the project directory", imp.Path.Value),
+				"Imports are read from the project directory."))
+			continue
+		}
+		for i, p := range chain {
+			if p == path {
+				cycle := make([]string, 0, len(chain)-i+1)
+				for _, p := range append(chain[i:], path) {
+					cycle = append(cycle, l.displayName(p))
+				}
+				diags.Extend(ast.ExprError(imp.Path, "import cycle: "+strings.Join(cycle, " -> "), ""))
+				return diags
+			}
+		}
+
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("failed to read import: %v", err), ""))
+			continue
+		}
+		t, tdiags, err := loadYAMLBytes(l.displayName(path), bs)
+		diags.Extend(tdiags...)
+		if err != nil {
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("failed to load import: %v", err), ""))
+			continue
+		}
+		if tdiags.HasErrors() {
+			continue
+		}
+		l.imported[path] = true
+
+		idiags := l.mergeImports(t, append(chain[:len(chain):len(chain)], path))
+		diags.Extend(idiags...)
+		if idiags.HasErrors() {
+			continue
+		}
+
+		if !isImportable(t) {
+			diags.Extend(ast.ExprError(imp.Path,
+				fmt.Sprintf("imported template %s may only declare imports, variables, resources and outputs", imp.Path.Value), ""))
+			continue
+		}
+		qualifyImportedReferences(t)
+		l.namespaceTemplate(t, namespace)
+
+		if err := template.Merge(t); err != nil {
+			if mdiags, ok := HasDiagnostics(err); ok {
+				diags.Extend(mdiags...)
+				continue
+			}
+			diags.Extend(ast.ExprError(imp.Path, fmt.Sprintf("failed to merge import: %v", err), ""))
+		}
+	}
+	return diags
+}
+
+// importsError returns an error diagnostic that points at the first of imports.
+func importsError(imports ast.ImportListDecl, summary, detail string) *syntax.Diagnostic {
+	var node syntax.Node
+	if len(imports.Entries) != 0 && imports.Entries[0] != nil {
+		node = imports.Entries[0].Syntax()
+	}
+	return syntax.NodeError(node, summary, detail)
+}
+
+// displayName returns the name of path in diagnostics: its path relative to the main template's directory.
+func (l importLoader) displayName(path string) string {
+	if rel, err := filepath.Rel(l.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// contains returns true if path is within the main template's directory.
+func (l importLoader) contains(path string) bool {
+	rel, err := filepath.Rel(l.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isImportable returns true if t declares nothing that would be lost when it is merged under a namespace.
+func isImportable(t *ast.TemplateDecl) bool {
+	return t.Name == nil && t.Namespace == nil && t.Description == nil && t.Version == nil &&
+		!t.Pulumi.HasSettings() && len(t.Configuration.Entries) == 0 && len(t.Config.Entries) == 0 &&
+		len(t.Functions.Entries) == 0 && len(t.Mappings.Entries) == 0 && len(t.Conditions.Entries) == 0 &&
+		len(t.Components.Entries) == 0
+}
+
+// namespaceTemplate prefixes the name of every variable, resource and output declared by t with namespace, along
+// with every reference to them.
+//
+// A resource's namespaced name, such as `net.vpc`, is only used to refer to it within the program. Unless it sets an
+// explicit `name`, the resource is registered as `net-vpc`, so that the logical names in its URN do not contain dots.
+func (l importLoader) namespaceTemplate(t *ast.TemplateDecl, namespace string) {
+	declared := map[string]bool{}
+	for _, entry := range t.Variables.Entries {
+		declared[entry.Key.Value] = true
+	}
+	for _, entry := range t.Resources.Entries {
+		declared[entry.Key.Value] = true
+	}
+
+	rename := func(key *ast.StringExpr, name string) *ast.StringExpr {
+		if node, ok := key.Syntax().(*syntax.StringNode); ok {
+			return ast.StringSyntaxValue(node, name)
+		}
+		return ast.String(name)
+	}
+	qualify := func(key *ast.StringExpr) *ast.StringExpr {
+		return rename(key, namespace+"."+key.Value)
+	}
+	for i := range t.Variables.Entries {
+		t.Variables.Entries[i].Key = qualify(t.Variables.Entries[i].Key)
+	}
+	for i, entry := range t.Resources.Entries {
+		if r := entry.Value; r != nil && (r.Name == nil || l.derivedNames[r]) {
+			name := entry.Key.Value
+			if r.Name != nil {
+				name = r.Name.Value
+			}
+			r.Name = rename(entry.Key, namespace+"-"+name)
+			l.derivedNames[r] = true
+		}
+		t.Resources.Entries[i].Key = qualify(t.Resources.Entries[i].Key)
+	}
+	for i := range t.Outputs.Entries {
+		t.Outputs.Entries[i].Key = qualify(t.Outputs.Entries[i].Key)
+	}
+
+	visitTemplatePropertyAccesses(t, func(access *ast.PropertyAccess) {
+		if root, ok := access.Accessors[0].(*ast.PropertyName); ok && declared[root.Name] {
+			access.Accessors[0] = &ast.PropertyName{Name: namespace + "." + root.Name}
+		}
+	})
+}
+
+// qualifyImportedReferences rewrites references to imported entities, such as `${net.vpc.id}`, so that their root
+// is the entity's namespaced name (`net.vpc`) rather than its namespace.
+func qualifyImportedReferences(t *ast.TemplateDecl) {
+	declared := map[string]bool{PulumiVarName: true, RangeVarName: true}
+	for _, entry := range t.GetConfig().Entries {
+		declared[entry.Key.Value] = true
+	}
+	for _, entry := range t.Variables.Entries {
+		declared[entry.Key.Value] = true
+	}
+	for _, entry := range t.Conditions.Entries {
+		declared[entry.Key.Value] = true
+	}
+	for _, entry := range t.Resources.Entries {
+		declared[entry.Key.Value] = true
+	}
+
+	visitTemplatePropertyAccesses(t, func(access *ast.PropertyAccess) {
+		root, ok := access.Accessors[0].(*ast.PropertyName)
+		if !ok || declared[root.Name] {
+			return
+		}
+		name := root.Name
+		for i := 1; i < len(access.Accessors); i++ {
+			next, ok := access.Accessors[i].(*ast.PropertyName)
+			if !ok {
+				return
+			}
+			name += "." + next.Name
+			if declared[name] {
+				access.Accessors = append([]ast.PropertyAccessor{&ast.PropertyName{Name: name}}, access.Accessors[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// visitTemplatePropertyAccesses calls visit once with each property access in the expressions of t. References to a
+// function's parameters within its body are skipped, as they do not refer to other entities.
+func visitTemplatePropertyAccesses(t *ast.TemplateDecl, visit func(*ast.PropertyAccess)) {
+	seen := map[*ast.PropertyAccess]bool{}
+	var shadowed map[string]bool
+	var walk func(x ast.Expr)
+	visitAccess := func(access *ast.PropertyAccess) {
+		if access == nil || len(access.Accessors) == 0 || seen[access] || shadowed[access.RootName()] {
+			return
+		}
+		seen[access] = true
+		visit(access)
+	}
+	walk = func(x ast.Expr) {
+		switch x := x.(type) {
+		case *ast.ListExpr:
+			for _, e := range x.Elements {
+				walk(e)
+			}
+		case *ast.ObjectExpr:
+			for _, kvp := range x.Entries {
+				walk(kvp.Key)
+				walk(kvp.Value)
+			}
+		case *ast.InterpolateExpr:
+			for _, p := range x.Parts {
+				visitAccess(p.Value)
+			}
+		case *ast.SymbolExpr:
+			visitAccess(x.Property)
+		case *ast.InvokeExpr:
+			walk(x.Args())
+			walk(x.CallOpts.DependsOn)
+			walk(x.CallOpts.Parent)
+			walk(x.CallOpts.Provider)
+		case ast.BuiltinExpr:
+			walk(x.Args())
+		}
+	}
+
+	walk(t.Pulumi.RequiredVersion)
+	for _, entry := range t.GetConfig().Entries {
+		if entry.Value != nil {
+			walk(entry.Value.Default)
+			walk(entry.Value.Value)
+		}
+	}
+	for _, entry := range t.Variables.Entries {
+		walk(entry.Value)
+	}
+	for _, entry := range t.Conditions.Entries {
+		walk(entry.Value)
+	}
+	for _, entry := range t.Resources.Entries {
+		r := entry.Value
+		if r == nil {
+			continue
+		}
+		walk(r.Condition)
+		walk(r.Count)
+		walk(r.ForEach)
+		if r.Properties.PropertyMap != nil {
+			for _, kvp := range r.Properties.PropertyMap.Entries {
+				walk(kvp.Value)
+			}
+		}
+		walk(r.Properties.Expr)
+		walk(r.Get.Id)
+		for _, kvp := range r.Get.State.Entries {
+			walk(kvp.Value)
+		}
+		opts := r.Options
+		for _, x := range []ast.Expr{
+			opts.Aliases, opts.DependsOn, opts.Parent, opts.Protect, opts.Provider, opts.Providers,
+			opts.ReplaceWith, opts.DeletedWith, opts.ReplacementTrigger, opts.EnvVarMappings,
+		} {
+			walk(x)
+		}
+		if opts.CustomTimeouts != nil {
+			walk(opts.CustomTimeouts.Create)
+			walk(opts.CustomTimeouts.Update)
+			walk(opts.CustomTimeouts.Delete)
+			walk(opts.CustomTimeouts.Read)
+		}
+	}
+	for _, entry := range t.Outputs.Entries {
+		walk(entry.Value)
+	}
+	for _, entry := range t.Functions.Entries {
+		if entry.Value == nil {
+			continue
+		}
+		shadowed = map[string]bool{}
+		for _, param := range entry.Value.Parameters.Entries {
+			shadowed[param.Key.Value] = true
+		}
+		for _, param := range entry.Value.Parameters.Entries {
+			if param.Value != nil {
+				walk(param.Value.Default)
+			}
+		}
+		walk(entry.Value.Body)
+		shadowed = nil
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+)
+
+// checkInput checks that v, the value of a component input or of one of its elements, conforms to decl. t is the
+// template that declares the component, and the types that decl refers to. path names the value in errors.
+//
+// Null values are not checked: an input that must be present is required by the component's schema.
+func checkInput(t *ast.TemplateDecl, decl *ast.ConfigParamDecl, path string, v interface{}) error {
+	if decl == nil || v == nil {
+		return nil
+	}
+	v = inputValue(v)
+
+	switch {
+	case decl.Ref != nil:
+		typ, ok := t.LookupType(decl.Ref.Value)
+		if !ok {
+			return fmt.Errorf("%s refers to unknown type %q", path, decl.Ref.Value)
+		}
+		return checkInput(t, typ, path, v)
+	case decl.Enum != nil:
+		_, values, err := decl.EnumValues()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		allowed := make([]string, len(values))
+		for i, value := range values {
+			if x, ok := inputNumber(v); ok && x == value || v == value {
+				return nil
+			}
+			allowed[i] = fmt.Sprintf("%#v", value)
+		}
+		return fmt.Errorf("%s must be one of %s, not %#v", path, strings.Join(allowed, ", "), v)
+	case decl.Type == nil:
+		return nil
+	}
+
+	mismatch := func(expected string) error {
+		return fmt.Errorf("%s must be %s, not %s", path, expected, inputKind(v))
+	}
+	switch decl.Type.Value {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return mismatch("a string")
+		}
+	case "integer":
+		if x, ok := inputNumber(v); !ok || math.Mod(x, 1) != 0 {
+			return mismatch("an integer")
+		}
+	case "number":
+		if _, ok := inputNumber(v); !ok {
+			return mismatch("a number")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch("a boolean")
+		}
+	case "array":
+		elements, ok := v.([]interface{})
+		if !ok {
+			return mismatch("a list")
+		}
+		for i, e := range elements {
+			if err := checkInput(t, decl.Items, fmt.Sprintf("%s[%d]", path, i), e); err != nil {
+				return err
+			}
+		}
+	case "object", "map":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch("an object")
+		}
+		properties := map[string]*ast.ConfigParamDecl{}
+		for _, entry := range decl.Properties.Entries {
+			properties[entry.Key.Value] = entry.Value
+		}
+		if decl.Required != nil {
+			for _, k := range decl.Required.Elements {
+				if obj[k.Value] == nil {
+					return fmt.Errorf("%s is missing required property %s", path, k.Value)
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			property, ok := properties[k]
+			if !ok {
+				if len(properties) != 0 && decl.AdditionalProperties == nil {
+					return fmt.Errorf("%s has unknown property %s", path, k)
+				}
+				property = decl.AdditionalProperties
+			}
+			if err := checkInput(t, property, path+"."+k, obj[k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inputValue converts the typed slices and maps of a resolved input, such as a `map[string]string`, to their untyped
+// equivalents.
+func inputValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if _, ok := v.([]interface{}); ok {
+			return v
+		}
+		elements := make([]interface{}, rv.Len())
+		for i := range elements {
+			elements[i] = rv.Index(i).Interface()
+		}
+		return elements
+	case reflect.Map:
+		if _, ok := v.(map[string]interface{}); ok || rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		obj := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			obj[iter.Key().String()] = iter.Value().Interface()
+		}
+		return obj
+	default:
+		return v
+	}
+}
+
+// inputNumber returns the value of v if it is a number.
+func inputNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// inputKind describes the kind of v in errors.
+func inputKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "a string"
+	case float64, int:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("a %T", v)
+	}
+}
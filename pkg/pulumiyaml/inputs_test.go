@@ -0,0 +1,141 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const componentInputsText = `
+name: test
+runtime: yaml
+types:
+  Subnet:
+    type: object
+    properties:
+      cidr:
+        type: string
+      public:
+        type: boolean
+    required: [ cidr ]
+components:
+  myComponent:
+    inputs:
+      networkConfig:
+        type: object
+        properties:
+          subnets:
+            type: array
+            items:
+              $ref: "#/types/Subnet"
+          mtu:
+            type: integer
+        required: [ subnets ]
+      tier:
+        enum: [ small, medium, large ]
+        default: small
+      labels:
+        type: map
+        additionalProperties:
+          type: string
+    outputs:
+      tier: ${tier}
+`
+
+func TestComponentInputValidation(t *testing.T) {
+	t.Parallel()
+
+	network := pulumi.Map{
+		"subnets": pulumi.Array{pulumi.Map{"cidr": pulumi.String("10.0.0.0/24"), "public": pulumi.Bool(true)}},
+		"mtu":     pulumi.Float64(1500),
+	}
+	tests := []struct {
+		name     string
+		inputs   pulumi.Map
+		expected string
+	}{
+		{
+			name:   "valid",
+			inputs: pulumi.Map{"networkConfig": network, "tier": pulumi.String("large"), "labels": pulumi.StringMap{}},
+		},
+		{
+			name:   "default",
+			inputs: pulumi.Map{"networkConfig": network, "labels": pulumi.StringMap{"env": pulumi.String("dev")}},
+		},
+		{
+			name:     "enum",
+			inputs:   pulumi.Map{"networkConfig": network, "tier": pulumi.String("huge")},
+			expected: `input tier must be one of "small", "medium", "large", not "huge"`,
+		},
+		{
+			name:     "missing",
+			inputs:   pulumi.Map{"networkConfig": network},
+			expected: "missing required input labels",
+		},
+		{
+			name: "required property",
+			inputs: pulumi.Map{"networkConfig": pulumi.Map{
+				"subnets": pulumi.Array{pulumi.Map{"public": pulumi.Bool(false)}},
+			}},
+			expected: "input networkConfig.subnets[0] is missing required property cidr",
+		},
+		{
+			name: "unknown property",
+			inputs: pulumi.Map{"networkConfig": pulumi.Map{
+				"subnets": pulumi.Array{},
+				"vpc":     pulumi.String("main"),
+			}},
+			expected: "input networkConfig has unknown property vpc",
+		},
+		{
+			name: "property type",
+			inputs: pulumi.Map{"networkConfig": pulumi.Map{
+				"subnets": pulumi.Array{},
+				"mtu":     pulumi.Float64(1500.5),
+			}},
+			expected: "input networkConfig.mtu must be an integer, not a number",
+		},
+		{
+			name:     "map values",
+			inputs:   pulumi.Map{"networkConfig": network, "labels": pulumi.Map{"env": pulumi.Bool(true)}},
+			expected: "input labels.env must be a string, not a boolean",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			template := yamlTemplate(t, strings.TrimSpace(componentInputsText))
+			var outputs pulumi.Map
+			mocks := &testMonitor{
+				NewResourceF: func(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+					return "", resource.PropertyMap{}, nil
+				},
+			}
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				var err error
+				_, outputs, err = RunComponentTemplate(ctx,
+					"test:index:myComponent", "myComp", nil,
+					template, tt.inputs, newMockPackageMap(),
+				)
+				return err
+			}, pulumi.WithMocks("projectFoo", "stackDev", mocks))
+
+			if tt.expected == "" {
+				require.NoError(t, err)
+				assert.Contains(t, outputs, "tier")
+				return
+			}
+			diags, ok := HasDiagnostics(err)
+			require.True(t, ok, "expected diagnostics, got %v", err)
+			require.Len(t, diags, 1)
+			assert.Equal(t, tt.expected, diags[0].Summary)
+		})
+	}
+}
@@ -4,6 +4,7 @@ package pulumiyaml
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha1" //nolint:gosec
 	"crypto/sha256"
@@ -16,10 +17,13 @@ import (
 	"io/fs"
 	"maps"
 	"math"
+	"mime"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -38,6 +42,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 	"gopkg.in/yaml.v3"
 
@@ -48,12 +53,19 @@ import (
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
 )
 
-// MainTemplate is the assumed name of the JSON template file.
-// TODO: would be nice to permit multiple files, but we'd need to know which is "main", and there's
-// no notion of "import" so we'd need to be a bit more clever. Might be nice to mimic e.g. Kustomize.
-// One idea is to hijack Pulumi.yaml's "main" directive and then just globally toposort the rest.
+// MainTemplate is the assumed name of the JSON template file. Template fragments named after it, such as
+// Main.network.yaml, are merged into the main template by LoadDir.
 const MainTemplate = "Main"
 
+// templateFragmentSections are the top-level keys that a template fragment may declare.
+var templateFragmentSections = map[string]bool{
+	"config":        true,
+	"configuration": true,
+	"variables":     true,
+	"resources":     true,
+	"outputs":       true,
+}
+
 func LoadFromCompiler(compiler string, workingDirectory string, env []string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 	var diags syntax.Diagnostics
 	var stdout bytes.Buffer
@@ -137,7 +149,10 @@ func probeForFile(dir string, filenames ...string) ([]byte, string, error) {
 	return nil, filenames[0], ErrMissingTemplateFile
 }
 
-// Load a template from the given directory.
+// Load a template from the given directory. The main template is merged with the template fragments that sit
+// alongside it, which are named Main.<part>.yaml (or .yml or .json), so that large programs may be split across
+// several files. Other files in the directory, such as Pulumi.<stack>.yaml stack configuration, are not merged, so
+// sibling files must carry the Main. prefix to be part of the program.
 func LoadDir(directory string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 	// Read in the template file - search first for Main.json, then Main.yaml, then Pulumi.yaml.
 	// The last of these will actually read the proram from the same Pulumi.yaml project file used by
@@ -151,7 +166,7 @@ func LoadDir(directory string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 		return nil, nil, fmt.Errorf("reading template %s: %w", filename, err)
 	}
 
-	template, diags, err := LoadYAMLBytes(filename, bs)
+	template, diags, err := loadYAMLBytes(filename, bs)
 	if err != nil {
 		return nil, diags, err
 	}
@@ -160,6 +175,24 @@ func LoadDir(directory string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 		return nil, diags, diags
 	}
 
+	imported, idiags := mergeImports(template, directory, filename)
+	diags.Extend(idiags...)
+	if diags.HasErrors() {
+		return nil, diags, diags
+	}
+
+	fdiags, err := mergeTemplateFragments(template, directory, imported)
+	diags.Extend(fdiags...)
+	if err != nil {
+		return nil, diags, err
+	}
+	if diags.HasErrors() {
+		return nil, diags, diags
+	}
+	if len(template.Imports.Entries) != 0 {
+		qualifyImportedReferences(template)
+	}
+
 	sdks, err := packages.SearchPackageDecls(directory)
 	if err != nil {
 		diags.Extend(syntax.Error(nil, err.Error(), ""))
@@ -169,6 +202,96 @@ func LoadDir(directory string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 	return template, diags, nil
 }
 
+// mergeTemplateFragments merges every template fragment in directory into template, in filename order. Files in
+// imported, which holds absolute paths, are included by the template's imports and so are skipped.
+func mergeTemplateFragments(template *ast.TemplateDecl, directory string, imported map[string]bool) (syntax.Diagnostics, error) {
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags syntax.Diagnostics
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !isTemplateFragment(name) {
+			continue
+		}
+		if path, err := filepath.Abs(filepath.Join(directory, name)); err == nil && imported[path] {
+			continue
+		}
+
+		bs, err := os.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			return diags, err
+		}
+		if sdiags := checkTemplateFragmentSections(name, bs); sdiags.HasErrors() {
+			diags.Extend(sdiags...)
+			continue
+		}
+
+		t, tdiags, err := loadYAMLBytes(name, bs)
+		diags.Extend(tdiags...)
+		if err != nil {
+			return diags, err
+		}
+		if tdiags.HasErrors() {
+			continue
+		}
+		if len(t.Imports.Entries) != 0 {
+			diags.Extend(importsError(t.Imports, fmt.Sprintf("template fragment %s may not declare imports", name),
+				"Declare the imports in the main template instead."))
+			continue
+		}
+
+		if err := template.Merge(t); err != nil {
+			if mdiags, ok := HasDiagnostics(err); ok {
+				diags.Extend(mdiags...)
+				continue
+			}
+			return diags, fmt.Errorf("merging template %s: %w", name, err)
+		}
+	}
+	return diags, nil
+}
+
+// templateFileExtensions are the extensions of the files that may hold a template fragment. JSON is a subset of YAML,
+// so fragments of either kind are read in the same way.
+var templateFileExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// isTemplateFragment returns true if filename names a template fragment, i.e. has the form Main.<part>.yaml. Other
+// files, such as Kubernetes manifests or stack configuration, are never merged into the program.
+func isTemplateFragment(filename string) bool {
+	ext := filepath.Ext(filename)
+	if !templateFileExtensions[ext] {
+		return false
+	}
+	part, ok := strings.CutPrefix(strings.TrimSuffix(filename, ext), MainTemplate+".")
+	return ok && part != ""
+}
+
+// checkTemplateFragmentSections returns an error for each top-level key of the fragment in source that is not a
+// program section. Errors in the YAML itself are left to the loader.
+func checkTemplateFragmentSections(filename string, source []byte) syntax.Diagnostics {
+	obj, diags := encoding.DecodeYAML(filename, yaml.NewDecoder(bytes.NewReader(source)), TagDecoder)
+	if diags.HasErrors() || obj == nil {
+		return nil
+	}
+	var errs syntax.Diagnostics
+	for i := 0; i < obj.Len(); i++ {
+		key := obj.Index(i).Key
+		// Fragments may not declare imports, but are still loaded so that their imports can be reported.
+		if name := strings.ToLower(key.Value()); !templateFragmentSections[name] && name != "imports" {
+			errs.Extend(syntax.NodeError(key, fmt.Sprintf("template fragment %s may not declare %s", filename, key.Value()),
+				"Fragments may only declare config, variables, resources and outputs."))
+		}
+	}
+	return errs
+}
+
 // Load a plugin template from the given directory.
 func LoadPluginTemplate(directory string) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 	// Get all yaml files in the directory, load them and merge them into a single template.
@@ -254,7 +377,24 @@ func LoadYAML(filename string, r io.Reader) (*ast.TemplateDecl, syntax.Diagnosti
 }
 
 // LoadYAMLBytes decodes a YAML template from a byte array.
+//
+// Imports are read relative to the directory of the template that declares them, so they are only supported when a
+// program is loaded with LoadDir. A template that declares imports is rejected.
 func LoadYAMLBytes(filename string, source []byte) (*ast.TemplateDecl, syntax.Diagnostics, error) {
+	t, diags, err := loadYAMLBytes(filename, source)
+	if err != nil || t == nil {
+		return t, diags, err
+	}
+	if len(t.Imports.Entries) != 0 {
+		diags.Extend(importsError(t.Imports, "imports are only supported when loading a program from its directory",
+			"Load the program with LoadDir, or merge the imported templates into this one."))
+		return nil, diags, nil
+	}
+	return t, diags, nil
+}
+
+// loadYAMLBytes decodes a YAML template from a byte array, leaving its imports to the caller.
+func loadYAMLBytes(filename string, source []byte) (*ast.TemplateDecl, syntax.Diagnostics, error) {
 	var diags syntax.Diagnostics
 
 	syn, sdiags := encoding.DecodeYAML(filename, yaml.NewDecoder(bytes.NewReader(source)), TagDecoder)
@@ -287,6 +427,22 @@ func LoadTemplate(t *Template) (*ast.TemplateDecl, syntax.Diagnostics) {
 
 	td, tdiags := ast.ParseTemplate(nil, syn)
 	diags.Extend(tdiags...)
+	if td == nil {
+		return nil, diags
+	}
+
+	// A resource's condition names one of the template's conditions, so it is loaded as a reference to it.
+	for _, kvp := range td.Resources.Entries {
+		r, ok := t.Resources[kvp.Key.Value]
+		if !ok || r == nil || r.Condition == "" || kvp.Value == nil {
+			continue
+		}
+		cond, cdiags := ast.VariableSubstitution(r.Condition)
+		diags.Extend(cdiags...)
+		if cond != nil {
+			kvp.Value.Condition = cond
+		}
+	}
 
 	return td, diags
 }
@@ -534,16 +690,9 @@ func RunComponentTemplate(ctx *pulumi.Context,
 	typ, name string, options pulumi.ResourceOption,
 	t *ast.TemplateDecl, inputs pulumi.Map, loader PackageLoader,
 ) (pulumi.URNOutput, pulumi.Map, error) {
-	var templ ast.Template
-	typSplit := strings.Split(typ, ":")
-	if len(typSplit) != 3 {
-		return pulumi.URNOutput{}, nil, errors.New("invalid component type")
-	}
-	for _, comp := range t.Components.Entries {
-		if comp.Key.Value == typSplit[2] {
-			templ = comp.Value
-			break
-		}
+	templ, err := findComponent(t, typ)
+	if err != nil {
+		return pulumi.URNOutput{}, nil, err
 	}
 	runner := newRunner(templ, loader)
 
@@ -587,6 +736,106 @@ func RunComponentTemplate(ctx *pulumi.Context,
 	return component.URN(), component.outputs, nil
 }
 
+// findComponent returns the component of a template whose type token is typ.
+func findComponent(t *ast.TemplateDecl, typ string) (*ast.ComponentParamDecl, error) {
+	typSplit := strings.Split(typ, ":")
+	if len(typSplit) != 3 {
+		return nil, errors.New("invalid component type")
+	}
+	for _, comp := range t.Components.Entries {
+		if comp.Key.Value == typSplit[2] {
+			return comp.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown component type %s", typ)
+}
+
+// RunComponentMethod runs the method of a component whose function token is tok, e.g.
+// `pkg:index:cluster/getKubeconfig`, on the component instance self. It returns the outputs of the method.
+func RunComponentMethod(ctx *pulumi.Context,
+	tok string, self pulumi.Resource,
+	t *ast.TemplateDecl, args pulumi.Map, loader PackageLoader,
+) (pulumi.Map, error) {
+	typ, name, ok := strings.Cut(tok, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method token %s", tok)
+	}
+	component, err := findComponent(t, typ)
+	if err != nil {
+		return nil, err
+	}
+	method, ok := component.Methods.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown method %s of component %s", name, typ)
+	}
+	if self == nil {
+		return nil, fmt.Errorf("method %s must be called on an instance of %s", name, typ)
+	}
+
+	runner := newRunner(method, loader)
+	if err := runner.setPackageDesciptors(); err != nil {
+		return nil, err
+	}
+	_, diags := TypeCheck(runner)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	packageRefs, diags := findPackageRefs(ctx, runner)
+	if diags != nil {
+		return nil, errors.New(diags.Error())
+	}
+
+	state, err := readComponentState(ctx, self)
+	if err != nil {
+		return nil, err
+	}
+	inputs := pulumi.Map{ast.SelfInput: state.Outputs}
+	for k, v := range args {
+		inputs[k] = v
+	}
+
+	evaluator := &componentEvaluator{
+		inputs:  inputs,
+		outputs: pulumi.Map{},
+		evaluator: &programEvaluator{
+			evalContext: runner.newContext(t),
+			pulumiCtx:   ctx,
+			packageRefs: packageRefs,
+		},
+	}
+	diags.Extend(runner.Run(evaluator)...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return evaluator.outputs, nil
+}
+
+// componentState holds the outputs of a component instance.
+type componentState struct {
+	pulumi.ResourceState
+
+	Outputs pulumi.MapOutput `pulumi:""`
+}
+
+// readComponentState reads the outputs of the component instance self from the engine.
+func readComponentState(ctx *pulumi.Context, self pulumi.Resource) (*componentState, error) {
+	result, err := internals.UnsafeAwaitOutput(ctx.Context(), self.URN())
+	if err != nil {
+		return nil, err
+	}
+	urn, ok := result.Value.(pulumi.URN)
+	if !ok || !result.Known {
+		return nil, errors.New("the URN of the component instance is unknown")
+	}
+
+	state := &componentState{}
+	u := resource.URN(urn)
+	if err := ctx.RegisterResource(string(u.Type()), u.Name(), nil, state, pulumi.URN_(string(urn))); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 type componentEvaluator struct {
 	pulumi.ResourceState
 
@@ -610,12 +859,69 @@ func (m *componentEvaluator) EvalConfig(r *Runner, node configNode) bool {
 	k := node.key().Value
 	v, ok := m.inputs[k]
 	if ok {
+		if !m.checkInput(node, v) {
+			return false
+		}
+		r.config[k] = v
+		return true
+	}
+	// Inputs whose types configuration cannot hold, such as enums and objects, are not read from configuration.
+	if decl, ok := node.(configNodeYaml); ok && decl.Value != nil && !isConfigType(decl.Value) {
+		if decl.Value.Default == nil {
+			_, ok := m.evaluator.errorf(decl.Key, "missing required input %s", k)
+			return ok
+		}
+		v, ok := m.evaluator.evaluateExpr(decl.Value.Default)
+		if !ok || !m.checkInput(node, v) {
+			return false
+		}
 		r.config[k] = v
 		return true
 	}
 	return m.evaluator.EvalConfig(r, node)
 }
 
+// isConfigType returns true if the type of a parameter is one that configuration can hold.
+func isConfigType(decl *ast.ConfigParamDecl) bool {
+	if decl.Ref != nil || decl.Enum != nil || len(decl.Properties.Entries) != 0 {
+		return false
+	}
+	if decl.Type == nil {
+		return true
+	}
+	_, ok := ctypes.Parse(decl.Type.Value)
+	return ok
+}
+
+// checkInput checks that the value of an input conforms to the input's declared type. The inputs of a component are
+// resolved before it is constructed, so awaiting them does not block; unknown values are not checked.
+func (m *componentEvaluator) checkInput(node configNode, v interface{}) bool {
+	decl, ok := node.(configNodeYaml)
+	if !ok || decl.Value == nil {
+		return true
+	}
+	result, err := internals.UnsafeAwaitOutput(m.evaluator.pulumiCtx.Context(), pulumi.ToOutput(v))
+	if err != nil {
+		_, ok := m.evaluator.error(decl.Key, err.Error())
+		return ok
+	}
+	if !result.Known {
+		return true
+	}
+	var template *ast.TemplateDecl
+	switch t := m.evaluator.t.(type) {
+	case *ast.ComponentParamDecl:
+		template = t.Template
+	case *ast.MethodDecl:
+		template = t.Component().Template
+	}
+	if err := checkInput(template, decl.Value, "input "+decl.Key.Value, result.Value); err != nil {
+		_, ok := m.evaluator.error(decl.Key, err.Error())
+		return ok
+	}
+	return true
+}
+
 func (m *componentEvaluator) EvalVariable(r *Runner, node variableNode) bool {
 	return m.evaluator.EvalVariable(r, node)
 }
@@ -650,6 +956,10 @@ func (m *componentEvaluator) EvalOutput(r *Runner, node ast.PropertyMapEntry) bo
 	return true
 }
 
+func (m *componentEvaluator) EvalFunction(r *Runner, node functionNode) bool {
+	return m.evaluator.EvalFunction(r, node)
+}
+
 func (m *componentEvaluator) EvalMissing(r *Runner, node missingNode) bool {
 	return m.evaluator.EvalMissing(r, node)
 }
@@ -690,7 +1000,7 @@ type Runner struct {
 	pkgLoader PackageLoader
 	config    map[string]interface{}
 	variables map[string]interface{}
-	resources map[string]lateboundResource
+	resources map[string]resourceValue
 	stackRefs map[string]*pulumi.StackReference
 
 	cwd string
@@ -707,6 +1017,10 @@ type evalContext struct {
 
 	root   interface{}
 	sdiags syncDiags
+
+	// loops holds the fn::for expressions whose bodies enclose the expression being walked by the
+	// type checker, innermost last.
+	loops []*ast.ForExpr
 }
 
 func (ctx *evalContext) addWarnDiag(rng *hcl.Range, summary string, detail string) {
@@ -740,10 +1054,18 @@ func (r *Runner) newContext(root interface{}) *evalContext {
 	return ctx
 }
 
+// resourceValue is the value of a resource declaration: a lateboundResource once the resource is
+// registered, or a skippedResource or resourceRange that stands in for it.
+type resourceValue interface {
+	isResourceValue()
+}
+
 // lateboundResource is an interface shared by lateboundCustomResourceState and
 // lateboundProviderResourceState so that both normal and provider resources can be
 // created and managed as part of a deployment.
 type lateboundResource interface {
+	resourceValue
+
 	GetOutput(k string) pulumi.Output
 	GetOutputs() pulumi.Output
 	CustomResource() *pulumi.CustomResourceState
@@ -799,6 +1121,8 @@ func (st *lateboundCustomResourceState) GetResourceSchema() *schema.Resource {
 	return st.resourceSchema
 }
 
+func (*lateboundCustomResourceState) isResourceValue() {}
+
 type lateboundProviderResourceState struct {
 	pulumi.ProviderResourceState
 	name           string
@@ -843,6 +1167,8 @@ func (st *lateboundProviderResourceState) GetResourceSchema() *schema.Resource {
 	return st.resourceSchema
 }
 
+func (*lateboundProviderResourceState) isResourceValue() {}
+
 type poisonMarker struct{}
 
 // GetOutputs returns the resource's outputs.
@@ -875,6 +1201,16 @@ func (st poisonMarker) GetResourceSchema() *schema.Resource {
 	return nil
 }
 
+func (poisonMarker) isResourceValue() {}
+
+// skippedResource stands in for a resource whose condition evaluated to false. The resource
+// was never registered, so any reference to it is reported as an error.
+type skippedResource struct {
+	condition ast.Expr
+}
+
+func (skippedResource) isResourceValue() {}
+
 // Check if a value is either a poisonMarker or is a collection that contains a
 // poisonMarker.
 func isPoisoned(v interface{}) (poisonMarker, bool) {
@@ -903,18 +1239,23 @@ func newRunner(t ast.Template, p PackageLoader) *Runner {
 		pkgLoader: p,
 		config:    make(map[string]interface{}),
 		variables: make(map[string]interface{}),
-		resources: make(map[string]lateboundResource),
+		resources: make(map[string]resourceValue),
 		stackRefs: make(map[string]*pulumi.StackReference),
 	}
 }
 
 const PulumiVarName = "pulumi"
 
+// RangeVarName is the name of the variable holding the current element within a resource declared
+// with count or forEach.
+const RangeVarName = "range"
+
 type Evaluator interface {
 	EvalPulumi(r *Runner, node pulumiNode) bool
 	EvalConfig(r *Runner, node configNode) bool
 	EvalVariable(r *Runner, node variableNode) bool
 	EvalResource(r *Runner, node resourceNode) bool
+	EvalFunction(r *Runner, node functionNode) bool
 	EvalOutput(r *Runner, node ast.PropertyMapEntry) bool
 	EvalMissing(r *Runner, node missingNode) bool
 }
@@ -923,7 +1264,9 @@ type programEvaluator struct {
 	*evalContext
 	pulumiCtx   *pulumi.Context
 	packageRefs map[tokens.Package]string
-	parent      pulumi.Resource // non-nil when evaluating inside a component
+	parent      pulumi.Resource        // non-nil when evaluating inside a component
+	rangeValue  interface{}            // non-nil when registering an instance of a resource with count or forEach
+	arguments   map[string]interface{} // non-nil when evaluating the body of a function
 }
 
 func (e *programEvaluator) error(expr ast.Expr, summary string) (interface{}, bool) {
@@ -1032,11 +1375,22 @@ func (e programEvaluator) EvalResource(r *Runner, node resourceNode) bool {
 			return false
 		}
 	} else {
+		if _, skipped := res.(skippedResource); skipped {
+			err := e.pulumiCtx.Log.Debug(fmt.Sprintf("Skipping resource [%v]: condition is false", node.Key.Value), &pulumi.LogArgs{})
+			if err != nil {
+				return false
+			}
+		}
 		e.resources[node.Key.Value] = res
 	}
 	return true
 }
 
+// EvalFunction does nothing: a function's body is evaluated each time it is called.
+func (e programEvaluator) EvalFunction(r *Runner, node functionNode) bool {
+	return true
+}
+
 func (e programEvaluator) EvalMissing(r *Runner, node missingNode) bool {
 	e.error(node.key(), fmt.Sprintf("resource, variable, or config value %q not found", node.key().Value))
 	return false
@@ -1260,6 +1614,16 @@ func (r *Runner) Run(e Evaluator) syntax.Diagnostics {
 			if !e.EvalVariable(r, kvp) {
 				return returnDiags()
 			}
+		case conditionNode:
+			if ctx != nil {
+				err := ctx.Log.Debug(fmt.Sprintf("Registering condition [%v]", kvp.Key.Value), &pulumi.LogArgs{})
+				if err != nil {
+					return returnDiags()
+				}
+			}
+			if !e.EvalVariable(r, variableNode(kvp)) {
+				return returnDiags()
+			}
 		case resourceNode:
 			if ctx != nil {
 				err := ctx.Log.Debug(fmt.Sprintf("Registering resource [%v]", kvp.Key.Value), &pulumi.LogArgs{})
@@ -1270,6 +1634,10 @@ func (r *Runner) Run(e Evaluator) syntax.Diagnostics {
 			if !e.EvalResource(r, kvp) {
 				return returnDiags()
 			}
+		case functionNode:
+			if !e.EvalFunction(r, kvp) {
+				return returnDiags()
+			}
 		case missingNode:
 			if !e.EvalMissing(r, kvp) {
 				return returnDiags()
@@ -1486,7 +1854,31 @@ func (e *programEvaluator) registerConfig(intm configNode) (interface{}, bool) {
 	return v, true
 }
 
-func (e *programEvaluator) registerResource(kvp resourceNode) (lateboundResource, bool) {
+func (e *programEvaluator) registerResource(kvp resourceNode) (resourceValue, bool) {
+	v := kvp.Value
+
+	if v.Condition != nil {
+		create, ok := e.evaluateResourceCondition(v.Condition)
+		if !ok {
+			return nil, false
+		}
+		if p, isPoison := create.(poisonMarker); isPoison {
+			return p, true
+		}
+		if !create.(bool) {
+			return skippedResource{condition: v.Condition}, true
+		}
+	}
+
+	if v.Count != nil || v.ForEach != nil {
+		return e.registerResourceRange(kvp)
+	}
+	return e.registerResourceInstance(kvp, "")
+}
+
+// registerResourceInstance registers a single instance of a resource with the engine. A non-empty
+// nameSuffix is appended to the resource name to tell apart the instances of a ranged resource.
+func (e *programEvaluator) registerResourceInstance(kvp resourceNode, nameSuffix string) (lateboundResource, bool) {
 	k, v := kvp.Key.Value, kvp.Value
 
 	// Read the properties and then evaluate them in case there are expressions contained inside.
@@ -1934,6 +2326,9 @@ func (e *programEvaluator) registerResource(kvp resourceNode) (lateboundResource
 	if v.Name != nil && v.Name.Value != "" {
 		resourceName = v.Name.Value
 	}
+	if nameSuffix != "" {
+		resourceName += "-" + nameSuffix
+	}
 
 	// When evaluating inside a component, prefix child resource names with the
 	// component instance name so multiple instances of the same component don't
@@ -2143,33 +2538,177 @@ func (e *programEvaluator) registerResource(kvp resourceNode) (lateboundResource
 	return state, true
 }
 
-func (e *programEvaluator) evaluateResourceListValuedOption(optionExpr ast.Expr, key string) ([]lateboundResource, bool) {
-	value, ok := e.evaluateExpr(optionExpr)
+// resourceRange stands in for a resource declared with count or forEach. It holds the registered
+// instances as a list indexed by position or, when forEach is a map, as a map indexed by key.
+type resourceRange struct {
+	instances interface{}
+}
+
+func (resourceRange) isResourceValue() {}
+
+// registerResourceRange registers one instance of a resource for each element of its count or
+// forEach expression. Each instance is named after its index or key, in a deterministic order, and
+// is registered with `range` bound to an object holding that key and the element's value.
+func (e *programEvaluator) registerResourceRange(kvp resourceNode) (resourceValue, bool) {
+	v := kvp.Value
+	if v.Count != nil && v.ForEach != nil {
+		e.error(v.ForEach, "a resource cannot specify both count and forEach")
+		return nil, false
+	}
+
+	rangeExpr, fieldName := v.ForEach, "forEach"
+	if v.Count != nil {
+		rangeExpr, fieldName = v.Count, "count"
+	}
+	value, ok := e.evaluateExpr(rangeExpr)
 	if !ok {
 		return nil, false
 	}
+	if p, isPoison := isPoisoned(value); isPoison {
+		return p, true
+	}
 	if hasOutputs(value) {
-		e.error(optionExpr, fmt.Sprintf("resource option %v value must be a list of resource, not an output", key))
+		e.errorf(rangeExpr, "resource %s must be known before the resource is registered; "+
+			"it cannot depend on resource outputs or secrets", fieldName)
 		return nil, false
 	}
-	dependencies, ok := value.([]interface{})
+
+	register := func(key, value interface{}, nameSuffix string) (lateboundResource, bool) {
+		// Each instance is evaluated with its own copy of the evaluator, so that `range` remains bound
+		// within applies that run after registration returns.
+		instance := *e
+		instance.rangeValue = map[string]interface{}{"key": key, "value": value}
+		return instance.registerResourceInstance(kvp, nameSuffix)
+	}
+
+	// Integer config values are the one source of ints among evaluated values.
+	if i, isInt := value.(int); isInt {
+		value = float64(i)
+	}
+	switch value := value.(type) {
+	case float64:
+		if v.Count == nil {
+			break
+		}
+		if value < 0 || value != math.Trunc(value) {
+			e.errorf(rangeExpr, "resource count must be a non-negative integer, not %v", value)
+			return nil, false
+		}
+		instances := make([]interface{}, int(value))
+		for i := range instances {
+			res, ok := register(float64(i), float64(i), strconv.Itoa(i))
+			if !ok {
+				return nil, false
+			}
+			instances[i] = res
+		}
+		return resourceRange{instances: instances}, true
+	case []interface{}:
+		if v.ForEach == nil {
+			break
+		}
+		instances := make([]interface{}, len(value))
+		for i, elem := range value {
+			res, ok := register(float64(i), elem, strconv.Itoa(i))
+			if !ok {
+				return nil, false
+			}
+			instances[i] = res
+		}
+		return resourceRange{instances: instances}, true
+	case map[string]interface{}:
+		if v.ForEach == nil {
+			break
+		}
+		instances := make(map[string]interface{}, len(value))
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			res, ok := register(key, value[key], key)
+			if !ok {
+				return nil, false
+			}
+			instances[key] = res
+		}
+		return resourceRange{instances: instances}, true
+	}
+
+	if v.Count != nil {
+		e.errorf(rangeExpr, "resource count must be a non-negative integer, not %v", typeString(value))
+	} else {
+		e.errorf(rangeExpr, "resource forEach must be a list or an object, not %v", typeString(value))
+	}
+	return nil, false
+}
+
+// evaluateResourceCondition evaluates the condition of a resource. The result is either a bool or a
+// poisonMarker. Since the condition decides whether the resource is registered at all, it must be
+// known at the time the resource is evaluated and so cannot depend on the outputs of other resources.
+func (e *programEvaluator) evaluateResourceCondition(condition ast.Expr) (interface{}, bool) {
+	value, ok := e.evaluateExpr(condition)
 	if !ok {
-		e.error(optionExpr, fmt.Sprintf("resource option %v value must be a list of resources", key))
 		return nil, false
 	}
-	var resources []lateboundResource
-	for _, dep := range dependencies {
-		res, err := asResource(dep)
-		if err != nil {
-			e.error(optionExpr, err.Error())
-			continue
-		}
-		resources = append(resources, res)
+	switch value := value.(type) {
+	case bool, poisonMarker:
+		return value, true
+	case pulumi.Output:
+		return e.error(condition, "resource condition must be known before the resource is registered; "+
+			"it cannot depend on resource outputs or secrets")
+	default:
+		return e.error(condition, fmt.Sprintf("resource condition must be a boolean, not %v", typeString(value)))
 	}
-	return resources, true
 }
 
-func (e *programEvaluator) evaluateResourceValuedOption(optionExpr ast.Expr) (lateboundResource, bool) {
+func (e *programEvaluator) evaluateResourceListValuedOption(optionExpr ast.Expr, key string) ([]lateboundResource, bool) {
+	value, ok := e.evaluateExpr(optionExpr)
+	if !ok {
+		return nil, false
+	}
+	if hasOutputs(value) {
+		e.error(optionExpr, fmt.Sprintf("resource option %v value must be a list of resource, not an output", key))
+		return nil, false
+	}
+	dependencies, ok := resourceInstances(value)
+	if !ok {
+		e.error(optionExpr, fmt.Sprintf("resource option %v value must be a list of resources", key))
+		return nil, false
+	}
+	var resources []lateboundResource
+	for _, dep := range dependencies {
+		// A resource declared with count or forEach may be listed, and stands for all of its instances.
+		instances, ok := resourceInstances(dep)
+		if !ok {
+			instances = []interface{}{dep}
+		}
+		for _, instance := range instances {
+			res, err := asResource(instance)
+			if err != nil {
+				e.error(optionExpr, err.Error())
+				continue
+			}
+			resources = append(resources, res)
+		}
+	}
+	return resources, true
+}
+
+// resourceInstances returns the elements of a list, or the values of an object in the order of their keys, such as
+// the instances of a resource declared with count or forEach.
+func resourceInstances(value interface{}) ([]interface{}, bool) {
+	switch value := value.(type) {
+	case []interface{}:
+		return value, true
+	case map[string]interface{}:
+		instances := make([]interface{}, 0, len(value))
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			instances = append(instances, value[key])
+		}
+		return instances, true
+	default:
+		return nil, false
+	}
+}
+
+func (e *programEvaluator) evaluateResourceValuedOption(optionExpr ast.Expr) (lateboundResource, bool) {
 	value, ok := e.evaluateExpr(optionExpr)
 	if !ok {
 		return nil, false
@@ -2250,8 +2789,30 @@ func (e *programEvaluator) evaluateExpr(x ast.Expr) (interface{}, bool) {
 		return e.evaluateBuiltinJoin(x)
 	case *ast.SplitExpr:
 		return e.evaluateBuiltinSplit(x)
+	case *ast.ReplaceExpr:
+		return e.evaluateBuiltinReplace(x)
+	case *ast.UpperExpr:
+		return e.evaluateBuiltinStringTransform(x, x.Value, strings.ToUpper)
+	case *ast.LowerExpr:
+		return e.evaluateBuiltinStringTransform(x, x.Value, strings.ToLower)
+	case *ast.TrimExpr:
+		return e.evaluateBuiltinStringTransform(x, x.Value, strings.TrimSpace)
+	case *ast.SubstringExpr:
+		return e.evaluateBuiltinSubstring(x)
+	case *ast.FormatExpr:
+		return e.evaluateBuiltinFormat(x)
+	case *ast.RegexExpr:
+		return e.evaluateBuiltinRegex(x)
+	case *ast.CallExpr:
+		return e.evaluateBuiltinCall(x)
 	case *ast.ToJSONExpr:
 		return e.evaluateBuiltinToJSON(x)
+	case *ast.FromJSONExpr:
+		return e.evaluateBuiltinFromJSON(x)
+	case *ast.FromYAMLExpr:
+		return e.evaluateBuiltinFromYAML(x)
+	case *ast.ToYAMLExpr:
+		return e.evaluateBuiltinToYAML(x)
 	case *ast.SelectExpr:
 		return e.evaluateBuiltinSelect(x)
 	case *ast.ToBase64Expr:
@@ -2288,14 +2849,50 @@ func (e *programEvaluator) evaluateExpr(x ast.Expr) (interface{}, bool) {
 		return e.evaluateBuiltinFileBase64Sha256(x)
 	case *ast.Sha1Expr:
 		return e.evaluateBuiltinSha1(x)
+	case *ast.MimeTypeExpr:
+		return e.evaluateBuiltinMimeType(x)
 	case *ast.LengthExpr:
 		return e.evaluateBuiltinLength(x)
 	case *ast.SingleOrNoneExpr:
 		return e.evaluateBuiltinSingleOrNone(x)
+	case *ast.MergeExpr:
+		return e.evaluateBuiltinMerge(x)
+	case *ast.KeysExpr:
+		return e.evaluateBuiltinKeys(x)
+	case *ast.ValuesExpr:
+		return e.evaluateBuiltinValues(x)
+	case *ast.FlattenExpr:
+		return e.evaluateBuiltinFlatten(x)
+	case *ast.ConcatExpr:
+		return e.evaluateBuiltinConcat(x)
+	case *ast.DistinctExpr:
+		return e.evaluateBuiltinDistinct(x)
+	case *ast.ContainsExpr:
+		return e.evaluateBuiltinContains(x)
+	case *ast.ZipExpr:
+		return e.evaluateBuiltinZip(x)
+	case *ast.LookupExpr:
+		return e.evaluateBuiltinLookup(x)
+	case *ast.ForExpr:
+		return e.evaluateBuiltinFor(x)
+	case *ast.FindInMapExpr:
+		return e.evaluateBuiltinFindInMap(x)
+	case *ast.TryExpr:
+		return e.evaluateBuiltinTry(x)
 	case *ast.PulumiResourceNameExpr:
 		return e.evaluateBuiltinPulumiResourceName(x)
 	case *ast.PulumiResourceTypeExpr:
 		return e.evaluateBuiltinPulumiResourceType(x)
+	case *ast.IfExpr:
+		return e.evaluateBuiltinIf(x)
+	case *ast.NotExpr:
+		return e.evaluateBuiltinNot(x)
+	case *ast.LogicalExpr:
+		return e.evaluateBuiltinLogical(x)
+	case *ast.ComparisonExpr:
+		return e.evaluateBuiltinComparison(x)
+	case *ast.ArithmeticExpr:
+		return e.evaluateBuiltinArithmetic(x)
 	default:
 		panic(fmt.Sprintf("fatal: invalid expr type %v", reflect.TypeOf(x)))
 	}
@@ -2410,10 +3007,41 @@ func unknownOutput() pulumi.Output {
 // variable. The tail of property accessors are either: `.foo` string literal property names or
 // `[42]` numeric literal property subscripts.
 func (e *programEvaluator) evaluatePropertyAccess(expr ast.Expr, access *ast.PropertyAccess) (interface{}, bool) {
+	return e.evaluatePropertyPath(expr, access, false)
+}
+
+// evaluateOptionalPropertyAccess is like evaluatePropertyAccess, but evaluates to nil instead of
+// failing when the path does not exist: when a key or list element is missing, when a value along
+// the path is null, or when the root is a resource whose condition is false.
+func (e *programEvaluator) evaluateOptionalPropertyAccess(expr ast.Expr, access *ast.PropertyAccess) (interface{}, bool) {
+	return e.evaluatePropertyPath(expr, access, true)
+}
+
+func (e *programEvaluator) evaluatePropertyPath(expr ast.Expr, access *ast.PropertyAccess, optional bool) (interface{}, bool) {
 	resourceName := access.RootName()
 	var receiver interface{}
-	if res, ok := e.resources[resourceName]; ok {
-		receiver = res
+	if arg, ok := e.arguments[resourceName]; ok {
+		receiver = arg
+	} else if resourceName == RangeVarName && e.rangeValue != nil {
+		receiver = e.rangeValue
+	} else if res, ok := e.resources[resourceName]; ok {
+		switch res := res.(type) {
+		case skippedResource:
+			if optional {
+				return nil, true
+			}
+			detail := "Resources that reference a conditional resource should share its condition."
+			if s := res.condition.Syntax(); s != nil {
+				detail = fmt.Sprintf("The condition is declared at %v. %s", s.Syntax().Range(), detail)
+			}
+			e.addDiag(ast.ExprError(expr,
+				fmt.Sprintf("resource %q is not registered because its condition is false", resourceName), detail))
+			return nil, false
+		case resourceRange:
+			receiver = res.instances
+		default:
+			receiver = res
+		}
 	} else if p, ok := e.config[resourceName]; ok {
 		receiver = p
 	} else if v, ok := e.variables[resourceName]; ok {
@@ -2424,10 +3052,14 @@ func (e *programEvaluator) evaluatePropertyAccess(expr ast.Expr, access *ast.Pro
 		return e.error(expr, fmt.Sprintf("resource or variable named %q could not be found", resourceName))
 	}
 
-	return e.evaluatePropertyAccessTail(expr, receiver, access.Accessors[1:])
+	return e.evaluatePropertyAccessTail(expr, receiver, access.Accessors[1:], optional)
 }
 
-func (e *programEvaluator) evaluatePropertyAccessTail(expr ast.Expr, receiver interface{}, accessors []ast.PropertyAccessor) (interface{}, bool) {
+// evaluatePropertyAccessTail applies accessors to receiver. If optional is set, a path that does not
+// exist evaluates to nil rather than an error.
+func (e *programEvaluator) evaluatePropertyAccessTail(
+	expr ast.Expr, receiver interface{}, accessors []ast.PropertyAccessor, optional bool,
+) (interface{}, bool) {
 	var evaluateAccessF func(args ...interface{}) (interface{}, bool)
 	evaluateAccessF = e.lift(func(args ...interface{}) (interface{}, bool) {
 		receiver := args[0]
@@ -2581,6 +3213,9 @@ func (e *programEvaluator) evaluatePropertyAccessTail(expr ast.Expr, receiver in
 					return e.error(expr, "cannot access a list element using a property name")
 				}
 				if index < 0 || index >= len(x) {
+					if optional {
+						return nil, true
+					}
 					return e.error(expr, fmt.Sprintf("list index %v out-of-bounds for list of length %v", index, len(x)))
 				}
 				receiver = x[index]
@@ -2600,6 +3235,9 @@ func (e *programEvaluator) evaluatePropertyAccessTail(expr ast.Expr, receiver in
 				reflx := reflect.ValueOf(x)
 				length := reflx.Len()
 				if index < 0 || index >= length {
+					if optional {
+						return nil, true
+					}
 					return e.error(expr, fmt.Sprintf("list index %v out-of-bounds for list of length %v", index, length))
 				}
 				receiver = reflect.Indirect(reflx).Index(index).Interface()
@@ -2625,6 +3263,9 @@ func (e *programEvaluator) evaluatePropertyAccessTail(expr ast.Expr, receiver in
 				if len(accessors) == 0 {
 					break Loop
 				}
+				if optional && receiver == nil {
+					return nil, true
+				}
 				return e.error(expr, fmt.Sprintf("receiver must be a list or object, not %v", typeString(receiver)))
 			}
 		}
@@ -2860,6 +3501,290 @@ func (e *programEvaluator) evaluateBuiltinSplit(v *ast.SplitExpr) (interface{},
 	return split(delimiter, source)
 }
 
+func (e *programEvaluator) evaluateBuiltinReplace(v *ast.ReplaceExpr) (interface{}, bool) {
+	source, sourceOk := e.evaluateExpr(v.Source)
+	search, searchOk := e.evaluateExpr(v.Search)
+	replacement, replacementOk := e.evaluateExpr(v.Replacement)
+	if !sourceOk || !searchOk || !replacementOk {
+		return nil, false
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		strs := make([]string, len(args))
+		for i, x := range []ast.Expr{v.Source, v.Search, v.Replacement} {
+			s, ok := args[i].(string)
+			if !ok {
+				return e.error(x, fmt.Sprintf("Must be a string, not %v", typeString(args[i])))
+			}
+			strs[i] = s
+		}
+		return strings.ReplaceAll(strs[0], strs[1], strs[2]), true
+	})(source, search, replacement)
+}
+
+// evaluateBuiltinStringTransform evaluates a builtin that applies transform to a single string,
+// such as fn::upper.
+func (e *programEvaluator) evaluateBuiltinStringTransform(
+	v ast.BuiltinExpr, value ast.Expr, transform func(string) string,
+) (interface{}, bool) {
+	arg, ok := e.evaluateExpr(value)
+	if !ok {
+		return nil, false
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		s, ok := args[0].(string)
+		if !ok {
+			return e.error(value, fmt.Sprintf("%s requires a string, not %v", v.Name().Value, typeString(args[0])))
+		}
+		return transform(s), true
+	})(arg)
+}
+
+func (e *programEvaluator) evaluateBuiltinSubstring(v *ast.SubstringExpr) (interface{}, bool) {
+	source, sourceOk := e.evaluateExpr(v.Source)
+	start, startOk := e.evaluateExpr(v.Start)
+	length, lengthOk := e.evaluateExpr(v.Length)
+	if !sourceOk || !startOk || !lengthOk {
+		return nil, false
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		s, ok := args[0].(string)
+		if !ok {
+			return e.error(v.Source, fmt.Sprintf("Must be a string, not %v", typeString(args[0])))
+		}
+		start, ok := asNumber(args[1])
+		if !ok || start < 0 || start != math.Trunc(start) {
+			return e.error(v.Start, fmt.Sprintf("the start of fn::substring must be a non-negative integer, not %v", args[1]))
+		}
+		length, ok := asNumber(args[2])
+		if !ok || length < -1 || length != math.Trunc(length) {
+			return e.error(v.Length, fmt.Sprintf("the length of fn::substring must be a non-negative integer or -1, not %v", args[2]))
+		}
+
+		// Index by character rather than by byte, so that multi-byte characters are not split.
+		runes := []rune(s)
+		from := min(int(start), len(runes))
+		to := len(runes)
+		if length >= 0 {
+			to = min(from+int(length), to)
+		}
+		return string(runes[from:to]), true
+	})(source, start, length)
+}
+
+func (e *programEvaluator) evaluateBuiltinFormat(v *ast.FormatExpr) (interface{}, bool) {
+	values := make([]interface{}, len(v.Values)+1)
+	for i, x := range append([]ast.Expr{v.Format}, v.Values...) {
+		value, ok := e.evaluateExpr(x)
+		if !ok {
+			return nil, false
+		}
+		values[i] = value
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		format, ok := args[0].(string)
+		if !ok {
+			return e.error(v.Format, fmt.Sprintf("the format string of fn::format must be a string, not %v", typeString(args[0])))
+		}
+		s, err := formatString(format, args[1:])
+		if err != nil {
+			return e.error(v, fmt.Sprintf("fn::format: %v", err))
+		}
+		return s, true
+	})(values...)
+}
+
+// formatString formats args according to a printf-style format string. It supports the %s, %q, %v,
+// %d, %f, %e and %g verbs with Go's flags, width and precision, as well as %%. Numbers are float64,
+// so %d requires a whole number.
+func formatString(format string, args []interface{}) (string, error) {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+
+		// Scan the flags, width and precision up to the verb.
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j == len(format) {
+			return "", fmt.Errorf("the format string ends with an incomplete verb %q", format[i:])
+		}
+		spec, verb := format[i:j], format[j]
+		i = j
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+
+		if next == len(args) {
+			return "", fmt.Errorf("%%%c is missing an argument", verb)
+		}
+		arg := args[next]
+		next++
+
+		switch verb {
+		case 'd':
+			n, ok := asNumber(arg)
+			if !ok || n != math.Trunc(n) {
+				return "", fmt.Errorf("%%d requires a whole number, not %v", arg)
+			}
+			fmt.Fprintf(&b, spec+"d", int64(n))
+		case 'f', 'e', 'g':
+			n, ok := asNumber(arg)
+			if !ok {
+				return "", fmt.Errorf("%%%c requires a number, not %v", verb, typeString(arg))
+			}
+			fmt.Fprintf(&b, spec+string(verb), n)
+		case 's', 'q', 'v':
+			var str string
+			switch arg := arg.(type) {
+			case string:
+				str = arg
+			case bool:
+				str = strconv.FormatBool(arg)
+			default:
+				if n, ok := asNumber(arg); ok {
+					str = strconv.FormatFloat(n, 'f', -1, 64)
+				} else if verb == 'v' {
+					encoded, err := json.Marshal(arg)
+					if err != nil {
+						return "", err
+					}
+					str = string(encoded)
+				} else {
+					return "", fmt.Errorf("%%%c requires a string, number or boolean, not %v", verb, typeString(arg))
+				}
+			}
+			if verb == 'v' {
+				verb = 's'
+			}
+			fmt.Fprintf(&b, spec+string(verb), str)
+		default:
+			return "", fmt.Errorf("unsupported verb %%%c", verb)
+		}
+	}
+	if next != len(args) {
+		return "", fmt.Errorf("the format string uses %d of %d arguments", next, len(args))
+	}
+	return b.String(), nil
+}
+
+func (e *programEvaluator) evaluateBuiltinRegex(v *ast.RegexExpr) (interface{}, bool) {
+	exprs := []ast.Expr{v.Pattern, v.Source}
+	if v.Replacement != nil {
+		exprs = append(exprs, v.Replacement)
+	}
+	values := make([]interface{}, len(exprs))
+	for i, x := range exprs {
+		value, ok := e.evaluateExpr(x)
+		if !ok {
+			return nil, false
+		}
+		values[i] = value
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			s, ok := arg.(string)
+			if !ok {
+				return e.error(exprs[i], fmt.Sprintf("Must be a string, not %v", typeString(arg)))
+			}
+			strs[i] = s
+		}
+		re, err := regexp.Compile(strs[0])
+		if err != nil {
+			return e.error(v.Pattern, fmt.Sprintf("invalid regular expression: %v", err))
+		}
+
+		switch v.Operator {
+		case ast.RegexMatch:
+			return re.MatchString(strs[1]), true
+		case ast.RegexReplace:
+			return re.ReplaceAllString(strs[1], strs[2]), true
+		case ast.RegexFind:
+			matches := re.FindAllString(strs[1], -1)
+			result := make([]interface{}, len(matches))
+			for i, m := range matches {
+				result[i] = m
+			}
+			return result, true
+		default:
+			panic(fmt.Sprintf("fatal: invalid regex operator %q", v.Operator))
+		}
+	})(values...)
+}
+
+// evaluateBuiltinCall evaluates a call to a declared function. The arguments are evaluated in the
+// caller's scope. The body is evaluated in the template's scope, where the function's parameters
+// shadow any variable, resource or config value of the same name.
+func (e *programEvaluator) evaluateBuiltinCall(v *ast.CallExpr) (interface{}, bool) {
+	fn, ok := e.t.GetFunctions().Get(v.Function.Value)
+	if !ok && v.IsShorthand() {
+		// The type checker warns of the reserved key.
+		obj, _ := v.ReservedObject()
+		return e.evaluateExpr(obj)
+	}
+	if !ok {
+		return e.error(v.Function, fmt.Sprintf("function %q is not declared", v.Function.Value))
+	}
+	if diag := v.ArgsError(); diag != nil {
+		e.addDiag(diag)
+		return nil, false
+	}
+
+	args := map[string]interface{}{}
+	if v.CallArgs != nil {
+		for _, kvp := range v.CallArgs.Entries {
+			k, ok := kvp.Key.(*ast.StringExpr)
+			if !ok {
+				return e.error(kvp.Key, "function argument names must be string literals")
+			}
+			if !slices.ContainsFunc(fn.Parameters.Entries, func(param ast.ConfigMapEntry) bool {
+				return param.Key.Value == k.Value
+			}) {
+				return e.error(kvp.Key, fmt.Sprintf("function %q has no parameter named %q", v.Function.Value, k.Value))
+			}
+			arg, ok := e.evaluateExpr(kvp.Value)
+			if !ok {
+				return nil, false
+			}
+			args[k.Value] = arg
+		}
+	}
+
+	// The body is evaluated by a copy of the evaluator, so that the parameters remain bound within
+	// applies that run after the call returns. Defaults are evaluated in the same scope, so they may
+	// refer to earlier parameters.
+	body := *e
+	body.arguments, body.rangeValue = args, nil
+	for _, param := range fn.Parameters.Entries {
+		name := param.Key.Value
+		if _, ok := args[name]; ok {
+			continue
+		}
+		if param.Value == nil || param.Value.Default == nil {
+			return e.error(v, fmt.Sprintf("missing argument %q to function %q", name, v.Function.Value))
+		}
+		def, ok := body.evaluateExpr(param.Value.Default)
+		if !ok {
+			return nil, false
+		}
+		args[name] = def
+	}
+
+	return body.evaluateExpr(fn.Body)
+}
+
 func (e *programEvaluator) evaluateBuiltinToJSON(v *ast.ToJSONExpr) (interface{}, bool) {
 	value, ok := e.evaluateExpr(v.Value)
 	if !ok {
@@ -2877,6 +3802,113 @@ func (e *programEvaluator) evaluateBuiltinToJSON(v *ast.ToJSONExpr) (interface{}
 	return toJSON(value)
 }
 
+func (e *programEvaluator) evaluateBuiltinFromJSON(v *ast.FromJSONExpr) (interface{}, bool) {
+	value, ok := e.evaluateExpr(v.Value)
+	if !ok {
+		return nil, false
+	}
+
+	fromJSON := e.lift(func(args ...interface{}) (interface{}, bool) {
+		s, ok := args[0].(string)
+		if !ok {
+			return e.error(v.Value, fmt.Sprintf("the argument to fn::fromJSON must be a string, not %v", typeString(args[0])))
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return e.error(v.Value, fmt.Sprintf("failed to parse JSON: %v", err))
+		}
+		return decoded, true
+	})
+	return fromJSON(value)
+}
+
+func (e *programEvaluator) evaluateBuiltinFromYAML(v *ast.FromYAMLExpr) (interface{}, bool) {
+	value, ok := e.evaluateExpr(v.Value)
+	if !ok {
+		return nil, false
+	}
+
+	fromYAML := e.lift(func(args ...interface{}) (interface{}, bool) {
+		s, ok := args[0].(string)
+		if !ok {
+			return e.error(v.Value, fmt.Sprintf("the argument to fn::fromYAML must be a string, not %v", typeString(args[0])))
+		}
+		var decoded interface{}
+		if err := yaml.Unmarshal([]byte(s), &decoded); err != nil {
+			return e.error(v.Value, fmt.Sprintf("failed to parse YAML: %v", err))
+		}
+		normalized, err := normalizeYAMLValue(decoded)
+		if err != nil {
+			return e.error(v.Value, fmt.Sprintf("failed to parse YAML: %v", err))
+		}
+		return normalized, true
+	})
+	return fromYAML(value)
+}
+
+// normalizeYAMLValue converts a value decoded by yaml.v3 into the shapes produced by the rest of
+// the evaluator: numbers become float64 and mapping keys must be strings.
+func normalizeYAMLValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case []interface{}:
+		for i, elem := range v {
+			normalized, err := normalizeYAMLValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = normalized
+		}
+		return v, nil
+	case map[string]interface{}:
+		for k, elem := range v {
+			normalized, err := normalizeYAMLValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = normalized
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("mapping keys must be strings, not %v", typeString(k))
+			}
+			normalized, err := normalizeYAMLValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = normalized
+		}
+		return m, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *programEvaluator) evaluateBuiltinToYAML(v *ast.ToYAMLExpr) (interface{}, bool) {
+	value, ok := e.evaluateExpr(v.Value)
+	if !ok {
+		return nil, false
+	}
+
+	toYAML := e.lift(func(args ...interface{}) (interface{}, bool) {
+		b, err := yaml.Marshal(args[0])
+		if err != nil {
+			return e.error(v, fmt.Sprintf("failed to encode YAML: %v", err))
+		}
+		return string(b), true
+	})
+	return toYAML(value)
+}
+
 func (e *programEvaluator) evaluateBuiltinSelect(v *ast.SelectExpr) (interface{}, bool) {
 	index, ok := e.evaluateExpr(v.Index)
 	if !ok {
@@ -2902,7 +3934,7 @@ func (e *programEvaluator) evaluateBuiltinSelect(v *ast.SelectExpr) (interface{}
 		}
 		intIndex := int(index)
 
-		return e.evaluatePropertyAccessTail(v.Values, elemsArg, []ast.PropertyAccessor{&ast.PropertySubscript{Index: intIndex}})
+		return e.evaluatePropertyAccessTail(v.Values, elemsArg, []ast.PropertyAccessor{&ast.PropertySubscript{Index: intIndex}}, false)
 	})
 	return selectFn(index, values)
 }
@@ -3111,6 +4143,388 @@ func (e *programEvaluator) evaluateBuiltinSingleOrNone(s *ast.SingleOrNoneExpr)
 	})(expr)
 }
 
+func (e *programEvaluator) evaluateBuiltinMerge(s *ast.MergeExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::merge requires a list of objects, got %v", typeString(args[0])))
+		}
+		result := map[string]interface{}{}
+		for _, elem := range list {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				return e.error(s.Value, fmt.Sprintf("fn::merge requires a list of objects, but the list contains %v", typeString(elem)))
+			}
+			maps.Copy(result, obj)
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinKeys(s *ast.KeysExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		obj, ok := args[0].(map[string]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::keys requires an object, got %v", typeString(args[0])))
+		}
+		result := make([]interface{}, 0, len(obj))
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			result = append(result, k)
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinValues(s *ast.ValuesExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		obj, ok := args[0].(map[string]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::values requires an object, got %v", typeString(args[0])))
+		}
+		result := make([]interface{}, 0, len(obj))
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			result = append(result, obj[k])
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinFlatten(s *ast.FlattenExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::flatten requires a list, got %v", typeString(args[0])))
+		}
+		var flatten func(list []interface{}) []interface{}
+		flatten = func(list []interface{}) []interface{} {
+			result := []interface{}{}
+			for _, elem := range list {
+				if nested, ok := elem.([]interface{}); ok {
+					result = append(result, flatten(nested)...)
+				} else {
+					result = append(result, elem)
+				}
+			}
+			return result
+		}
+		return flatten(list), true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinConcat(s *ast.ConcatExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		lists, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::concat requires a list of lists, got %v", typeString(args[0])))
+		}
+		result := []interface{}{}
+		for _, elem := range lists {
+			list, ok := elem.([]interface{})
+			if !ok {
+				return e.error(s.Value, fmt.Sprintf("fn::concat requires a list of lists, but the list contains %v", typeString(elem)))
+			}
+			result = append(result, list...)
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinDistinct(s *ast.DistinctExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::distinct requires a list, got %v", typeString(args[0])))
+		}
+		result := []interface{}{}
+		for _, elem := range list {
+			if !slices.ContainsFunc(result, func(x interface{}) bool { return valuesEqual(x, elem) }) {
+				result = append(result, elem)
+			}
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinContains(s *ast.ContainsExpr) (interface{}, bool) {
+	values, valuesOk := e.evaluateExpr(s.Values)
+	value, valueOk := e.evaluateExpr(s.Value)
+	if !valuesOk || !valueOk {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Values, fmt.Sprintf("fn::contains requires a list, got %v", typeString(args[0])))
+		}
+		return slices.ContainsFunc(list, func(x interface{}) bool { return valuesEqual(x, args[1]) }), true
+	})(values, value)
+}
+
+func (e *programEvaluator) evaluateBuiltinZip(s *ast.ZipExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		elems, ok := args[0].([]interface{})
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::zip requires a list of lists, got %v", typeString(args[0])))
+		}
+		lists := make([][]interface{}, len(elems))
+		for i, elem := range elems {
+			list, ok := elem.([]interface{})
+			if !ok {
+				return e.error(s.Value, fmt.Sprintf("fn::zip requires a list of lists, but the list contains %v", typeString(elem)))
+			}
+			if i > 0 && len(list) != len(lists[0]) {
+				return e.error(s.Value, fmt.Sprintf("fn::zip requires lists of the same length, got %d and %d", len(lists[0]), len(list)))
+			}
+			lists[i] = list
+		}
+		result := []interface{}{}
+		if len(lists) == 0 {
+			return result, true
+		}
+		for i := range lists[0] {
+			tuple := make([]interface{}, len(lists))
+			for j, list := range lists {
+				tuple[j] = list[i]
+			}
+			result = append(result, tuple)
+		}
+		return result, true
+	})(expr)
+}
+
+func (e *programEvaluator) evaluateBuiltinLookup(s *ast.LookupExpr) (interface{}, bool) {
+	m, mapOk := e.evaluateExpr(s.Map)
+	key, keyOk := e.evaluateExpr(s.Key)
+	def, defOk := e.evaluateExpr(s.Default)
+	if !mapOk || !keyOk || !defOk {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		obj, ok := args[0].(map[string]interface{})
+		if !ok {
+			return e.error(s.Map, fmt.Sprintf("fn::lookup requires an object, got %v", typeString(args[0])))
+		}
+		k, ok := args[1].(string)
+		if !ok {
+			return e.error(s.Key, fmt.Sprintf("fn::lookup requires a string key, got %v", typeString(args[1])))
+		}
+		if v, ok := obj[k]; ok && v != nil {
+			return v, true
+		}
+		return args[2], true
+	})(m, key, def)
+}
+
+// evaluateBuiltinFor evaluates fn::for. The elements of a list are visited in order and those of an
+// object in order of key. The body is evaluated with its own copy of the evaluator for each element,
+// so that `range` remains bound within applies of the body. The collection must be known, as the
+// evaluator is not safe for concurrent use and so the body cannot be evaluated within an apply; its
+// elements may be outputs.
+func (e *programEvaluator) evaluateBuiltinFor(s *ast.ForExpr) (interface{}, bool) {
+	in, ok := e.evaluateExpr(s.In)
+	if !ok {
+		return nil, false
+	}
+
+	var keys, values []interface{}
+	switch collection := in.(type) {
+	case poisonMarker:
+		return collection, true
+	case []interface{}:
+		for i, v := range collection {
+			keys, values = append(keys, float64(i)), append(values, v)
+		}
+	case map[string]interface{}:
+		for _, k := range slices.Sorted(maps.Keys(collection)) {
+			keys, values = append(keys, k), append(values, collection[k])
+		}
+	case pulumi.Output:
+		return e.error(s.In, "the collection of fn::for must be known; it cannot depend on resource outputs or secrets")
+	default:
+		return e.error(s.In, fmt.Sprintf("fn::for requires a list or an object, got %v", typeString(in)))
+	}
+
+	var list []interface{}
+	obj := map[string]interface{}{}
+	for i, key := range keys {
+		body := *e
+		body.rangeValue = map[string]interface{}{"key": key, "value": values[i]}
+		if s.If != nil {
+			cond, ok := body.evaluateExpr(s.If)
+			if !ok {
+				return nil, false
+			}
+			switch cond := cond.(type) {
+			case bool:
+				if !cond {
+					continue
+				}
+			case pulumi.Output:
+				return e.error(s.If, "the condition of fn::for must be known; it cannot depend on resource outputs or secrets")
+			default:
+				return e.error(s.If, fmt.Sprintf("the condition of fn::for must be a boolean, not %v", typeString(cond)))
+			}
+		}
+
+		value, ok := body.evaluateExpr(s.Value)
+		if !ok {
+			return nil, false
+		}
+		if s.Key == nil {
+			list = append(list, value)
+			continue
+		}
+		k, ok := body.evaluateExpr(s.Key)
+		if !ok {
+			return nil, false
+		}
+		str, ok := k.(string)
+		if !ok {
+			if _, isOutput := k.(pulumi.Output); isOutput {
+				return e.error(s.Key, "the keys of fn::for must be known; they cannot depend on resource outputs or secrets")
+			}
+			return e.error(s.Key, fmt.Sprintf("the keys of fn::for must be strings, not %v", typeString(k)))
+		}
+		obj[str] = value
+	}
+	if s.Key != nil {
+		return obj, true
+	}
+	if list == nil {
+		list = []interface{}{}
+	}
+	return list, true
+}
+
+// evaluateBuiltinFindInMap evaluates fn::findInMap. Unlike fn::lookup, a missing key is an error.
+func (e *programEvaluator) evaluateBuiltinFindInMap(s *ast.FindInMapExpr) (interface{}, bool) {
+	mapping, ok := e.t.GetMappings().Get(s.MapName.Value)
+	if !ok {
+		return e.error(s.MapName, fmt.Sprintf("mapping %q is not declared", s.MapName.Value))
+	}
+	m, mapOk := e.evaluateExpr(mapping)
+	top, topOk := e.evaluateExpr(s.TopLevelKey)
+	second, secondOk := e.evaluateExpr(s.SecondLevelKey)
+	if !mapOk || !topOk || !secondOk {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		topKey, ok := args[1].(string)
+		if !ok {
+			return e.error(s.TopLevelKey, fmt.Sprintf("fn::findInMap requires a string key, got %v", typeString(args[1])))
+		}
+		secondKey, ok := args[2].(string)
+		if !ok {
+			return e.error(s.SecondLevelKey, fmt.Sprintf("fn::findInMap requires a string key, got %v", typeString(args[2])))
+		}
+		inner, ok := args[0].(map[string]interface{})[topKey].(map[string]interface{})
+		if !ok {
+			return e.error(s.TopLevelKey, fmt.Sprintf("mapping %q has no key %q", s.MapName.Value, topKey))
+		}
+		v, ok := inner[secondKey]
+		if !ok {
+			return e.error(s.SecondLevelKey, fmt.Sprintf("mapping %q has no key %q under %q", s.MapName.Value, secondKey, topKey))
+		}
+		return v, true
+	})(m, top, second)
+}
+
+// evaluateBuiltinTry evaluates fn::try. Values are evaluated in order until one is known not to be
+// null, so a fallback is only evaluated when it is needed.
+func (e *programEvaluator) evaluateBuiltinTry(s *ast.TryExpr) (interface{}, bool) {
+	var values []interface{}
+	for _, x := range s.Values {
+		var value interface{}
+		var ok bool
+		if sym, isSymbol := x.(*ast.SymbolExpr); isSymbol {
+			value, ok = e.evaluateOptionalPropertyAccess(sym, sym.Property)
+		} else {
+			value, ok = e.evaluateExpr(x)
+		}
+		if !ok {
+			return nil, false
+		}
+		if value == nil {
+			continue
+		}
+		values = append(values, value)
+		if !hasOutputs(value) {
+			break
+		}
+	}
+
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		for _, arg := range args {
+			if arg != nil {
+				return arg, true
+			}
+		}
+		return nil, true
+	})(values...)
+}
+
+// valuesEqual reports whether two evaluated values are equal, treating integer config values, including those
+// nested in lists and objects, as the numbers they represent.
+func valuesEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeNumbers(a), normalizeNumbers(b))
+}
+
+// normalizeNumbers returns v with every number in it as a float64. Typed lists and objects, such as the []int of an
+// integer list config value, are returned as []interface{} and map[string]interface{}.
+func normalizeNumbers(v interface{}) interface{} {
+	if n, ok := asNumber(v); ok {
+		return n
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = normalizeNumbers(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		obj := make(map[string]interface{}, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			obj[iter.Key().String()] = normalizeNumbers(iter.Value().Interface())
+		}
+		return obj
+	default:
+		return v
+	}
+}
+
 func (e *programEvaluator) evaluateBuiltinFileBase64(s *ast.FileBase64Expr) (interface{}, bool) {
 	expr, ok := e.evaluateExpr(s.Path)
 	if !ok {
@@ -3163,6 +4577,24 @@ func (e *programEvaluator) evaluateBuiltinSha1(s *ast.Sha1Expr) (interface{}, bo
 	})(expr)
 }
 
+func (e *programEvaluator) evaluateBuiltinMimeType(s *ast.MimeTypeExpr) (interface{}, bool) {
+	expr, ok := e.evaluateExpr(s.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		str, ok := args[0].(string)
+		if !ok {
+			return e.error(s.Value, fmt.Sprintf("fn::mimeType requires a string, got %v", typeString(args[0])))
+		}
+		if t := mime.TypeByExtension(filepath.Ext(str)); t != "" {
+			return t, true
+		}
+		// Files of unknown type are treated as arbitrary binary data.
+		return "application/octet-stream", true
+	})(expr)
+}
+
 func (e *programEvaluator) evaluateBuiltinPulumiResourceName(s *ast.PulumiResourceNameExpr) (interface{}, bool) {
 	res, ok := e.evaluateExpr(s.Resource)
 	if !ok {
@@ -3193,6 +4625,211 @@ func (e *programEvaluator) evaluateBuiltinPulumiResourceType(s *ast.PulumiResour
 	}
 }
 
+// evaluateBuiltinIf evaluates fn::if. When the condition is known only the selected branch is
+// evaluated, so a branch may refer to a resource whose condition guards it. If the condition is an
+// output, both branches are evaluated and the choice between them is made once it resolves. An
+// error in either branch, such as a reference to a resource whose condition is false, is then
+// reported even if the condition does not select it. The branches are not evaluated inside the
+// apply, since applies run concurrently with the rest of the program and the evaluator is not safe
+// for concurrent use.
+func (e *programEvaluator) evaluateBuiltinIf(v *ast.IfExpr) (interface{}, bool) {
+	cond, ok := e.evaluateExpr(v.Condition)
+	if !ok {
+		return nil, false
+	}
+	if b, ok := cond.(bool); ok {
+		if b {
+			return e.evaluateExpr(v.Then)
+		}
+		return e.evaluateExpr(v.Else)
+	}
+
+	then, thenOk := e.evaluateExpr(v.Then)
+	els, elseOk := e.evaluateExpr(v.Else)
+	if !thenOk || !elseOk {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		b, ok := args[0].(bool)
+		if !ok {
+			return e.error(v.Condition, fmt.Sprintf("the condition of fn::if must be a boolean, not %v", typeString(args[0])))
+		}
+		if b {
+			return args[1], true
+		}
+		return args[2], true
+	})(cond, then, els)
+}
+
+func (e *programEvaluator) evaluateBuiltinNot(v *ast.NotExpr) (interface{}, bool) {
+	value, ok := e.evaluateExpr(v.Value)
+	if !ok {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		b, ok := args[0].(bool)
+		if !ok {
+			return e.error(v.Value, fmt.Sprintf("fn::not requires a boolean, not %v", typeString(args[0])))
+		}
+		return !b, true
+	})(value)
+}
+
+// evaluateBuiltinLogical evaluates fn::and and fn::or. The values are evaluated in order, and evaluation stops at the
+// first boolean that decides the result, so a value may refer to a resource whose condition an earlier value guards.
+// Outputs are combined once they resolve; the values after them are still evaluated, as in fn::if.
+func (e *programEvaluator) evaluateBuiltinLogical(v *ast.LogicalExpr) (interface{}, bool) {
+	// fn::and is true unless a value is false, and fn::or is false unless a value is true.
+	identity := v.Operator == ast.LogicalAnd
+	var values []interface{}
+	var exprs []ast.Expr
+	for _, x := range v.Values {
+		value, ok := e.evaluateExpr(x)
+		if !ok {
+			return nil, false
+		}
+		switch value := value.(type) {
+		case bool:
+			if value != identity {
+				return value, true
+			}
+		case pulumi.Output, poisonMarker:
+			values, exprs = append(values, value), append(exprs, x)
+		default:
+			return e.error(x, fmt.Sprintf("fn::%s requires booleans, not %v", v.Operator, typeString(value)))
+		}
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		for i, arg := range args {
+			b, ok := arg.(bool)
+			if !ok {
+				return e.error(exprs[i], fmt.Sprintf("fn::%s requires booleans, not %v", v.Operator, typeString(arg)))
+			}
+			if b != identity {
+				return b, true
+			}
+		}
+		return identity, true
+	})(values...)
+}
+
+func (e *programEvaluator) evaluateBuiltinComparison(v *ast.ComparisonExpr) (interface{}, bool) {
+	left, leftOk := e.evaluateExpr(v.Left)
+	right, rightOk := e.evaluateExpr(v.Right)
+	if !leftOk || !rightOk {
+		return nil, false
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		switch v.Operator {
+		case ast.Equals:
+			return valuesEqual(args[0], args[1]), true
+		case ast.NotEquals:
+			return !valuesEqual(args[0], args[1]), true
+		}
+
+		left, right := args[0], args[1]
+		if l, ok := asNumber(left); ok {
+			left = l
+		}
+		if r, ok := asNumber(right); ok {
+			right = r
+		}
+
+		var c int
+		switch l := left.(type) {
+		case float64:
+			r, ok := right.(float64)
+			if !ok {
+				return e.error(v.Right, fmt.Sprintf("fn::%s cannot compare a number with %v", v.Operator, typeString(right)))
+			}
+			c = cmp.Compare(l, r)
+		case string:
+			r, ok := right.(string)
+			if !ok {
+				return e.error(v.Right, fmt.Sprintf("fn::%s cannot compare a string with %v", v.Operator, typeString(right)))
+			}
+			c = strings.Compare(l, r)
+		default:
+			return e.error(v.Left, fmt.Sprintf("fn::%s requires numbers or strings, not %v", v.Operator, typeString(left)))
+		}
+
+		switch v.Operator {
+		case ast.LessThan:
+			return c < 0, true
+		case ast.LessThanOrEqual:
+			return c <= 0, true
+		case ast.GreaterThan:
+			return c > 0, true
+		case ast.GreaterThanOrEqual:
+			return c >= 0, true
+		default:
+			panic(fmt.Sprintf("fatal: invalid comparison operator %q", v.Operator))
+		}
+	})(left, right)
+}
+
+func (e *programEvaluator) evaluateBuiltinArithmetic(v *ast.ArithmeticExpr) (interface{}, bool) {
+	values := make([]interface{}, len(v.Values))
+	for i, x := range v.Values {
+		value, ok := e.evaluateExpr(x)
+		if !ok {
+			return nil, false
+		}
+		values[i] = value
+	}
+	return e.lift(func(args ...interface{}) (interface{}, bool) {
+		var result float64
+		for i, arg := range args {
+			n, ok := asNumber(arg)
+			if !ok {
+				return e.error(v.Values[i], fmt.Sprintf("fn::%s requires numbers, not %v", v.Operator, typeString(arg)))
+			}
+			if i == 0 {
+				result = n
+				continue
+			}
+
+			switch v.Operator {
+			case ast.Add:
+				result += n
+			case ast.Subtract:
+				result -= n
+			case ast.Multiply:
+				result *= n
+			case ast.Divide, ast.Modulo:
+				if n == 0 {
+					return e.error(v.Values[i], fmt.Sprintf("fn::%s cannot divide by zero", v.Operator))
+				}
+				if v.Operator == ast.Divide {
+					result /= n
+				} else {
+					result = math.Mod(result, n)
+				}
+			case ast.Minimum:
+				result = math.Min(result, n)
+			case ast.Maximum:
+				result = math.Max(result, n)
+			default:
+				panic(fmt.Sprintf("fatal: invalid arithmetic operator %q", v.Operator))
+			}
+		}
+		return result, true
+	})(values...)
+}
+
+// asNumber returns v as a float64 if it is a number. Numbers are float64 except for integer config
+// values, which are ints.
+func asNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
 func hasOutputs(v interface{}) bool {
 	switch v := v.(type) {
 	case pulumi.Output:
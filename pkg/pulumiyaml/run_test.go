@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	b64 "encoding/base64"
@@ -2530,7 +2531,7 @@ func TestHandleUnknownPropertiesDuringPreview(t *testing.T) {
 			evalContext: &evalContext{
 				Runner: &Runner{
 					t: &ast.TemplateDecl{},
-					resources: map[string]lateboundResource{
+					resources: map[string]resourceValue{
 						"image": &mockLateboundResource{
 							resourceSchema: &schema.Resource{
 								InputProperties: []*schema.Property{
@@ -2664,6 +2665,8 @@ func (st *mockLateboundResource) GetResourceSchema() *schema.Resource {
 	return st.resourceSchema
 }
 
+func (*mockLateboundResource) isResourceValue() {}
+
 // TestResourceMissingType ensures that we fail with an error message when a resource is missing a type.
 func TestResourceMissingType(t *testing.T) {
 	t.Parallel()
@@ -2702,7 +2705,7 @@ func TestHandleUnknownNestedPropertiesDuringPreview(t *testing.T) {
 			evalContext: &evalContext{
 				Runner: &Runner{
 					t: &ast.TemplateDecl{},
-					resources: map[string]lateboundResource{
+					resources: map[string]resourceValue{
 						"image": &mockLateboundResource{
 							resourceSchema: &schema.Resource{
 								InputProperties: []*schema.Property{
@@ -2755,7 +2758,7 @@ func TestUnknownsDuringPreviewNotUpdate(t *testing.T) {
 				evalContext: &evalContext{
 					Runner: &Runner{
 						t: &ast.TemplateDecl{},
-						resources: map[string]lateboundResource{
+						resources: map[string]resourceValue{
 							"image": &mockLateboundResource{
 								resourceSchema: &schema.Resource{
 									InputProperties: []*schema.Property{
@@ -3041,3 +3044,1548 @@ components:
 		Summary:  "missing required `name` field.",
 	}}}, diags)
 }
+
+func TestLoadDirMergesTemplateFragments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte(`name: test-yaml
+runtime: yaml
+config:
+  prefix:
+    type: string
+resources:
+  bucket:
+    type: test:resource:type
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.network.yaml"), []byte(`variables:
+  cidr: 10.0.0.0/16
+resources:
+  vpc:
+    type: test:resource:type
+    properties:
+      foo: ${cidr}
+outputs:
+  vpcId: ${vpc.id}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.storage.yml"), []byte(`resources:
+  logs:
+    type: test:resource:type
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.dns.json"), []byte(`{"resources": {"zone": {"type": "test:resource:type"}}}`), 0o600))
+	// Stack configuration and other YAML files are not part of the program, even if they look like a template.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.dev.yaml"), []byte(`config:
+  test-yaml:prefix: dev
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixtures.yaml"), []byte(`resources:
+  fixture:
+    type: test:resource:type
+`), 0o600))
+
+	tmpl, diags, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	var resources []string
+	for _, r := range tmpl.Resources.Entries {
+		resources = append(resources, r.Key.Value)
+	}
+	assert.Equal(t, []string{"bucket", "zone", "vpc", "logs"}, resources)
+	require.Len(t, tmpl.Config.Entries, 1)
+	require.Len(t, tmpl.Variables.Entries, 1)
+	require.Len(t, tmpl.Outputs.Entries, 1)
+	assert.Equal(t, "Main.network.yaml", tmpl.Resources.Entries[2].Key.Syntax().Syntax().Range().Filename)
+}
+
+func TestLoadDirDuplicateKeysAcrossFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.yaml"), []byte(`resources:
+  bucket:
+    type: test:resource:type
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.storage.yaml"), []byte(`resources:
+  bucket:
+    type: test:resource:type
+`), 0o600))
+
+	_, diags, err := LoadDir(dir)
+	require.Error(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Main.storage.yaml:2:3: found duplicate resource bucket; resource bucket was previously declared at Main.yaml:2,3-9", diagString(diags[0]))
+	assert.Equal(t, "resource bucket was previously declared at Main.yaml:2,3-9", diags[0].Detail)
+}
+
+func TestLoadDirImports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "lib"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte(`name: test-yaml
+runtime: yaml
+imports:
+  - lib/network.yaml
+  - path: lib/tags.yaml
+    namespace: std
+variables:
+  subnet: ${network.cidr}
+  owner: ${std.tags.owner}
+  vpcFoo: ${network.vpc.foo}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "network.yaml"), []byte(`imports:
+  - tags.yaml
+variables:
+  cidr: 10.0.0.0/16
+resources:
+  vpc:
+    type: test:resource:type
+    properties:
+      foo: ${tags.tags.foo}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "tags.yaml"), []byte(`variables:
+  tags:
+    owner: platform
+    foo: oof
+`), 0o600))
+
+	tmpl, diags, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	var resources []string
+	for _, r := range tmpl.Resources.Entries {
+		resources = append(resources, r.Key.Value)
+	}
+	assert.Equal(t, []string{"network.vpc"}, resources)
+	assert.Equal(t, "network-vpc", tmpl.Resources.Entries[0].Value.Name.Value)
+	assert.Equal(t, "lib/network.yaml", tmpl.Resources.Entries[0].Key.Syntax().Syntax().Range().Filename)
+
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.Equal(t, "10.0.0.0/16", e.variables["subnet"])
+		assert.Equal(t, "platform", e.variables["owner"])
+		out := e.variables["vpcFoo"].(pulumi.AnyOutput).ApplyT(func(x interface{}) (interface{}, error) {
+			assert.Equal(t, "qux", x)
+			return nil, nil
+		})
+		e.pulumiCtx.Export("out", out)
+	})
+}
+
+func TestLoadDirImportCycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.yaml"), []byte(`imports:
+  - a.yaml
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(`imports:
+  - b.yaml
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(`imports:
+  - a.yaml
+`), 0o600))
+
+	_, diags, err := LoadDir(dir)
+	require.Error(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "b.yaml:2:5: import cycle: a.yaml -> b.yaml -> a.yaml", diagString(diags[0]))
+}
+
+func TestLoadDirImportOutsideProject(t *testing.T) {
+	t.Parallel()
+
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "a.yaml"), []byte(`variables:
+  foo: bar
+`), 0o600))
+	abs := filepath.ToSlash(filepath.Join(outside, "a.yaml"))
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.yaml"), []byte(`imports:
+  - lib/b.yaml
+  - path: `+abs+`
+    namespace: abs
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "b.yaml"), []byte(`imports:
+  - ../c.yaml
+  - ../../a.yaml
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.yaml"), []byte(`variables:
+  foo: bar
+`), 0o600))
+
+	_, diags, err := LoadDir(dir)
+	require.Error(t, err)
+	require.Len(t, diags, 2)
+	assert.Equal(t, "lib/b.yaml:3:5: import ../../a.yaml is outside of the project directory; "+
+		"Imports are read from the project directory.", diagString(diags[0]))
+	assert.Equal(t, "Main.yaml:3:11: import "+abs+" must be a relative path; "+
+		"Imports are read from the project directory.", diagString(diags[1]))
+}
+
+func TestLoadDirFragmentImports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.yaml"), []byte(`variables:
+  foo: bar
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.network.yaml"), []byte(`imports:
+  - lib/tags.yaml
+resources:
+  vpc:
+    type: test:resource:type
+`), 0o600))
+
+	_, diags, err := LoadDir(dir)
+	require.Error(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Main.network.yaml:2:5: template fragment Main.network.yaml may not declare imports; "+
+		"Declare the imports in the main template instead.", diagString(diags[0]))
+}
+
+func TestLoadDirFragmentSections(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.yaml"), []byte(`variables:
+  foo: bar
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Main.deployment.yaml"), []byte(`kind: Deployment
+resources:
+  vpc:
+    type: test:resource:type
+`), 0o600))
+
+	_, diags, err := LoadDir(dir)
+	require.Error(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Main.deployment.yaml:1:1: template fragment Main.deployment.yaml may not declare kind; "+
+		"Fragments may only declare config, variables, resources and outputs.", diagString(diags[0]))
+}
+
+func TestLoadYAMLImports(t *testing.T) {
+	t.Parallel()
+
+	tmpl, diags, err := LoadYAMLBytes("Main.yaml", []byte(`imports:
+  - lib/network.yaml
+variables:
+  cidr: ${network.cidr}
+`))
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Main.yaml:2:5: imports are only supported when loading a program from its directory; "+
+		"Load the program with LoadDir, or merge the imported templates into this one.", diagString(diags[0]))
+}
+
+func TestResourceCondition(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  isProd: false
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+  res-b:
+    type: test:resource:type
+    condition: ${isProd}
+    properties:
+      foo: oof
+  res-c:
+    type: test:resource:type
+    condition: ${isProd}
+    properties:
+      foo: ${res-b.bar}
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.IsType(t, &lateboundCustomResourceState{}, e.resources["res-a"])
+		assert.IsType(t, skippedResource{}, e.resources["res-b"])
+		assert.IsType(t, skippedResource{}, e.resources["res-c"])
+		_, isResource := e.resources["res-b"].(lateboundResource)
+		assert.False(t, isResource, "a skipped resource has no outputs to read")
+	})
+}
+
+func TestResourceConditionReference(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+resources:
+  res-a:
+    type: test:resource:type
+    condition: false
+    properties:
+      foo: oof
+outputs:
+  out: ${res-a.bar}
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, `<stdin>:10:8: resource "res-a" is not registered because its condition is false; `+
+		`The condition is declared at <stdin>:6,16-21. Resources that reference a conditional resource should share its condition.`,
+		diagString(diags[0]))
+}
+
+func TestResourceConditionType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+resources:
+  res-a:
+    type: test:resource:type
+    condition: [ true ]
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Contains(t, diagString(diags[0]), "<stdin>:6:16: boolean is not assignable from")
+}
+
+func TestLoadTemplateResourceCondition(t *testing.T) {
+	t.Parallel()
+
+	tmpl := template(t, &Template{
+		Name: "test-yaml",
+		Conditions: map[string]interface{}{
+			"isProd": false,
+		},
+		Resources: map[string]*Resource{
+			"res-a": {
+				Type:       "test:resource:type",
+				Properties: map[string]interface{}{"foo": "oof"},
+			},
+			"res-b": {
+				Type:       "test:resource:type",
+				Properties: map[string]interface{}{"foo": "oof"},
+				Condition:  "isProd",
+			},
+		},
+	})
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.IsType(t, &lateboundCustomResourceState{}, e.resources["res-a"])
+		assert.IsType(t, skippedResource{}, e.resources["res-b"])
+	})
+}
+
+func TestMappingsAndConditions(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+config:
+  env:
+    default: prod
+mappings:
+  envMap:
+    prod:
+      value: oof
+      replicas: 3
+    dev:
+      value: dev
+      replicas: 1
+conditions:
+  isProd:
+    fn::equals: [ "${env}", prod ]
+  isDev:
+    fn::not: ${isProd}
+resources:
+  res-a:
+    type: test:resource:type
+    condition: ${isProd}
+    properties:
+      foo:
+        fn::findInMap: [ envMap, "${env}", value ]
+  res-b:
+    type: test:resource:type
+    condition: ${isDev}
+    properties:
+      foo: oof
+outputs:
+  replicas:
+    fn::findInMap: [ envMap, prod, replicas ]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.Equal(t, true, e.variables["isProd"])
+		assert.Equal(t, false, e.variables["isDev"])
+		assert.IsType(t, &lateboundCustomResourceState{}, e.resources["res-a"])
+		assert.IsType(t, skippedResource{}, e.resources["res-b"])
+	})
+}
+
+func TestFindInMapErrors(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+mappings:
+  envMap:
+    prod:
+      value: oof
+variables:
+  missingMap:
+    fn::findInMap: [ regionMap, prod, value ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	var diagStrings []string
+	for _, v := range diags {
+		diagStrings = append(diagStrings, diagString(v))
+	}
+	assert.ElementsMatch(t, []string{
+		`<stdin>:9:22: mapping "regionMap" is not declared`,
+	}, diagStrings)
+
+	const text2 = `name: test-yaml
+runtime: yaml
+mappings:
+  envMap:
+    prod:
+      value: oof
+variables:
+  missingKey:
+    fn::findInMap: [ envMap, prod, other ]
+`
+	tmpl = yamlTemplate(t, text2)
+	diags = testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	diagStrings = nil
+	for _, v := range diags {
+		diagStrings = append(diagStrings, diagString(v))
+	}
+	assert.ElementsMatch(t, []string{
+		`<stdin>:9:36: mapping "envMap" has no key "other" under "prod"`,
+	}, diagStrings)
+}
+
+func TestMappingsMustBeLiteral(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+mappings:
+  envMap:
+    prod:
+      value: ${foo}
+`
+	_, diags, err := LoadYAMLBytes("<stdin>", []byte(text))
+	require.NoError(t, err)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "<stdin>:6:14: mappings may only contain literal values; "+
+		"Mappings are static; use a variable for values that are computed.", diagString(diags[0]))
+}
+
+func TestConditionType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+conditions:
+  isProd: prod
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Contains(t, diagString(diags[0]), "<stdin>:4:11: boolean is not assignable from")
+}
+
+func TestResourceCount(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+resources:
+  bucket:
+    type: test:resource:type
+    count: 3
+    properties:
+      foo: bucket-${range.value}
+  last:
+    type: test:resource:type
+    properties:
+      foo: ${bucket[2].foo}
+`
+	tmpl := yamlTemplate(t, text)
+	var mutex sync.Mutex
+	registered := map[string]string{}
+	mocks := &testMonitor{
+		NewResourceF: func(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			registered[args.Name] = args.Inputs["foo"].StringValue()
+			return args.Name, args.Inputs, nil
+		},
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		runner := newRunner(tmpl, newMockPackageMap())
+		_, diags := TypeCheck(runner)
+		requireNoErrors(t, tmpl, diags)
+		diags = runner.Evaluate(ctx)
+		requireNoErrors(t, tmpl, diags)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"bucket-0": "bucket-0",
+		"bucket-1": "bucket-1",
+		"bucket-2": "bucket-2",
+		"last":     "bucket-2",
+	}, registered)
+}
+
+func TestResourceForEach(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  sizes:
+    small: 1
+    large: 8
+resources:
+  disk:
+    type: test:resource:type
+    forEach: ${sizes}
+    properties:
+      foo: ${range.key}-${range.value}
+  backup:
+    type: test:resource:type
+    forEach: [ "x", "y" ]
+    properties:
+      foo: ${disk["large"].foo}-${range.value}
+`
+	tmpl := yamlTemplate(t, text)
+	var mutex sync.Mutex
+	registered := map[string]string{}
+	mocks := &testMonitor{
+		NewResourceF: func(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			registered[args.Name] = args.Inputs["foo"].StringValue()
+			return args.Name, args.Inputs, nil
+		},
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		runner := newRunner(tmpl, newMockPackageMap())
+		_, diags := TypeCheck(runner)
+		requireNoErrors(t, tmpl, diags)
+		diags = runner.Evaluate(ctx)
+		requireNoErrors(t, tmpl, diags)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"disk-small": "small-1",
+		"disk-large": "large-8",
+		"backup-0":   "large-8-x",
+		"backup-1":   "large-8-y",
+	}, registered)
+}
+
+func TestResourceRangeOptions(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+resources:
+  bucket:
+    type: test:resource:type
+    count: 2
+  disk:
+    type: test:resource:type
+    forEach:
+      small: 1
+      large: 8
+  listed:
+    type: test:resource:type
+    options:
+      dependsOn: [ "${bucket}", "${disk}" ]
+      parent: ${bucket[1]}
+  all:
+    type: test:resource:type
+    options:
+      dependsOn: ${disk}
+`
+	tmpl := yamlTemplate(t, text)
+	var mutex sync.Mutex
+	dependencies := map[string][]string{}
+	parents := map[string]string{}
+	mocks := &testMonitor{
+		NewResourceF: func(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, urn := range args.RegisterRPC.GetDependencies() {
+				dependencies[args.Name] = append(dependencies[args.Name], resource.URN(urn).Name())
+			}
+			if parent := args.RegisterRPC.GetParent(); parent != "" {
+				parents[args.Name] = resource.URN(parent).Name()
+			}
+			return args.Name, args.Inputs, nil
+		},
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		runner := newRunner(tmpl, newMockPackageMap())
+		_, diags := TypeCheck(runner)
+		requireNoErrors(t, tmpl, diags)
+		diags = runner.Evaluate(ctx)
+		requireNoErrors(t, tmpl, diags)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bucket-0", "bucket-1", "disk-large", "disk-small"}, dependencies["listed"])
+	assert.Equal(t, []string{"disk-large", "disk-small"}, dependencies["all"])
+	assert.Equal(t, "bucket-1", parents["listed"])
+}
+
+func TestResourceForEachType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+resources:
+  res-a:
+    type: test:resource:type
+    forEach: true
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:6:14: forEach must be a list or an object, not boolean", diagString(diags[0]))
+}
+
+func TestConditionalBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  env: prod
+  isProd:
+    fn::equals: [ "${env}", "prod" ]
+  isDev:
+    fn::notEquals: [ "${env}", "prod" ]
+  small:
+    fn::lessThan: [ 1, 2 ]
+  notSmall:
+    fn::not: ${small}
+  both:
+    fn::and: [ "${isProd}", "${small}", "${notSmall}" ]
+  either:
+    fn::or: [ "${isDev}", "${notSmall}", "${isProd}" ]
+  instanceSize:
+    fn::if: [ "${isProd}", "large", "small" ]
+  guarded:
+    fn::if: [ "${isDev}", "${res-a.bar}", "none" ]
+  guardedAnd:
+    fn::and: [ "${isDev}", { fn::equals: [ "${res-a.bar}", 1 ] } ]
+  guardedOr:
+    fn::or: [ "${isProd}", { fn::equals: [ "${res-a.bar}", 1 ] } ]
+resources:
+  res-a:
+    type: test:resource:type
+    condition: ${isDev}
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, true, e.variables["isProd"])
+		assert.Equal(t, false, e.variables["isDev"])
+		assert.Equal(t, true, e.variables["small"])
+		assert.Equal(t, false, e.variables["notSmall"])
+		assert.Equal(t, false, e.variables["both"])
+		assert.Equal(t, true, e.variables["either"])
+		assert.Equal(t, "large", e.variables["instanceSize"])
+		assert.Equal(t, "none", e.variables["guarded"])
+		assert.Equal(t, false, e.variables["guardedAnd"])
+		assert.Equal(t, true, e.variables["guardedOr"])
+		assert.IsType(t, skippedResource{}, e.resources["res-a"])
+	})
+}
+
+func TestEqualsNestedConfigNumbers(t *testing.T) { //nolint:paralleltest
+	const text = `name: test-yaml
+runtime: yaml
+configuration:
+  ports:
+    type: List<number>
+  names:
+    type: List<string>
+variables:
+  samePorts:
+    fn::equals: [ "${ports}", [ 80, 443 ] ]
+  sameObjects:
+    fn::equals: [ { ports: "${ports}" }, { ports: [ 80, 443 ] } ]
+  otherPorts:
+    fn::notEquals: [ "${ports}", [ 80 ] ]
+  sameNames:
+    fn::equals: [ "${names}", [ a, b ] ]
+  hasPorts:
+    fn::contains: [ [ "${ports}" ], [ 80, 443 ] ]
+`
+	tmpl := yamlTemplate(t, text)
+	setConfig(t,
+		resource.PropertyMap{
+			projectConfigKey("ports"): resource.NewStringProperty("[80, 443]"),
+			projectConfigKey("names"): resource.NewStringProperty(`["a", "b"]`),
+		})
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.Equal(t, true, e.variables["samePorts"])
+		assert.Equal(t, true, e.variables["sameObjects"])
+		assert.Equal(t, true, e.variables["otherPorts"])
+		assert.Equal(t, true, e.variables["sameNames"])
+		assert.Equal(t, true, e.variables["hasPorts"])
+	})
+}
+
+func TestConditionalBuiltinsType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  size:
+    fn::if: [ [ true ], "large", "small" ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diagString(diags[0]), "<stdin>:5:15: boolean is not assignable from")
+}
+
+func TestComparisonBuiltinsType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  small:
+    fn::lessThan: [ [ 1 ], 2 ]
+  early:
+    fn::greaterThanOrEqual: [ "a", "b" ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Contains(t, diagString(diags[0]), "<stdin>:5:21: Union<number, string> is not assignable from")
+}
+
+func TestConditionalBuiltinsOutputCondition(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  isUp:
+    fn::equals: [ "${res-a.bar}", "oof" ]
+  instanceSize:
+    fn::if: [ "${isUp}", "large", "small" ]
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		out := e.variables["instanceSize"].(pulumi.AnyOutput).ApplyT(func(x interface{}) (interface{}, error) {
+			assert.Equal(t, "large", x)
+			return nil, nil
+		})
+		e.pulumiCtx.Export("out", out)
+	})
+}
+
+func TestConditionalBuiltinsOutputConditionEvaluatesBothBranches(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  isUp:
+    fn::equals: [ "${res-a.bar}", "oof" ]
+  guarded:
+    fn::if: [ "${isUp}", "none", "${res-b.bar}" ]
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+  res-b:
+    type: test:resource:type
+    condition: false
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), `resource "res-b" is not registered because its condition is false`)
+}
+
+func TestArithmeticBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  base: 8000
+  sum:
+    fn::add: [ "${base}", 80, 1 ]
+  difference:
+    fn::sub: [ 10, 3, 2 ]
+  product:
+    fn::mul: [ 2, 3, 4 ]
+  quotient:
+    fn::div: [ 7, 2 ]
+  remainder:
+    fn::mod: [ 7, 2 ]
+  smallest:
+    fn::min: [ 3, 1, 2 ]
+  largest:
+    fn::max: [ 3, 1, 2 ]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, 8081.0, e.variables["sum"])
+		assert.Equal(t, 5.0, e.variables["difference"])
+		assert.Equal(t, 24.0, e.variables["product"])
+		assert.Equal(t, 3.5, e.variables["quotient"])
+		assert.Equal(t, 1.0, e.variables["remainder"])
+		assert.Equal(t, 1.0, e.variables["smallest"])
+		assert.Equal(t, 3.0, e.variables["largest"])
+	})
+}
+
+func TestArithmeticDivideByZero(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  zero: 0
+  ratio:
+    fn::div: [ 1, "${zero}" ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:6:19: fn::div cannot divide by zero", diagString(diags[0]))
+}
+
+func TestStringBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  raw: "  My_Service  "
+  trimmed:
+    fn::trim: ${raw}
+  lowered:
+    fn::lower: ${trimmed}
+  upper:
+    fn::upper: ${trimmed}
+  dashed:
+    fn::replace: [ "${lowered}", "_", "-" ]
+  short:
+    fn::substring: [ "${dashed}", 0, 5 ]
+  rest:
+    fn::substring: [ "${dashed}", 3, -1 ]
+  clamped:
+    fn::substring: [ "${dashed}", 0, 63 ]
+  formatted:
+    fn::format: [ "%s-%03d-%.2f-%v%%", "${dashed}", 7, 2.5, [ 1, "a" ] ]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, "My_Service", e.variables["trimmed"])
+		assert.Equal(t, "my_service", e.variables["lowered"])
+		assert.Equal(t, "MY_SERVICE", e.variables["upper"])
+		assert.Equal(t, "my-service", e.variables["dashed"])
+		assert.Equal(t, "my-se", e.variables["short"])
+		assert.Equal(t, "service", e.variables["rest"])
+		assert.Equal(t, "my-service", e.variables["clamped"])
+		assert.Equal(t, `my-service-007-2.50-[1,"a"]%`, e.variables["formatted"])
+	})
+}
+
+func TestFormatDiags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{`[ "%s-%s", "a" ]`, "fn::format: %s is missing an argument"},
+		{`[ "%s", "a", "b" ]`, "fn::format: the format string uses 1 of 2 arguments"},
+		{`[ "%d", 1.5 ]`, "fn::format: %d requires a whole number, not 1.5"},
+		{`[ "%x", 1 ]`, "fn::format: unsupported verb %x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			text := `name: test-yaml
+runtime: yaml
+variables:
+  formatted:
+    fn::format: ` + tt.format + `
+`
+			tmpl := yamlTemplate(t, text)
+			diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+			require.True(t, diags.HasErrors())
+			require.Len(t, diags, 1)
+			assert.Equal(t, tt.expected, diags[0].Summary)
+		})
+	}
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  arn: arn:aws:s3:::my-bucket
+  isBucket:
+    fn::regexMatch: [ "^arn:aws:s3:::[a-z0-9.-]+$", "${arn}" ]
+  bucket:
+    fn::regexReplace: [ "^arn:aws:s3:::(?P<name>.*)$", "${arn}", "$${name}" ]
+  parts:
+    fn::regexFind: [ "[a-z0-9]+", "${bucket}" ]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, true, e.variables["isBucket"])
+		assert.Equal(t, "my-bucket", e.variables["bucket"])
+		assert.Equal(t, []interface{}{"my", "bucket"}, e.variables["parts"])
+	})
+}
+
+func TestRegexReplaceCaptureGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		replacement string
+		expected    string
+	}{
+		{`"$1"`, "my-bucket"},
+		{`"$${1}"`, "my-bucket"},
+		{`"$${name}"`, "my-bucket"},
+		{`"$${name}-logs"`, "my-bucket-logs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.replacement, func(t *testing.T) {
+			t.Parallel()
+
+			text := `name: test-yaml
+runtime: yaml
+variables:
+  bucket:
+    fn::regexReplace: [ "^arn:aws:s3:::(?P<name>.*)$", "arn:aws:s3:::my-bucket", ` + tt.replacement + ` ]
+`
+			tmpl := yamlTemplate(t, text)
+			testTemplate(t, tmpl, func(e *programEvaluator) {
+				assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+				assert.Equal(t, tt.expected, e.variables["bucket"])
+			})
+		})
+	}
+}
+
+func TestRegexInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  isBucket:
+    fn::regexMatch: [ "(unclosed", "value" ]
+`
+	_, diags, err := LoadYAMLBytes("<stdin>", []byte(text))
+	require.NoError(t, err)
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:5:23: invalid regular expression: error parsing regexp: missing closing ): `(unclosed`",
+		diagString(diags[0]))
+}
+
+func TestRegexUnknown(t *testing.T) {
+	t.Parallel()
+
+	tmpl := yamlTemplate(t, "name: test-yaml\nruntime: yaml\n")
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		e.variables["unknown"] = pulumi.UnsafeUnknownOutput(nil)
+		source, diags := ast.ParseExpr(syntax.String("${unknown}"))
+		require.False(t, diags.HasErrors())
+
+		v, ok := e.evaluateBuiltinRegex(ast.Regex(ast.RegexMatch, ast.String("^t"), source))
+		require.True(t, ok)
+		out, ok := v.(pulumi.AnyOutput)
+		require.True(t, ok)
+		e.pulumiCtx.Export("matches", out.ApplyT(func(x interface{}) (interface{}, error) {
+			t.Error("fn::regexMatch over an unknown value should be unknown")
+			return nil, nil
+		}))
+	})
+}
+
+func TestCollectionBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  defaultTags:
+    team: infra
+    env: dev
+  tags:
+    fn::merge:
+      - ${defaultTags}
+      - env: prod
+        app: web
+  tagKeys:
+    fn::keys: ${tags}
+  tagValues:
+    fn::values: ${tags}
+  subnets:
+    fn::concat: [ [ "a", "b" ], [ "c" ] ]
+  flat:
+    fn::flatten: [ [ 1, [ 2, 3 ] ], 4 ]
+  unique:
+    fn::distinct: [ "a", "b", "a", "c", "b" ]
+  hasB:
+    fn::contains: [ "${subnets}", "b" ]
+  hasZ:
+    fn::contains: [ "${subnets}", "z" ]
+  pairs:
+    fn::zip: [ [ "a", "b" ], [ 1, 2 ] ]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, map[string]interface{}{"team": "infra", "env": "prod", "app": "web"}, e.variables["tags"])
+		assert.Equal(t, []interface{}{"app", "env", "team"}, e.variables["tagKeys"])
+		assert.Equal(t, []interface{}{"web", "prod", "infra"}, e.variables["tagValues"])
+		assert.Equal(t, []interface{}{"a", "b", "c"}, e.variables["subnets"])
+		assert.Equal(t, []interface{}{1.0, 2.0, 3.0, 4.0}, e.variables["flat"])
+		assert.Equal(t, []interface{}{"a", "b", "c"}, e.variables["unique"])
+		assert.Equal(t, true, e.variables["hasB"])
+		assert.Equal(t, false, e.variables["hasZ"])
+		assert.Equal(t, []interface{}{[]interface{}{"a", 1.0}, []interface{}{"b", 2.0}}, e.variables["pairs"])
+	})
+}
+
+func TestZipLengthMismatch(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  pairs:
+    fn::zip: [ [ "a", "b" ], [ 1 ] ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:5:14: fn::zip requires lists of the same length, got 2 and 1", diagString(diags[0]))
+}
+
+func TestLookupAndTry(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  settings:
+    size: small
+    zones: [ "a" ]
+  size:
+    fn::lookup: [ "${settings}", "size", "large" ]
+  tier:
+    fn::lookup: [ "${settings}", "tier", "standard" ]
+  replicas:
+    fn::try: [ "${settings.scaling.replicas}", 1 ]
+  zone:
+    fn::try: [ "${settings.zones[1]}", "${settings.zones[0]}", "none" ]
+  bar:
+    fn::try: [ "${res-a.bar}", "skipped" ]
+resources:
+  res-a:
+    type: test:resource:type
+    condition: false
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, "small", e.variables["size"])
+		assert.Equal(t, "standard", e.variables["tier"])
+		assert.Equal(t, 1.0, e.variables["replicas"])
+		assert.Equal(t, "a", e.variables["zone"])
+		assert.Equal(t, "skipped", e.variables["bar"])
+	})
+}
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  ports: [ 80, 443, 8080 ]
+  tags:
+    env: dev
+    team: web
+  rules:
+    fn::for:
+      in: ${ports}
+      value:
+        port: ${range.value}
+        priority: ${range.key}
+  public:
+    fn::for:
+      in: ${ports}
+      value: ${range.value}
+      if:
+        fn::lessThan: [ "${range.value}", 1024 ]
+  labels:
+    fn::for:
+      in: ${tags}
+      key: label-${range.key}
+      value:
+        fn::upper: ${range.value}
+  pairs:
+    fn::for:
+      in: ${tags}
+      value: ${range.key}=${range.value}
+  none:
+    fn::for:
+      in: []
+      value: ${range.value}
+  outputs:
+    fn::for:
+      in: [ "${res-a.bar}" ]
+      value: ${range.value}
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"port": 80.0, "priority": 0.0},
+			map[string]interface{}{"port": 443.0, "priority": 1.0},
+			map[string]interface{}{"port": 8080.0, "priority": 2.0},
+		}, e.variables["rules"])
+		assert.Equal(t, []interface{}{80.0, 443.0}, e.variables["public"])
+		assert.Equal(t, map[string]interface{}{"label-env": "DEV", "label-team": "WEB"}, e.variables["labels"])
+		assert.Equal(t, []interface{}{"env=dev", "team=web"}, e.variables["pairs"])
+		assert.Equal(t, []interface{}{}, e.variables["none"])
+		// The collection is known, so its elements are visited even though they are outputs.
+		outputs, ok := e.variables["outputs"].([]interface{})
+		require.True(t, ok, "expected a list, got %v", e.variables["outputs"])
+		require.Len(t, outputs, 1)
+		assert.Implements(t, (*pulumi.Output)(nil), outputs[0])
+	})
+}
+
+func TestForErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name: "collection",
+			value: `
+      in: 42
+      value: ${range.value}`,
+			expected: "fn::for requires a list or an object, got a number",
+		},
+		{
+			name: "condition",
+			value: `
+      in: [ 1 ]
+      value: ${range.value}
+      if: yes`,
+			expected: "Cannot assign type 'string' to type 'boolean'",
+		},
+		{
+			name: "key",
+			value: `
+      in: [ 1 ]
+      key: ${range.value}
+      value: ${range.value}`,
+			expected: "the keys of fn::for must be strings, not a number",
+		},
+		{
+			name: "output collection",
+			value: `
+      in:
+        fn::split: [ ",", "${res-a.bar}" ]
+      value: ${range.value}`,
+			expected: "the collection of fn::for must be known; it cannot depend on resource outputs or secrets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			text := `name: test-yaml
+runtime: yaml
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+variables:
+  result:
+    fn::for:` + tt.value + "\n"
+			tmpl := yamlTemplate(t, text)
+			diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), tt.expected)
+		})
+	}
+}
+
+func TestMimeType(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  html:
+    fn::mimeType: ./site/index.html
+  unknown:
+    fn::mimeType: ./data.unknown-extension
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, "text/html; charset=utf-8", e.variables["html"])
+		assert.Equal(t, "application/octet-stream", e.variables["unknown"])
+	})
+}
+
+func TestMissingPropertyWithoutTry(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  settings:
+    size: small
+  replicas: ${settings.scaling.replicas}
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+}
+
+func TestParsingBuiltins(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  config:
+    fn::fromJSON: '{"name": "web", "ports": [80, 443]}'
+  manifest:
+    fn::fromYAML: |
+      kind: Deployment
+      spec:
+        replicas: 3
+  port: ${config.ports[1]}
+  kind: ${manifest.kind}
+  replicas: ${manifest.spec.replicas}
+  rendered:
+    fn::toYAML:
+      name: ${config.name}
+      replicas: ${replicas}
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, 443.0, e.variables["port"])
+		assert.Equal(t, "Deployment", e.variables["kind"])
+		assert.Equal(t, 3.0, e.variables["replicas"])
+		assert.Equal(t, "name: web\nreplicas: 3\n", e.variables["rendered"])
+	})
+}
+
+func TestParsingBuiltinsMalformed(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  config:
+    fn::fromJSON: '{"name": }'
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:5:19: failed to parse JSON: invalid character '}' looking for beginning of value", diagString(diags[0]))
+}
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  qualify:
+    parameters:
+      name:
+        type: string
+      env:
+        type: string
+        default: dev
+    body: ${env}-${name}
+  bucketName:
+    parameters:
+      name:
+        type: string
+    body:
+      fn::lower:
+        fn::qualify:
+          name: ${name}
+          env: PROD
+variables:
+  name: shadowed
+  short:
+    fn::qualify:
+      name: logs
+  long:
+    fn::call:
+      function: qualify
+      args:
+        name: ${name}
+        env: test
+  bucket:
+    fn::bucketName:
+      name: Assets
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		assert.Equal(t, "dev-logs", e.variables["short"])
+		assert.Equal(t, "test-shadowed", e.variables["long"])
+		assert.Equal(t, "prod-assets", e.variables["bucket"])
+	})
+}
+
+func TestFunctionOutputArguments(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  qualify:
+    parameters:
+      name:
+        type: string
+      env:
+        type: string
+    body: ${name}-${env}
+resources:
+  res-a:
+    type: test:resource:type
+    properties:
+      foo: oof
+variables:
+  qualified:
+    fn::qualify:
+      name: ${res-a.foo}
+      env: prod
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.False(t, e.evalContext.Evaluate(e.pulumiCtx).HasErrors())
+		out := e.variables["qualified"].(pulumi.AnyOutput).ApplyT(func(x interface{}) (interface{}, error) {
+			assert.Equal(t, "qux-prod", x)
+			return nil, nil
+		})
+		e.pulumiCtx.Export("out", out)
+	})
+}
+
+func TestFunctionRecursion(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  countdown:
+    parameters:
+      n:
+        type: number
+    body:
+      fn::if:
+        - fn::equals: [ "${n}", 0 ]
+        - 0
+        - fn::countdown:
+            n:
+              fn::sub: [ "${n}", 1 ]
+variables:
+  done:
+    fn::countdown:
+      n: 3
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, func(e *programEvaluator) {})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "circular dependency of function 'fn::countdown' transitively on itself")
+}
+
+func TestFunctionShorthandRequiresDeclaration(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  shout:
+    parameters:
+      value:
+        type: string
+    body:
+      fn::upper: ${value}
+variables:
+  loud:
+    fn::shout:
+      value: hi
+  typo:
+    fn::jion: [ "-", [ a, b ] ]
+`
+	tmpl := yamlTemplate(t, text)
+	_, diags := TypeCheck(newRunner(tmpl, newMockPackageMap()))
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "'fn::' is a reserved prefix", diags[0].Summary)
+	assert.Equal(t, 15, diags[0].Subject.Start.Line)
+
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.Equal(t, "HI", e.variables["loud"])
+		assert.Equal(t, map[string]interface{}{"fn::jion": []interface{}{"-", []interface{}{"a", "b"}}}, e.variables["typo"])
+	})
+}
+
+func TestFunctionShorthandArguments(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-yaml
+runtime: yaml
+functions:
+  shout:
+    parameters:
+      value:
+        type: string
+    body:
+      fn::upper: ${value}
+variables:
+  loud:
+    fn::shout: [ hi ]
+`
+	tmpl := yamlTemplate(t, text)
+	diags := testTemplateDiags(t, tmpl, nil)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "the argument to fn::shout must be an object of function arguments", diags[0].Summary)
+}
+
+const componentMethodText = `
+name: test
+runtime: yaml
+components:
+  myComponent:
+    inputs:
+      name:
+        type: string
+    outputs:
+      kubeconfig: ${name}
+    methods:
+      getKubeconfig:
+        inputs:
+          profile:
+            type: string
+        variables:
+          config: ${self.kubeconfig}-${profile}
+        outputs:
+          kubeconfig: ${config}
+`
+
+func TestRunComponentMethod(t *testing.T) {
+	t.Parallel()
+
+	template := yamlTemplate(t, strings.TrimSpace(componentMethodText))
+	mocks := &testMonitor{
+		NewResourceF: func(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+			return "", resource.PropertyMap{"kubeconfig": resource.NewStringProperty("abc")}, nil
+		},
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		self := &componentState{}
+		err := ctx.RegisterResource("test:index:myComponent", "myComp", nil, self)
+		require.NoError(t, err)
+
+		outputs, err := RunComponentMethod(ctx, "test:index:myComponent/getKubeconfig", self,
+			template, pulumi.Map{"profile": pulumi.String("admin")}, newMockPackageMap())
+		require.NoError(t, err)
+		require.Contains(t, outputs, "kubeconfig")
+		pulumi.ToOutput(outputs["kubeconfig"]).ApplyT(func(v interface{}) error {
+			assert.Equal(t, "abc-admin", v)
+			return nil
+		})
+
+		_, err = RunComponentMethod(ctx, "test:index:myComponent/getToken", self,
+			template, pulumi.Map{}, newMockPackageMap())
+		assert.EqualError(t, err, "unknown method getToken of component test:index:myComponent")
+
+		_, err = RunComponentMethod(ctx, "test:index:myComponent/getKubeconfig", nil,
+			template, pulumi.Map{}, newMockPackageMap())
+		assert.EqualError(t, err, "method getKubeconfig must be called on an instance of test:index:myComponent")
+		return nil
+	}, pulumi.WithMocks("projectFoo", "stackDev", mocks))
+	require.NoError(t, err)
+}
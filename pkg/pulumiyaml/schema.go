@@ -0,0 +1,244 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// GenerateSchema generates the schema of the components of a template. It extends ast.TemplateDecl.GenerateSchema
+// by type checking each component and typing its outputs as the analyser infers them, rather than as `Any`. An
+// output's type in the `outputTypes` section, if it has one, takes precedence. Inferred object types are added to the
+// schema as types of the package, alongside the declared ones, unless they are declared by the package of a resource
+// the component uses, which the schema refers to.
+//
+// The outputs of the methods of components are typed in the same way. The outputs of a component or method that does
+// not type check are typed as `Any`: its errors are reported when it is run. A warning is returned for each output
+// that is typed as `Any` because its type could not be inferred.
+func GenerateSchema(t *ast.TemplateDecl, loader PackageLoader) (schema.PackageSpec, syntax.Diagnostics, error) {
+	spec, err := t.GenerateSchema()
+	if err != nil {
+		return schema.PackageSpec{}, nil, err
+	}
+
+	var diags syntax.Diagnostics
+	types := schemaTypes{
+		pkg:        spec.Name,
+		specs:      spec.Types,
+		tokens:     map[*schema.ObjectType]string{},
+		components: t.ComponentTypeTokens(),
+	}
+	if types.specs == nil {
+		types.specs = map[string]schema.ComplexTypeSpec{}
+	}
+	for _, component := range t.Components.Entries {
+		resource := spec.Resources[spec.Name+":index:"+component.Key.Value]
+		subject := "component " + component.Key.Value
+		wdiags, err := types.inferOutputs(component.Value, loader, resource.Properties, subject,
+			ast.TypeName(component.Key.Value))
+		if err != nil {
+			return schema.PackageSpec{}, nil, err
+		}
+		diags.Extend(wdiags...)
+		for _, method := range component.Value.Methods.Entries {
+			function := spec.Functions[resource.Methods[method.Key.Value]]
+			subject := fmt.Sprintf("method %s of component %s", method.Key.Value, component.Key.Value)
+			name := ast.TypeName(component.Key.Value, method.Key.Value)
+			wdiags, err := types.inferOutputs(method.Value, loader, function.Outputs.Properties, subject, name)
+			if err != nil {
+				return schema.PackageSpec{}, nil, err
+			}
+			diags.Extend(wdiags...)
+		}
+	}
+	if len(types.specs) != 0 {
+		spec.Types = types.specs
+	}
+	return spec, diags, nil
+}
+
+// schemaTypes converts the types inferred by the analyser to schema type specs.
+type schemaTypes struct {
+	// pkg is the name of the package the schema describes.
+	pkg string
+	// specs holds the object types synthesized for the package, by token.
+	specs map[string]schema.ComplexTypeSpec
+	// tokens holds the token of each object type that has been, or is being, synthesized.
+	tokens map[*schema.ObjectType]string
+	// components holds the tokens that would collide with the components of the package, as
+	// ast.TemplateDecl.ComponentTypeTokens.
+	components map[string]bool
+}
+
+var anyTypeSpec = schema.TypeSpec{Ref: "pulumi.json#/Any"}
+
+// typedTemplate is a template whose outputs may have declared types: a component, or one of its methods.
+type typedTemplate interface {
+	ast.Template
+
+	OutputType(name string) *ast.ConfigParamDecl
+}
+
+// inferOutputs types the properties of the outputs of t whose types are not declared as the analyser infers them.
+// subject describes t in warnings, and name is the prefix of the names of the object types that are synthesized for
+// its outputs. The outputs are typed as `Any` if t does not type check.
+func (s *schemaTypes) inferOutputs(
+	t typedTemplate, loader PackageLoader, properties map[string]schema.PropertySpec, subject, name string,
+) (syntax.Diagnostics, error) {
+	runner := newRunner(t, loader)
+	if err := runner.setPackageDesciptors(); err != nil {
+		return nil, err
+	}
+	typing, tdiags := TypeCheck(runner)
+
+	var diags syntax.Diagnostics
+	for _, output := range t.GetOutputs().Entries {
+		k := output.Key.Value
+		if t.OutputType(k) != nil {
+			continue
+		}
+		typeSpec := anyTypeSpec
+		if !tdiags.HasErrors() {
+			typeSpec = s.typeSpec(typing.TypeOutput(k), name+ast.TypeName(k))
+		}
+		if typeSpec.Ref == anyTypeSpec.Ref {
+			summary := fmt.Sprintf("the type of output %s of %s could not be inferred, so it is typed as Any", k, subject)
+			detail := "Declare the type of the output in outputTypes."
+			if tdiags.HasErrors() {
+				detail = fmt.Sprintf("The %s does not type check. %s", subject, detail)
+			}
+			diags.Extend(syntax.Warning(exprRange(output.Key), summary, detail))
+		}
+		property := properties[k]
+		property.TypeSpec = typeSpec
+		properties[k] = property
+	}
+	return diags, nil
+}
+
+// exprRange returns the range of the syntax of expr, if it has any.
+func exprRange(expr ast.Expr) *hcl.Range {
+	if expr == nil || expr.Syntax() == nil || expr.Syntax().Syntax() == nil {
+		return nil
+	}
+	return expr.Syntax().Syntax().Range()
+}
+
+// typeSpec returns the schema type spec of typ. name is the name given to typ if it is an object type that must be
+// synthesized.
+func (s *schemaTypes) typeSpec(typ schema.Type, name string) schema.TypeSpec {
+	switch typ := typ.(type) {
+	case *schema.InputType:
+		return s.typeSpec(typ.ElementType, name)
+	case *schema.OptionalType:
+		return s.typeSpec(typ.ElementType, name)
+	case *schema.EnumType:
+		return s.typeSpec(typ.ElementType, name)
+	case *schema.TokenType:
+		return s.typeSpec(typ.UnderlyingType, name)
+	case *schema.ArrayType:
+		items := s.typeSpec(typ.ElementType, name+"Item")
+		return schema.TypeSpec{Type: "array", Items: &items}
+	case *schema.MapType:
+		values := s.typeSpec(typ.ElementType, name+"Value")
+		return schema.TypeSpec{Type: "object", AdditionalProperties: &values}
+	case *schema.UnionType:
+		var oneOf []schema.TypeSpec
+		for _, t := range typ.ElementTypes {
+			spec := s.typeSpec(t, name)
+			if spec.Ref == anyTypeSpec.Ref {
+				return anyTypeSpec
+			}
+			oneOf = append(oneOf, spec)
+		}
+		if len(oneOf) == 1 {
+			return oneOf[0]
+		}
+		return schema.TypeSpec{OneOf: oneOf}
+	case *schema.ResourceType:
+		if typ.Resource == nil {
+			return anyTypeSpec
+		}
+		if ref, ok := externalRef(typ.Resource.PackageReference, "resources", typ.Token); ok {
+			return schema.TypeSpec{Ref: ref}
+		}
+		return anyTypeSpec
+	case *schema.ObjectType:
+		if !strings.HasPrefix(typ.Token, adhockObjectToken) {
+			if ref, ok := externalRef(typ.PackageReference, "types", typ.Token); ok {
+				return schema.TypeSpec{Ref: ref}
+			}
+		}
+		return schema.TypeSpec{Ref: "#/types/" + s.objectType(typ, name)}
+	}
+
+	switch typ {
+	case schema.BoolType:
+		return schema.TypeSpec{Type: "boolean"}
+	case schema.IntType:
+		return schema.TypeSpec{Type: "integer"}
+	case schema.NumberType:
+		return schema.TypeSpec{Type: "number"}
+	case schema.StringType:
+		return schema.TypeSpec{Type: "string"}
+	case schema.ArchiveType:
+		return schema.TypeSpec{Ref: "pulumi.json#/Archive"}
+	case schema.AssetType:
+		return schema.TypeSpec{Ref: "pulumi.json#/Asset"}
+	case schema.JSONType:
+		return schema.TypeSpec{Ref: "pulumi.json#/Json"}
+	default:
+		return anyTypeSpec
+	}
+}
+
+// objectType synthesizes typ as an object type of the package, and returns its token.
+func (s *schemaTypes) objectType(typ *schema.ObjectType, name string) string {
+	if token, ok := s.tokens[typ]; ok {
+		return token
+	}
+
+	token := fmt.Sprintf("%s:index:%s", s.pkg, name)
+	for i := 2; ; i++ {
+		if _, ok := s.specs[token]; !ok && !s.components[token] {
+			break
+		}
+		token = fmt.Sprintf("%s:index:%s%d", s.pkg, name, i)
+	}
+	// Record the token before the properties are converted, in case the type refers to itself.
+	s.tokens[typ] = token
+	s.specs[token] = schema.ComplexTypeSpec{}
+
+	spec := schema.ObjectTypeSpec{
+		Type:        "object",
+		Description: typ.Comment,
+		Properties:  map[string]schema.PropertySpec{},
+	}
+	for _, p := range typ.Properties {
+		spec.Properties[p.Name] = schema.PropertySpec{
+			TypeSpec:    s.typeSpec(p.Type, name+ast.TypeName(p.Name)),
+			Description: p.Comment,
+		}
+		if _, optional := p.Type.(*schema.OptionalType); !optional {
+			spec.Required = append(spec.Required, p.Name)
+		}
+	}
+	s.specs[token] = schema.ComplexTypeSpec{ObjectTypeSpec: spec}
+	return token
+}
+
+// externalRef returns a reference to the resource or type with the given token in the schema of another package.
+func externalRef(pkg schema.PackageReference, kind, token string) (string, bool) {
+	if pkg == nil || pkg.Version() == nil {
+		return "", false
+	}
+	return fmt.Sprintf("/%s/v%s/schema.json#/%s/%s", pkg.Name(), pkg.Version(), kind,
+		strings.ReplaceAll(token, "/", "%2F")), true
+}
@@ -0,0 +1,151 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+func TestGenerateSchemaOutputTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: plugin
+runtime: yaml
+components:
+  web-site:
+    inputs:
+      name:
+        type: string
+      replicas:
+        type: integer
+        default: 1
+    resources:
+      res:
+        type: test:resource:type
+        properties:
+          foo: ${name}
+    outputs:
+      label: ${name}-site
+      names: [ "${name}" ]
+      foo: ${res.foo}
+      settings:
+        name: ${name}
+        ports: [ 80, 443 ]
+      replicas: ${replicas}
+      record:
+        type: string
+        value: ${name}
+      unknown: ${res}
+    outputTypes:
+      replicas:
+        type: string
+`
+	tmpl := yamlTemplate(t, text)
+	spec, diags, err := GenerateSchema(tmpl, newMockPackageMap())
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+	assert.Equal(t, "the type of output unknown of component web-site could not be inferred, so it is typed as Any",
+		diags[0].Summary)
+
+	properties := spec.Resources["plugin:index:web-site"].Properties
+	assert.Equal(t, schema.TypeSpec{Type: "string"}, properties["label"].TypeSpec)
+	assert.Equal(t, schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Type: "string"}}, properties["names"].TypeSpec)
+	assert.Equal(t, schema.TypeSpec{Type: "string"}, properties["foo"].TypeSpec)
+	assert.Equal(t, schema.TypeSpec{Ref: "#/types/plugin:index:WebSiteSettings"}, properties["settings"].TypeSpec)
+	assert.Equal(t, schema.TypeSpec{Type: "string"}, properties["replicas"].TypeSpec, "declared types take precedence")
+	assert.Equal(t, schema.TypeSpec{Ref: "#/types/plugin:index:WebSiteRecord"}, properties["record"].TypeSpec,
+		"objects with the keys of a type declaration are literals")
+	assert.Equal(t, schema.TypeSpec{Ref: "pulumi.json#/Any"}, properties["unknown"].TypeSpec)
+
+	assert.Equal(t, map[string]schema.ComplexTypeSpec{
+		"plugin:index:WebSiteRecord": {
+			ObjectTypeSpec: schema.ObjectTypeSpec{
+				Type: "object",
+				Properties: map[string]schema.PropertySpec{
+					"type":  {TypeSpec: schema.TypeSpec{Type: "string"}},
+					"value": {TypeSpec: schema.TypeSpec{Type: "string"}},
+				},
+				Required: []string{"type", "value"},
+			},
+		},
+		"plugin:index:WebSiteSettings": {
+			ObjectTypeSpec: schema.ObjectTypeSpec{
+				Type: "object",
+				Properties: map[string]schema.PropertySpec{
+					"name":  {TypeSpec: schema.TypeSpec{Type: "string"}},
+					"ports": {TypeSpec: schema.TypeSpec{Type: "array", Items: &schema.TypeSpec{Type: "number"}}},
+				},
+				Required: []string{"name", "ports"},
+			},
+		},
+	}, spec.Types)
+}
+
+func TestGenerateSchemaDeclaredTypes(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: plugin
+runtime: yaml
+components:
+  web-site:
+    inputs:
+      settings:
+        enum: [ small, large ]
+    outputs:
+      settings:
+        size: ${settings}
+`
+	tmpl := yamlTemplate(t, text)
+	spec, diags, err := GenerateSchema(tmpl, newMockPackageMap())
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+
+	// The inferred type of the output does not replace the declared type of the input.
+	resource := spec.Resources["plugin:index:web-site"]
+	assert.Equal(t, "#/types/plugin:index:WebSiteSettings", resource.InputProperties["settings"].Ref)
+	assert.Equal(t, "#/types/plugin:index:WebSiteSettings2", resource.Properties["settings"].Ref)
+	assert.Equal(t, "string", spec.Types["plugin:index:WebSiteSettings"].Type)
+	assert.Len(t, spec.Types["plugin:index:WebSiteSettings"].Enum, 2)
+	assert.Equal(t, "object", spec.Types["plugin:index:WebSiteSettings2"].Type)
+}
+
+func TestGenerateSchemaTypeCheckFailure(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: plugin
+runtime: yaml
+components:
+  web-site:
+    resources:
+      res:
+        type: test:resource:type
+        properties:
+          foo: [ 1, 2 ]
+    outputs:
+      label: ${res.foo}
+      name: web
+    outputTypes:
+      name:
+        type: string
+`
+	tmpl := yamlTemplate(t, text)
+	spec, diags, err := GenerateSchema(tmpl, newMockPackageMap())
+	require.NoError(t, err)
+
+	// Only the output whose type is not declared falls back to `Any`, with a warning.
+	properties := spec.Resources["plugin:index:web-site"].Properties
+	assert.Equal(t, schema.TypeSpec{Ref: "pulumi.json#/Any"}, properties["label"].TypeSpec)
+	assert.Equal(t, schema.TypeSpec{Type: "string"}, properties["name"].TypeSpec)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "the type of output label of component web-site could not be inferred, so it is typed as Any",
+		diags[0].Summary)
+	assert.Equal(t, "The component web-site does not type check. Declare the type of the output in outputTypes.",
+		diags[0].Detail)
+}
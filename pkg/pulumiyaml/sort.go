@@ -49,6 +49,45 @@ func (e variableNode) key() *ast.StringExpr {
 	return e.Key
 }
 
+// conditionNode is an entry in the template's conditions section. Conditions are evaluated like variables, but
+// must be booleans.
+type conditionNode ast.VariablesMapEntry
+
+func (e conditionNode) valueKind() string {
+	return "condition"
+}
+
+func (e conditionNode) key() *ast.StringExpr {
+	return e.Key
+}
+
+// functionNode is a function declared in the template's functions section. Functions share the
+// dependency graph with other nodes so that recursion is reported as a cycle, but they are keyed by
+// functionKey so that they do not collide with variables or resources of the same name.
+type functionNode ast.FunctionsMapEntry
+
+func (e functionNode) valueKind() string {
+	return "function"
+}
+
+func (e functionNode) key() *ast.StringExpr {
+	return functionKeyExpr(e.Key)
+}
+
+// functionKey returns the key of the named function in the dependency graph.
+func functionKey(name string) string {
+	return "fn::" + name
+}
+
+// functionKeyExpr returns the key of the named function, positioned at name.
+func functionKeyExpr(name *ast.StringExpr) *ast.StringExpr {
+	node := name.Syntax()
+	if node == nil || node.Syntax() == nil {
+		return ast.String(functionKey(name.Value))
+	}
+	return ast.StringSyntax(syntax.StringSyntax(node.Syntax(), functionKey(name.Value)))
+}
+
 type configNode interface {
 	graphNode
 	value() interface{}
@@ -190,6 +229,45 @@ func topologicallySortedResources(t ast.Template, externalConfig []configNode) (
 		}
 	}
 
+	for _, kvp := range t.GetConditions().Entries {
+		cname := kvp.Key.Value
+		node := conditionNode(kvp)
+
+		cdiags := checkUniqueNode(intermediates, node)
+		diags = append(diags, cdiags...)
+
+		if !cdiags.HasErrors() {
+			addIntermediate(cname, node)
+			dependencies[cname] = GetVariableDependencies(kvp)
+		}
+	}
+
+	for _, kvp := range t.GetFunctions().Entries {
+		node := functionNode(kvp)
+		fname := node.key().Value
+
+		cdiags := checkUniqueNode(intermediates, node)
+		diags = append(diags, cdiags...)
+
+		if !cdiags.HasErrors() {
+			addIntermediate(fname, node)
+			dependencies[fname] = GetFunctionDependencies(kvp.Value)
+		}
+	}
+
+	// Stack configuration may hold any key, but is only in scope if the template declares it.
+	if node, ok := intermediates[RangeVarName]; ok && !isConfigNodeProp(node) {
+		if ref := findRangeReference(t); ref != nil {
+			summary := fmt.Sprintf("%s range is shadowed where it is referred to", node.valueKind())
+			if rng := exprRange(ref); rng != nil {
+				summary += fmt.Sprintf(" at %v", rng)
+			}
+			diags.Extend(ast.ExprError(node.key(), summary,
+				"Within a resource declared with count or forEach, or within fn::for, range refers to the current "+
+					"element."))
+		}
+	}
+
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -198,6 +276,10 @@ func topologicallySortedResources(t ast.Template, externalConfig []configNode) (
 	var visit func(name *ast.StringExpr) bool
 	visit = func(name *ast.StringExpr) bool {
 		e, ok := intermediates[name.Value]
+		if !ok && strings.HasPrefix(name.Value, functionKey("")) {
+			// Calls to undeclared functions are reported where they are made.
+			return true
+		}
 		if !ok {
 			if t.GetName() != nil {
 				s := stripConfigNamespace(t.GetName().Value, name.Value)
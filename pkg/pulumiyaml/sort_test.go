@@ -167,6 +167,102 @@ func TestSortErrorCycle(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSortErrorReservedRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name: "count",
+			text: `name: test-yaml
+runtime: yaml
+variables:
+  range: [ a, b ]
+resources:
+  bucket:
+    type: test:resource:type
+    count: 2
+    properties:
+      foo: ${range}
+`,
+			expected: "<stdin>:4:3: variable range is shadowed where it is referred to at <stdin>:10,12-20; " +
+				"Within a resource declared with count or forEach, or within fn::for, range refers to the current element.",
+		},
+		{
+			name: "fn::for",
+			text: `name: test-yaml
+runtime: yaml
+variables:
+  range: [ a, b ]
+  names:
+    fn::for:
+      in: [ x, y ]
+      value: ${range.value}
+`,
+			expected: "<stdin>:4:3: variable range is shadowed where it is referred to at <stdin>:8,14-28; " +
+				"Within a resource declared with count or forEach, or within fn::for, range refers to the current element.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl := yamlTemplate(t, tt.text)
+			_, diags := topologicallySortedResources(tmpl, nil)
+			require.Len(t, diags, 1)
+			assert.Equal(t, tt.expected, diagString(diags[0]))
+		})
+	}
+}
+
+func TestSortErrorReservedRangeNoSyntax(t *testing.T) {
+	t.Parallel()
+
+	// A reference without syntax, such as one built by a rewrite, is reported without its position.
+	tmpl := yamlTemplate(t, `name: test-yaml
+runtime: yaml
+variables:
+  range: [ a, b ]
+  names: []
+`)
+	value, vdiags := ast.VariableSubstitution("range.value")
+	require.False(t, vdiags.HasErrors())
+	tmpl.Variables.Entries[1].Value = ast.For(ast.List(ast.String("x")), nil, value, nil)
+
+	_, diags := topologicallySortedResources(tmpl, nil)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "<stdin>:4:3: variable range is shadowed where it is referred to; "+
+		"Within a resource declared with count or forEach, or within fn::for, range refers to the current element.",
+		diagString(diags[0]))
+}
+
+func TestSortReservedRangeUnshadowed(t *testing.T) {
+	t.Parallel()
+
+	// A node named range may be referred to outside of ranged resources and fn::for.
+	const text = `name: test-yaml
+runtime: yaml
+variables:
+  range: [ a, b ]
+  names:
+    fn::for:
+      in: ${range}
+      value: name
+resources:
+  bucket:
+    type: test:resource:type
+    properties:
+      foo: ${range}
+`
+	tmpl := yamlTemplate(t, text)
+	resources, diags := topologicallySortedResources(tmpl, nil)
+	requireNoErrors(t, tmpl, diags)
+	assert.Equal(t, []string{"range", "bucket", "names"}, sortedNames(resources))
+}
+
 func sortedNames(rs []graphNode) []string {
 	names := make([]string, len(rs))
 	for i, kvp := range rs {
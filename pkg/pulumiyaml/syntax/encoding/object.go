@@ -23,7 +23,8 @@ import (
 //   - Maps are decoded as *syntax.ObjectNode. Map keys must be strings. Nil maps are deocded as *syntax.NullNode.
 //   - Structs are decoded as *syntax.ObjectNode. Exported struct fields decode into object properties using the name of
 //     the field as the property's key. The name of the struct field can be customized using a struct tag of the form
-//     `object:"name"`. If a field's value decodes as *syntax.NullNode, that field is omitted from the result.
+//     `object:"name"`. If a field's value decodes as *syntax.NullNode, that field is omitted from the result. Fields
+//     tagged `syntax:"-"` are always omitted.
 func DecodeValue(v interface{}) (syntax.Node, syntax.Diagnostics) {
 	return decodeValue(reflect.ValueOf(v))
 }
@@ -103,6 +104,10 @@ func decodeValue(v reflect.Value) (syntax.Node, syntax.Diagnostics) {
 				entries = make([]syntax.ObjectPropertyDef, 0, t.NumField())
 
 				for i := 0; i < t.NumField(); i++ {
+					if tag, ok := t.Field(i).Tag.Lookup("syntax"); ok && tag == "-" {
+						continue
+					}
+
 					vn, fdiags := decodeValue(v.Field(i))
 					diags.Extend(fdiags...)
 
@@ -31,6 +31,33 @@ func (s YAMLSyntax) Range() *hcl.Range {
 	return s.rng
 }
 
+// WithValue returns a copy of s that records value as the node's decoded value. Tag decoders that decode a scalar into
+// a different value use this so that MarshalYAML can tell whether the value has since changed.
+func (s YAMLSyntax) WithValue(value interface{}) YAMLSyntax {
+	s.value = value
+	return s
+}
+
+// isLocalTag returns true if tag is a local tag, such as `!Join`, rather than a tag from the YAML core schema.
+func isLocalTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+func isYAMLSyntax(n syntax.Node) bool {
+	_, ok := n.Syntax().(YAMLSyntax)
+	return ok
+}
+
+// sameYAMLNode returns true if a and b were decoded from the same YAML node.
+func sameYAMLNode(a, b syntax.Node) bool {
+	as, ok := a.Syntax().(YAMLSyntax)
+	if !ok {
+		return false
+	}
+	bs, ok := b.Syntax().(YAMLSyntax)
+	return ok && as.Node != nil && as.Node == bs.Node
+}
+
 // yamlEndPos calculates the end position of a YAML node.
 //
 // For simple scalars, this is reasonably accurate: the end position is (start line + the number of lines, start
@@ -223,6 +250,13 @@ func MarshalYAML(n syntax.Node) (*yaml.Node, syntax.Diagnostics) {
 	case *syntax.StringNode:
 		value := n.Value()
 		yamlNode.Kind = yaml.ScalarNode
+		if isLocalTag(yamlNode.Tag) {
+			if originalValue == value {
+				// The string was decoded from a tag, e.g. `!Ref name`, and is unchanged; keep the tagged form.
+				break
+			}
+			yamlNode.Tag, yamlNode.Style = "", 0
+		}
 		if yamlNode.Tag != "" && yamlNode.Tag != "!!str" {
 			yamlNode.Tag = "!!str"
 		}
@@ -253,6 +287,16 @@ func MarshalYAML(n syntax.Node) (*yaml.Node, syntax.Diagnostics) {
 		}
 		yamlNode.Content = content
 	case *syntax.ObjectNode:
+		// A builtin decoded from a tag, e.g. `!Join [...]`, is an object with a single key that has no YAML node of its own.
+		// If its argument is unchanged, marshal the argument on its own: it carries the tag.
+		if isLocalTag(yamlNode.Tag) && n.Len() == 1 {
+			if kvp := n.Index(0); !isYAMLSyntax(kvp.Key) {
+				if sameYAMLNode(n, kvp.Value) {
+					return MarshalYAML(kvp.Value)
+				}
+				yamlNode.Tag, yamlNode.Style = "", 0
+			}
+		}
 		yamlNode.Kind = yaml.MappingNode
 
 		var content []*yaml.Node
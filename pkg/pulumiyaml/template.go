@@ -19,10 +19,8 @@ type Template struct {
 	// Outputs declares a set of output values that will be exported from the stack and usable from other stacks.
 	Outputs map[string]interface{} `json:",omitempty" yaml:",omitempty"`
 
-	// TODO: Mappings and Conditions
-
 	// Mappings provides the ability to have a static set of maps for programs that need to
-	// perform lookups using fn::FindInMap. For instance, we can map from region name to AMI IDs:
+	// perform lookups using fn::findInMap. For instance, we can map from region name to AMI IDs:
 	//      "Mappings": {
 	//          "RegionMap": {
 	//              "us-east-1"     : { "HVM64": "ami-0ff8a91507f77f867" },
@@ -36,6 +34,7 @@ type Template struct {
 	Mappings map[string]map[string]map[string]string `json:",omitempty" yaml:",omitempty"`
 	// Conditions can optionally contain a set of statements that defines the circumstances under which
 	// entities are created or configured. This can be based on parameters to enable dynamic resource creation.
+	// Each condition is a named boolean expression, which resources reference with `condition: ${name}`.
 	// Read more at https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/conditions-section-structure.html.
 	Conditions map[string]interface{} `json:",omitempty" yaml:",omitempty"`
 }
@@ -122,11 +121,12 @@ type Resource struct {
 	// Options contains all Pulumi resource options used to register the resource.
 	ResourceOptions *ResourceOptions `json:",omitempty" yaml:",omitempty"`
 
-	// TODO: Condition, Metadata
+	// TODO: Metadata
 
-	// Condition makes this resource's creation conditional upon a predefined Condition attribute;
-	// see https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/conditions-section-structure.html.
-	Condition string `json:",omitempty" yaml:",omitempty"`
+	// Condition makes this resource's creation conditional upon a predefined Condition attribute, named by one of the
+	// template's Conditions; see
+	// https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/conditions-section-structure.html.
+	Condition string `json:",omitempty" yaml:",omitempty" syntax:"-"`
 	// Metadata enables arbitrary metadata values to be associated with a resource.
 	Metadata map[string]interface{} `json:",omitempty" yaml:",omitempty"`
 }
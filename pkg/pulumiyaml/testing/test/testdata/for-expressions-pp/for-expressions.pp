@@ -0,0 +1,36 @@
+config "regions" "list(string)" {
+  default = ["us-east-1", "us-west-2"]
+}
+
+config "tags" "map(string)" {
+  default = {
+    env  = "dev"
+    team = "web"
+  }
+}
+
+servers = [
+  { name = "a", port = 80 },
+  { name = "b", port = 8080 },
+]
+
+resource things "other:index:Thing" {
+  options {
+    range = regions
+  }
+  idea = "${range.key}: ${range.value}"
+}
+
+resource answers "other:module:Object" {
+  options {
+    range = length(regions)
+  }
+  answer = range.value * 2
+}
+
+regionNames = [for region in regions : "region-${region}"]
+regionIndexes = { for i, region in regions : region => i }
+westRegions = [for region in regions : region if region != "us-east-1"]
+tagPairs = [for entry in entries(tags) : "${entry.key}=${entry.value}"]
+tagEntries = entries(tags)
+serverNames = servers[*].name
@@ -0,0 +1,24 @@
+config prefix string {
+  default = "demo"
+}
+
+component site "./site" {
+  name = prefix
+  replicas = 2
+}
+
+component workers "./worker" {
+  options {
+    range = 3
+  }
+  idea = "worker-${range.value}"
+}
+
+component anotherSite "./site" {
+  name = "${prefix}-other"
+  replicas = 1
+}
+
+output siteIdea {
+  value = site.idea
+}
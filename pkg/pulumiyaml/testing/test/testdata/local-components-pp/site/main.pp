@@ -0,0 +1,17 @@
+config name string {}
+
+config replicas int {}
+
+label = "${name}-site"
+
+resource thing "other:index:Thing" {
+  idea = label
+}
+
+component worker "../worker" {
+  idea = label
+}
+
+output idea {
+  value = thing.id
+}
@@ -0,0 +1,5 @@
+config idea string {}
+
+resource thing "other:index:Thing" {
+  idea = idea
+}
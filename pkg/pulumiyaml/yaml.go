@@ -3,19 +3,90 @@
 package pulumiyaml
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
 	"gopkg.in/yaml.v3"
 
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
 	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
 )
 
 // The TagDecoder is responsible for decoding YAML tags that represent calls to builtin functions.
 //
-// No tags are presently supported, but the machinery to support tags is useful to preserve until
-// we are confident that we won't re-introduce.
+// A short-form tag is decoded as a call to the builtin of the same name, with the tagged value as its argument:
+// `!Join [",", [a, b]]` is equivalent to `fn::join: [",", [a, b]]`. In addition, the CloudFormation tags `!Ref name`,
+// `!GetAtt name.property` and `!Sub "text ${name}"` are decoded as the interpolated strings `${name}`,
+// `${name.property}` and `text ${name}`. Note that `!Sub` is therefore not the `fn::sub` builtin.
+//
+// Decoded nodes keep their YAML syntax, so encoding.MarshalYAML writes them back in their tagged form.
 var TagDecoder = tagDecoder(0)
 
 type tagDecoder int
 
+// tagBuiltins maps the lowercased name of each builtin that may be written as a tag to its canonical name. Every
+// builtin may be, except for fn::sub: `!Sub` is CloudFormation's string substitution.
+var tagBuiltins = func() map[string]string {
+	m := map[string]string{}
+	for _, name := range ast.BuiltinNames() {
+		if name != "fn::sub" {
+			m[strings.ToLower(strings.TrimPrefix(name, "fn::"))] = name
+		}
+	}
+	// CloudFormation's name for fn::toBase64.
+	m["base64"] = "fn::toBase64"
+	return m
+}()
+
 func (d tagDecoder) DecodeTag(filename string, n *yaml.Node) (syntax.Node, syntax.Diagnostics, bool) {
-	return nil, nil, false
+	if !strings.HasPrefix(n.Tag, "!") || strings.HasPrefix(n.Tag, "!!") {
+		return nil, nil, false
+	}
+	tag := n.Tag[1:]
+
+	value, diags := encoding.UnmarshalYAMLNode(filename, n, d)
+	if value == nil {
+		return nil, diags, true
+	}
+	rng := value.Syntax().Range()
+
+	switch tag {
+	case "Ref", "GetAtt", "Sub":
+		str, ok := value.(*syntax.StringNode)
+		if !ok {
+			diags.Extend(syntax.Error(rng, fmt.Sprintf("the argument to !%s must be a string", tag),
+				"The list forms of !GetAtt and !Sub are not supported; write the reference as a single string."))
+			return value, diags, true
+		}
+		interpolated := str.Value()
+		if tag != "Sub" {
+			interpolated = "${" + interpolated + "}"
+		}
+		var s syntax.Syntax = str.Syntax()
+		if ys, ok := s.(encoding.YAMLSyntax); ok {
+			s = ys.WithValue(interpolated)
+		}
+		return syntax.StringSyntax(s, interpolated), diags, true
+	}
+
+	name, ok := tagBuiltins[strings.ToLower(tag)]
+	if !ok {
+		diags.Extend(syntax.Error(rng, fmt.Sprintf("unknown tag %s", n.Tag),
+			"Tags must name a builtin function, e.g. !Join, or be one of !Ref, !GetAtt or !Sub."))
+		return value, diags, true
+	}
+	key := syntax.StringSyntax(tagKeySyntax{rng}, name)
+	return syntax.ObjectSyntax(value.Syntax(), syntax.ObjectPropertySyntax(value.Syntax(), key, value)), diags, true
+}
+
+// tagKeySyntax is the syntax of the `fn::` key of a builtin decoded from a tag. It shares the tag's range, but has no
+// YAML node of its own.
+type tagKeySyntax struct {
+	rng *hcl.Range
+}
+
+func (s tagKeySyntax) Range() *hcl.Range {
+	return s.rng
 }
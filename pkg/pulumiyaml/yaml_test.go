@@ -0,0 +1,90 @@
+// Copyright 2026, Pulumi Corporation.  All rights reserved.
+
+package pulumiyaml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/ast"
+	"github.com/pulumi/pulumi-yaml/pkg/pulumiyaml/syntax/encoding"
+)
+
+func TestTagDecoder(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-tags
+runtime: yaml
+variables:
+  name: world
+  joined: !Join [",", [a, b]]
+  upper: !Upper ${name}
+  ref: !Ref name
+  sub: !Sub hello ${name}
+  nested: !Join ["-", [!Ref name, !Lower ABC]]
+`
+	tmpl := yamlTemplate(t, text)
+	testTemplate(t, tmpl, func(e *programEvaluator) {
+		assert.Equal(t, "a,b", e.variables["joined"])
+		assert.Equal(t, "WORLD", e.variables["upper"])
+		assert.Equal(t, "world", e.variables["ref"])
+		assert.Equal(t, "hello world", e.variables["sub"])
+		assert.Equal(t, "world-abc", e.variables["nested"])
+	})
+}
+
+func TestTagBuiltins(t *testing.T) {
+	t.Parallel()
+
+	// Every builtin may be written as a tag, except for fn::sub, as !Sub is CloudFormation's string substitution.
+	for _, name := range ast.BuiltinNames() {
+		tag := strings.ToLower(strings.TrimPrefix(name, "fn::"))
+		if name == "fn::sub" {
+			assert.NotContains(t, tagBuiltins, tag)
+		} else {
+			assert.Equal(t, name, tagBuiltins[tag], name)
+		}
+	}
+}
+
+func TestTagDecoderUnknownTag(t *testing.T) {
+	t.Parallel()
+
+	const text = `name: test-tags
+runtime: yaml
+variables:
+  bad: !Frobnicate foo
+`
+	_, diags, err := LoadYAMLBytes("<stdin>", []byte(text))
+	require.NoError(t, err)
+	var diagStrings []string
+	for _, v := range diags {
+		diagStrings = append(diagStrings, diagString(v))
+	}
+	assert.Equal(t, []string{
+		"<stdin>:4:8: unknown tag !Frobnicate; Tags must name a builtin function, e.g. !Join, or be one of !Ref, !GetAtt or !Sub.",
+	}, diagStrings)
+}
+
+func TestTagDecoderRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const text = `variables:
+    joined: !Join [",", [a, b]]
+    ref: !Ref name
+    secret: !Secret ${password}
+    file: !ReadFile ./README.md
+`
+	node, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(text)), TagDecoder)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	var buf bytes.Buffer
+	diags = encoding.EncodeYAML(yaml.NewEncoder(&buf), node)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, text, buf.String())
+}
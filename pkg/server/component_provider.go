@@ -34,6 +34,7 @@ type componentProvider struct {
 	version   string
 	schema    []byte
 	construct provider.ConstructFunc
+	call      provider.CallFunc
 }
 
 // GetPluginInfo returns generic information about this plugin, like its version.
@@ -74,7 +75,7 @@ func (p *componentProvider) Construct(ctx context.Context,
 func (p *componentProvider) Call(ctx context.Context,
 	req *pulumirpc.CallRequest,
 ) (*pulumirpc.CallResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "Call is not yet implemented")
+	return provider.Call(ctx, req, p.host, p.call)
 }
 
 // Cancel signals the provider to gracefully shut down and abort any ongoing resource operations.
@@ -386,10 +386,16 @@ func (host *yamlLanguageHost) RunPlugin(
 	loader := pulumiyaml.NewPackageLoaderFromSchemaLoader(schema.NewCachedLoader(rpcLoader))
 	defer loader.Close()
 
-	schema, err := template.GenerateSchema()
+	schema, sdiags, err := pulumiyaml.GenerateSchema(template, loader)
 	if err != nil {
 		return err
 	}
+	if len(sdiags) != 0 {
+		err := diagWriter.WriteDiagnostics(sdiags.HCL())
+		if err != nil {
+			return err
+		}
+	}
 
 	jsonSchema, err := json.Marshal(schema)
 	if err != nil {
@@ -442,6 +448,22 @@ func (host *yamlLanguageHost) RunPlugin(
 				State: state,
 			}, nil
 		},
+		call: func(ctx *pulumi.Context, tok string, args providersdk.CallArgs) (*providersdk.CallResult, error) {
+			self, err := args.Self()
+			if err != nil {
+				return nil, err
+			}
+			m, err := args.Map()
+			if err != nil {
+				return nil, err
+			}
+			delete(m, "__self__")
+			outputs, err := pulumiyaml.RunComponentMethod(ctx, tok, self, template, m, loader)
+			if err != nil {
+				return nil, err
+			}
+			return &providersdk.CallResult{Return: outputs}, nil
+		},
 	}
 
 	// Fire up a gRPC server, letting the kernel choose a free port for us.